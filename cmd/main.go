@@ -1,29 +1,289 @@
 package main
 
 import (
+	"crypto/rand"
 	"go.uber.org/zap"
+	"net/http"
 	"note-service/internal/app"
+	"note-service/internal/app/admin"
+	"note-service/internal/app/apikey"
+	"note-service/internal/app/attachment"
+	"note-service/internal/app/audit"
+	"note-service/internal/app/comment"
+	"note-service/internal/app/export"
+	"note-service/internal/app/importer"
 	"note-service/internal/app/note"
+	"note-service/internal/app/oidc"
+	"note-service/internal/app/reminder"
+	"note-service/internal/app/template"
 	"note-service/internal/app/user"
+	apikeypkg "note-service/internal/pkg/apikey"
+	attachmentpkg "note-service/internal/pkg/attachment"
+	auditpkg "note-service/internal/pkg/audit"
+	"note-service/internal/pkg/blob"
+	commentpkg "note-service/internal/pkg/comment"
+	exportpkg "note-service/internal/pkg/export"
+	"note-service/internal/pkg/idempotency"
+	importerpkg "note-service/internal/pkg/importer"
+	"note-service/internal/pkg/mail"
 	notepkg "note-service/internal/pkg/note"
+	"note-service/internal/pkg/notify"
+	oidcpkg "note-service/internal/pkg/oidc"
+	"note-service/internal/pkg/ratelimit"
+	reminderpkg "note-service/internal/pkg/reminder"
+	templatepkg "note-service/internal/pkg/template"
 	userpkg "note-service/internal/pkg/user"
+	"os"
+	"strings"
 	"time"
 )
 
 func main() {
 	logger, _ := zap.NewProduction()
 
+	eventBus := notify.NewBus()
+	notifiers := notify.Fanout{eventBus, notify.NewLog(logger.Named("events"))}
+	if url := os.Getenv("NOTE_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, notify.NewWebhook(url, 5*time.Second))
+	}
+	notifier := notify.NewQueue(notifiers, 1000, logger.Named("notify"))
+	go notifier.Run()
+
+	auditService := auditpkg.NewService(auditpkg.NewInMemoryStore(), logger.Named("audit"))
+
 	userStore := userpkg.NewInMemoryStore()
 	userService := userpkg.NewService(userStore)
-	userRouter := user.NewRouter(userService, logger.Named("user-router"))
+	if admins := os.Getenv("ADMIN_USERS"); admins != "" {
+		userService.SetAdmins(strings.Split(admins, ","))
+	}
+	key := tokenKey()
+	mailer := mail.NewQueue(newMailer(logger), 100, logger.Named("mail-queue"))
+	go mailer.Run()
+	userRecovery := userpkg.NewRecovery(userStore, mailer, key, userpkg.Links{
+		ResetPassword: appURL() + "/reset-password",
+		VerifyEmail:   appURL() + "/verify-email",
+	})
+	userMFA := userpkg.NewMFA(userStore, key, "note-service")
+	loginThrottle := userpkg.NewThrottle(userpkg.DefaultThrottleConfig)
+	userService.SetThrottle(loginThrottle)
+	userMFA.SetThrottle(loginThrottle)
+	userRouter := user.NewRouter(userService, userRecovery, userMFA, auditService, logger.Named("user-router"))
+	oidcService := oidcpkg.NewService(oidcpkg.NewInMemoryStore(), oidcProviders()...)
+	oidcRouter := oidc.NewRouter(oidcService, userpkg.NewSSO(userStore), userMFA, auditService, logger.Named("oidc-router"))
+
+	keyStore := apikeypkg.NewInMemoryStore()
+	keyService := apikeypkg.NewService(keyStore, userStore)
+	app.SetKeyAuthenticator(keyService)
+	keyRouter := apikey.NewRouter(keyService, logger.Named("api-key-router"))
 
 	noteStore := notepkg.NewInMemoryStore(logger.Named("note-store"))
+	noteStore.SetExpirationLeadTimes(24*time.Hour, time.Hour)
 	noteService := notepkg.NewService(noteStore)
-	noteRouter := note.NewRouter(noteService, logger.Named("note-router"))
-	noteExpService := notepkg.NewExpService(noteStore, 10*time.Second, logger.Named("note-exp-service"))
+	noteStore.OnRemove(noteService.ForgetRendered)
+	noteRouter := note.NewRouter(noteService, auditService, logger.Named("note-router"))
+	noteExpService := notepkg.NewExpService(noteStore, 100, notifier, logger.Named("note-exp-service"))
+	noteExpService.OnExpire(func(e notepkg.Expiration) {
+		auditService.Record(auditpkg.Event{
+			Action:  auditpkg.ActionNoteExpire,
+			ActorID: auditpkg.ActorSystem,
+		}.On(auditpkg.TargetNote, e.NoteID, e.UserID))
+	})
 	go noteExpService.Run()
 
-	router := app.NewRouter(logger.Named("router"), userRouter, noteRouter)
+	reminderStore := reminderpkg.NewInMemoryStore()
+	noteStore.OnRemove(func(n notepkg.Note) { reminderStore.DeleteNoteReminders(n.ID) })
+	reminderService := reminderpkg.NewService(reminderStore, noteService)
+	reminderRouter := reminder.NewRouter(reminderService, eventBus, logger.Named("reminder-router"))
+	reminderDispatcher := reminderpkg.NewDispatcher(reminderStore, noteService, 100, notifier, logger.Named("reminder-dispatcher"))
+	go reminderDispatcher.Run()
+
+	attachmentStore := attachmentpkg.NewInMemoryStore()
+	attachmentLimits := attachmentpkg.Limits{
+		MaxSize: 10 << 20,
+		Quota:   100 << 20,
+	}
+	attachmentService := attachmentpkg.NewService(attachmentStore, newBlobStore(), noteService, attachmentLimits)
+	noteStore.OnRemove(func(n notepkg.Note) {
+		if err := attachmentService.DeleteNoteAttachments(n.ID); err != nil {
+			logger.Error("failed to delete note attachments", zap.String("noteID", n.ID), zap.Error(err))
+		}
+	})
+	attachmentRouter := attachment.NewRouter(attachmentService, attachmentLimits.MaxSize, logger.Named("attachment-router"))
+
+	commentStore := commentpkg.NewInMemoryStore()
+	noteStore.OnRemove(func(n notepkg.Note) { commentStore.DeleteNoteComments(n.ID) })
+	commentService := commentpkg.NewService(commentStore, noteService)
+	commentRouter := comment.NewRouter(commentService, logger.Named("comment-router"))
+
+	exportDir := os.Getenv("EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = "exports"
+	}
+	exportService := exportpkg.NewService(exportpkg.NewInMemoryStore(), noteService, attachmentService, exportDir, exportRetention(), logger.Named("export-service"))
+	go exportService.Run()
+	exportRouter := export.NewRouter(exportService, logger.Named("export-router"))
+
+	importDir := os.Getenv("IMPORT_DIR")
+	if importDir == "" {
+		importDir = "imports"
+	}
+	importService := importerpkg.NewService(importerpkg.NewInMemoryStore(), noteService, attachmentService, importDir, logger.Named("import-service"))
+	importService.OnCreate(func(n notepkg.Note, jobID string) {
+		auditService.Record(auditpkg.Event{
+			Action:  auditpkg.ActionNoteCreate,
+			ActorID: n.UserID,
+		}.On(auditpkg.TargetNote, n.ID, n.UserID).With("importJobId", jobID))
+	})
+	importRouter := importer.NewRouter(importService, logger.Named("import-router"))
+
+	templateStore := templatepkg.NewInMemoryStore()
+	templateService := templatepkg.NewService(templateStore, noteService, userStore)
+	templateRouter := template.NewRouter(templateService, logger.Named("template-router"))
+	templateScheduler := templatepkg.NewScheduler(templateStore, templateService, 100, logger.Named("template-scheduler"))
+	templateScheduler.OnCreate(func(n notepkg.Note, templateID string) {
+		auditService.Record(auditpkg.Event{
+			Action:  auditpkg.ActionNoteCreate,
+			ActorID: auditpkg.ActorSystem,
+		}.On(auditpkg.TargetNote, n.ID, n.UserID).With("templateId", templateID))
+	})
+	go templateScheduler.Run()
+
+	adminRouter := admin.NewRouter(userService, userRecovery, noteService, auditService, logger.Named("admin-router"))
+	auditRouter := audit.NewRouter(auditService, userService, logger.Named("audit-router"))
+
+	userStore.OnDelete(func(u userpkg.User) {
+		noteStore.DeleteUserNotes(u.ID)
+		templateStore.DeleteUserTemplates(u.ID)
+		keyStore.DeleteUserKeys(u.ID)
+		commentStore.DeleteUserComments(u.ID)
+		reminderStore.DeleteUserReminders(u.ID)
+		exportService.DeleteUserJobs(u.ID)
+		importService.DeleteUserJobs(u.ID)
+	})
+
+	router := app.NewRouter(logger.Named("router"), userRouter, oidcRouter, keyRouter, noteRouter, reminderRouter, templateRouter, attachmentRouter, commentRouter, exportRouter, importRouter, adminRouter, auditRouter)
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		logger.Fatal("invalid TRUSTED_PROXIES", zap.Error(err))
+	}
+	router.Use(app.RequestIDMiddleware())
+	limits, err := ratelimit.ParseRules(rateLimits())
+	if err != nil {
+		logger.Fatal("invalid RATE_LIMITS", zap.Error(err))
+	}
+	router.Use(app.RateLimitMiddleware(ratelimit.NewInMemoryStore(), limits))
+	router.Use(app.IdempotencyMiddleware(idempotency.NewInMemoryStore(), idempotencyWindow()))
 	router.SetUpRouter()
 	router.Run()
 }
+
+// newBlobStore uses S3-compatible storage when ATTACHMENT_S3_ENDPOINT is set and local directory otherwise
+func newBlobStore() blob.Store {
+	if endpoint := os.Getenv("ATTACHMENT_S3_ENDPOINT"); endpoint != "" {
+		return blob.NewS3(blob.S3Config{
+			Endpoint:  endpoint,
+			Region:    os.Getenv("ATTACHMENT_S3_REGION"),
+			Bucket:    os.Getenv("ATTACHMENT_S3_BUCKET"),
+			AccessKey: os.Getenv("ATTACHMENT_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("ATTACHMENT_S3_SECRET_KEY"),
+		}, &http.Client{Timeout: time.Minute})
+	}
+	dir := os.Getenv("ATTACHMENT_DIR")
+	if dir == "" {
+		dir = "attachments"
+	}
+	return blob.NewFS(dir)
+}
+
+// idempotencyWindow is how long responses are kept for Idempotency-Key, IDEMPOTENCY_WINDOW overrides 24 hours
+func idempotencyWindow() time.Duration {
+	if window, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_WINDOW")); err == nil && window > 0 {
+		return window
+	}
+	return 24 * time.Hour
+}
+
+// exportRetention is how long finished export archives are kept, EXPORT_RETENTION overrides 24 hours
+func exportRetention() time.Duration {
+	if retention, err := time.ParseDuration(os.Getenv("EXPORT_RETENTION")); err == nil && retention > 0 {
+		return retention
+	}
+	return 24 * time.Hour
+}
+
+// newMailer sends mail through SMTP_ADDR when it is set and writes it to the log otherwise
+func newMailer(logger *zap.Logger) mail.Mailer {
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		return mail.NewSMTP(mail.SMTPConfig{
+			Addr:     addr,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+	}
+	return mail.NewLog(logger.Named("mail"))
+}
+
+// tokenKey signs password reset, email verification and 2FA login tokens, without TOKEN_KEY tokens don't survive a restart
+func tokenKey() []byte {
+	if key := os.Getenv("TOKEN_KEY"); key != "" {
+		return []byte(key)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// rateLimits are per route limits, RATE_LIMITS overrides the default rules and "" turns limiting off
+func rateLimits() string {
+	if limits, ok := os.LookupEnv("RATE_LIMITS"); ok {
+		return limits
+	}
+	return "POST /user=10/1h,POST /user/login=30/m,POST /note=60/m,*=600/m"
+}
+
+// trustedProxies may set X-Forwarded-For, by default the client IP is the address of the connection
+func trustedProxies() []string {
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		return strings.Split(proxies, ",")
+	}
+	return nil
+}
+
+// oidcProviders reads OIDC_PROVIDERS, a comma separated list of names, and OIDC_<NAME>_* settings of each provider
+func oidcProviders() []*oidcpkg.Provider {
+	var providers []*oidcpkg.Provider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		redirectURL := os.Getenv(prefix + "REDIRECT_URL")
+		if redirectURL == "" {
+			redirectURL = appURL() + "/oidc/" + name + "/callback"
+		}
+		var scopes []string
+		if s := os.Getenv(prefix + "SCOPES"); s != "" {
+			scopes = strings.Split(s, ",")
+		}
+		providers = append(providers, oidcpkg.NewProvider(oidcpkg.Config{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+		}, &http.Client{Timeout: 10 * time.Second}))
+	}
+	return providers
+}
+
+// appURL is where links in mail lead, APP_URL overrides the local address
+func appURL() string {
+	if url := os.Getenv("APP_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:8080"
+}
@@ -0,0 +1,39 @@
+package admin
+
+import (
+	notepkg "note-service/internal/pkg/note"
+	userpkg "note-service/internal/pkg/user"
+)
+
+func userToUserResponse(u userpkg.User) UserResponse {
+	return UserResponse{
+		ID:            u.ID,
+		Username:      u.Username,
+		DisplayName:   u.DisplayName,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		Disabled:      u.Disabled,
+		MFAEnabled:    u.TOTPEnabled,
+		CreatedAt:     u.CreatedAt,
+	}
+}
+
+func usersToUsersResponse(users []userpkg.User, total int) UsersResponse {
+	res := UsersResponse{Users: make([]UserResponse, len(users)), Total: total}
+	for i, u := range users {
+		res.Users[i] = userToUserResponse(u)
+	}
+	return res
+}
+
+func statsToStatsResponse(users userpkg.UserStats, notes notepkg.Stats) StatsResponse {
+	return StatsResponse{
+		Users:         users.Users,
+		Admins:        users.Admins,
+		DisabledUsers: users.Disabled,
+		Notes:         notes.Notes,
+		PublicNotes:   notes.Public,
+		SharedNotes:   notes.Shared,
+	}
+}
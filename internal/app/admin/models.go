@@ -0,0 +1,39 @@
+package admin
+
+import "time"
+
+type UserResponse struct {
+	ID            string    `json:"id"`
+	Username      string    `json:"username"`
+	DisplayName   string    `json:"displayName"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"emailVerified"`
+	Role          string    `json:"role"`
+	Disabled      bool      `json:"disabled"`
+	MFAEnabled    bool      `json:"mfaEnabled"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type UsersResponse struct {
+	Users []UserResponse `json:"users"`
+	Total int            `json:"total"`
+}
+
+type SearchRequest struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+type RoleRequest struct {
+	Role string `json:"role"`
+}
+
+type StatsResponse struct {
+	Users         int `json:"users"`
+	Admins        int `json:"admins"`
+	DisabledUsers int `json:"disabledUsers"`
+	Notes         int `json:"notes"`
+	PublicNotes   int `json:"publicNotes"`
+	SharedNotes   int `json:"sharedNotes"`
+}
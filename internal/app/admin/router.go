@@ -0,0 +1,192 @@
+package admin
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"note-service/internal/app"
+	"note-service/internal/pkg/audit"
+	notepkg "note-service/internal/pkg/note"
+	userpkg "note-service/internal/pkg/user"
+	"strconv"
+)
+
+type userService interface {
+	SearchUsers(query string, limit, offset int) ([]userpkg.User, int, error)
+	SetDisabled(id string, disabled bool) (userpkg.User, error)
+	SetRole(id, role string) (userpkg.User, error)
+	UnlockUser(id string) error
+	IsAdmin(id string) bool
+	Stats() (userpkg.UserStats, error)
+}
+
+type recoveryService interface {
+	ForceReset(userID string) error
+}
+
+type noteService interface {
+	TakeDown(id string) (notepkg.Note, error)
+	Stats() notepkg.Stats
+}
+
+type auditLog interface {
+	Record(e audit.Event)
+}
+
+// Router is the API for admins, every action of an admin is written to the audit log
+type Router struct {
+	users    userService
+	recovery recoveryService
+	notes    noteService
+	audit    auditLog
+	logger   *zap.Logger
+}
+
+func NewRouter(users userService, recovery recoveryService, notes noteService, auditLog auditLog, logger *zap.Logger) *Router {
+	return &Router{users: users, recovery: recovery, notes: notes, audit: auditLog, logger: logger}
+}
+
+func (r *Router) SetUpRouter(engine *gin.Engine) {
+	admin := engine.Group("/admin", app.AuthMiddleware(), app.AdminMiddleware(r.users))
+	admin.GET("/users", r.searchUsers)
+	admin.POST("/users/:id/disable", r.setDisabled(true))
+	admin.POST("/users/:id/enable", r.setDisabled(false))
+	admin.PUT("/users/:id/role", r.setRole)
+	admin.POST("/users/:id/unlock", r.unlockUser)
+	admin.POST("/users/:id/password/reset", r.forceReset)
+	admin.DELETE("/notes/:id", r.takeDown)
+	admin.GET("/stats", r.getStats)
+}
+
+func (r *Router) searchUsers(c *gin.Context) {
+	limit, errLimit := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, errOffset := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	request := SearchRequest{Query: c.Query("q"), Limit: limit, Offset: offset}
+	if errLimit != nil {
+		request.Limit = -1
+	}
+	if errOffset != nil {
+		request.Offset = -1
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	users, total, err := r.users.SearchUsers(request.Query, request.Limit, request.Offset)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, usersToUsersResponse(users, total))
+}
+
+func (r *Router) setDisabled(disabled bool) gin.HandlerFunc {
+	action := audit.ActionUserEnable
+	if disabled {
+		action = audit.ActionUserDisable
+	}
+	return func(c *gin.Context) {
+		if disabled && c.Param("id") == c.GetString("userId") {
+			c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: ErrSelf.Error()})
+			return
+		}
+		u, err := r.users.SetDisabled(c.Param("id"), disabled)
+		if err != nil {
+			r.handleError(c, err)
+			return
+		}
+		r.record(c, action, u.ID)
+		if disabled {
+			r.record(c, audit.ActionTokensRevoked, u.ID, "reason", "disabled")
+		}
+		c.IndentedJSON(http.StatusOK, userToUserResponse(u))
+	}
+}
+
+func (r *Router) setRole(c *gin.Context) {
+	var request RoleRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	if request.Role != userpkg.RoleAdmin && c.Param("id") == c.GetString("userId") {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: ErrSelf.Error()})
+		return
+	}
+	u, err := r.users.SetRole(c.Param("id"), request.Role)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.record(c, audit.ActionUserRole, u.ID, "role", u.Role)
+	c.IndentedJSON(http.StatusOK, userToUserResponse(u))
+}
+
+// unlockUser lifts the lockout after failed logins
+func (r *Router) unlockUser(c *gin.Context) {
+	if err := r.users.UnlockUser(c.Param("id")); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.record(c, audit.ActionUserUnlock, c.Param("id"))
+	c.IndentedJSON(http.StatusOK, gin.H{"user": "user successfully unlocked"})
+}
+
+// forceReset makes the user set a new password by the link sent to their email
+func (r *Router) forceReset(c *gin.Context) {
+	if err := r.recovery.ForceReset(c.Param("id")); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.record(c, audit.ActionPasswordReset, c.Param("id"))
+	r.record(c, audit.ActionTokensRevoked, c.Param("id"), "reason", "admin_reset")
+	c.IndentedJSON(http.StatusAccepted, gin.H{"user": "password was reset, the reset link was sent to the user"})
+}
+
+// takeDown deletes a note of any user, reason is kept in the audit log
+func (r *Router) takeDown(c *gin.Context) {
+	n, err := r.notes.TakeDown(c.Param("id"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.audit.Record(app.AuditEvent(c, audit.ActionNoteTakeDown).
+		On(audit.TargetNote, n.ID, n.UserID).
+		With("reason", c.Query("reason")))
+	c.IndentedJSON(http.StatusOK, gin.H{"note": "note successfully taken down"})
+}
+
+func (r *Router) getStats(c *gin.Context) {
+	users, err := r.users.Stats()
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, statsToStatsResponse(users, r.notes.Stats()))
+}
+
+// record writes an admin action on the user to the audit log, details go in key and value pairs
+func (r *Router) record(c *gin.Context, action, userID string, details ...string) {
+	e := app.AuditEvent(c, action).On(audit.TargetUser, userID, userID)
+	for i := 0; i+1 < len(details); i += 2 {
+		e = e.With(details[i], details[i+1])
+	}
+	r.audit.Record(e)
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, userpkg.ErrUserNotFound), errors.Is(err, notepkg.ErrNoteNotFound):
+		c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, userpkg.ErrNoEmail), errors.Is(err, userpkg.ErrRole):
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+	default:
+		r.logger.Error("failed to handle admin action", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+	}
+}
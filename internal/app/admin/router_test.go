@@ -0,0 +1,269 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/app"
+	"note-service/internal/pkg/audit"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/note"
+	"note-service/internal/pkg/user"
+	"testing"
+	"time"
+)
+
+type userServiceMock struct {
+	SearchUsersFunc func(query string, limit, offset int) ([]user.User, int, error)
+	SetDisabledFunc func(id string, disabled bool) (user.User, error)
+	SetRoleFunc     func(id, role string) (user.User, error)
+	UnlockUserFunc  func(id string) error
+	StatsFunc       func() (user.UserStats, error)
+}
+
+func (m *userServiceMock) SearchUsers(query string, limit, offset int) ([]user.User, int, error) {
+	return m.SearchUsersFunc(query, limit, offset)
+}
+
+func (m *userServiceMock) SetDisabled(id string, disabled bool) (user.User, error) {
+	return m.SetDisabledFunc(id, disabled)
+}
+
+func (m *userServiceMock) SetRole(id, role string) (user.User, error) {
+	return m.SetRoleFunc(id, role)
+}
+
+func (m *userServiceMock) UnlockUser(id string) error {
+	return m.UnlockUserFunc(id)
+}
+
+// IsAdmin treats only the user "admin" as an admin
+func (m *userServiceMock) IsAdmin(id string) bool {
+	return id == "admin"
+}
+
+func (m *userServiceMock) Stats() (user.UserStats, error) {
+	return m.StatsFunc()
+}
+
+type auditLogMock struct {
+	events []audit.Event
+}
+
+func (m *auditLogMock) Record(e audit.Event) {
+	m.events = append(m.events, e)
+}
+
+type recoveryServiceMock struct {
+	ForceResetFunc func(userID string) error
+}
+
+func (m *recoveryServiceMock) ForceReset(userID string) error {
+	return m.ForceResetFunc(userID)
+}
+
+type noteServiceMock struct {
+	TakeDownFunc func(id string) (note.Note, error)
+	StatsFunc    func() note.Stats
+}
+
+func (m *noteServiceMock) TakeDown(id string) (note.Note, error) {
+	return m.TakeDownFunc(id)
+}
+
+func (m *noteServiceMock) Stats() note.Stats {
+	return m.StatsFunc()
+}
+
+func TestAdminRoutes(t *testing.T) {
+	target := user.User{ID: "123-123", Username: "username1", Role: user.RoleUser,
+		CreatedAt: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)}
+	disabled := target
+	disabled.Disabled = true
+	tests := []struct {
+		name            string
+		actor           string
+		userService     userServiceMock
+		recoveryService recoveryServiceMock
+		noteService     noteServiceMock
+		method          string
+		path            string
+		body            any
+		expectedCode    int
+		expectedBody    any
+		expectedAudit   string
+	}{
+		{
+			name:         "should forbid not admins",
+			actor:        "123-123",
+			method:       http.MethodGet,
+			path:         "/admin/stats",
+			expectedCode: http.StatusForbidden,
+			expectedBody: app.ErrorModel{Error: app.ErrNoAccess.Error()},
+		},
+		{
+			name:   "should search users",
+			method: http.MethodGet,
+			path:   "/admin/users?q=user&limit=10&offset=20",
+			userService: userServiceMock{
+				SearchUsersFunc: func(query string, limit, offset int) ([]user.User, int, error) {
+					if query != "user" || limit != 10 || offset != 20 {
+						return nil, 0, errors.New("unexpected search")
+					}
+					return []user.User{target}, 21, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: UsersResponse{Users: []UserResponse{userToUserResponse(target)}, Total: 21},
+		},
+		{
+			name:         "should validate search",
+			method:       http.MethodGet,
+			path:         "/admin/users?limit=1000&offset=x",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ValidationErrors{Errors: map[string]string{
+				"limit":  ErrLimit.Error(),
+				"offset": ErrOffset.Error(),
+			}},
+		},
+		{
+			name:   "should disable user",
+			method: http.MethodPost,
+			path:   "/admin/users/123-123/disable",
+			userService: userServiceMock{
+				SetDisabledFunc: func(id string, d bool) (user.User, error) {
+					return disabled, nil
+				},
+			},
+			expectedCode:  http.StatusOK,
+			expectedBody:  userToUserResponse(disabled),
+			expectedAudit: audit.ActionUserDisable,
+		},
+		{
+			name:         "should not disable themselves",
+			method:       http.MethodPost,
+			path:         "/admin/users/admin/disable",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ErrorModel{Error: ErrSelf.Error()},
+		},
+		{
+			name:   "should return ErrUserNotFound",
+			method: http.MethodPost,
+			path:   "/admin/users/unknown/enable",
+			userService: userServiceMock{
+				SetDisabledFunc: func(id string, d bool) (user.User, error) {
+					return user.User{}, user.ErrUserNotFound
+				},
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: app.ErrorModel{Error: user.ErrUserNotFound.Error()},
+		},
+		{
+			name:         "should validate role",
+			method:       http.MethodPut,
+			path:         "/admin/users/123-123/role",
+			body:         RoleRequest{Role: "owner"},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ValidationErrors{Errors: map[string]string{"role": ErrRole.Error()}},
+		},
+		{
+			name:   "should unlock user",
+			method: http.MethodPost,
+			path:   "/admin/users/123-123/unlock",
+			userService: userServiceMock{
+				UnlockUserFunc: func(id string) error {
+					return nil
+				},
+			},
+			expectedCode:  http.StatusOK,
+			expectedAudit: audit.ActionUserUnlock,
+		},
+		{
+			name:   "should return ErrNoEmail on forced reset",
+			method: http.MethodPost,
+			path:   "/admin/users/123-123/password/reset",
+			recoveryService: recoveryServiceMock{
+				ForceResetFunc: func(userID string) error {
+					return user.ErrNoEmail
+				},
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ErrorModel{Error: user.ErrNoEmail.Error()},
+		},
+		{
+			name:   "should take down note",
+			method: http.MethodDelete,
+			path:   "/admin/notes/note?reason=spam",
+			noteService: noteServiceMock{
+				TakeDownFunc: func(id string) (note.Note, error) {
+					return note.Note{ID: id, UserID: "123-123"}, nil
+				},
+			},
+			expectedCode:  http.StatusOK,
+			expectedAudit: audit.ActionNoteTakeDown,
+		},
+		{
+			name:   "should return stats",
+			method: http.MethodGet,
+			path:   "/admin/stats",
+			userService: userServiceMock{
+				StatsFunc: func() (user.UserStats, error) {
+					return user.UserStats{Users: 3, Admins: 1, Disabled: 1}, nil
+				},
+			},
+			noteService: noteServiceMock{
+				StatsFunc: func() note.Stats {
+					return note.Stats{Notes: 5, Public: 2, Shared: 1}
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: StatsResponse{Users: 3, Admins: 1, DisabledUsers: 1, Notes: 5, PublicNotes: 2, SharedNotes: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			auditLog := &auditLogMock{}
+			r := NewRouter(&tt.userService, &tt.recoveryService, &tt.noteService, auditLog, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			var body []byte
+			if tt.body != nil {
+				body, _ = json.Marshal(tt.body)
+			}
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewReader(body))
+			actor := tt.actor
+			if actor == "" {
+				actor = "admin"
+			}
+			token, _ := jwt.CreateToken(actor)
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != nil {
+				expected, err := json.Marshal(tt.expectedBody)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), w.Body.String())
+			}
+			if tt.expectedAudit == "" {
+				assert.Empty(t, auditLog.events)
+				return
+			}
+			if assert.NotEmpty(t, auditLog.events) {
+				e := auditLog.events[0]
+				assert.Equal(t, tt.expectedAudit, e.Action)
+				assert.Equal(t, "admin", e.ActorID)
+				assert.Equal(t, "123-123", e.OwnerID)
+			}
+		})
+	}
+}
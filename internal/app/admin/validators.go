@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"errors"
+	"note-service/internal/app"
+	userpkg "note-service/internal/pkg/user"
+)
+
+var (
+	ErrLimit  = errors.New("limit must be from 1 to 100")
+	ErrOffset = errors.New("offset must be a non-negative number")
+	ErrRole   = errors.New("role must be user or admin")
+	ErrSelf   = errors.New("admins can't disable or demote themselves")
+)
+
+// maxLimit is the largest page of users
+const maxLimit = 100
+
+func (r SearchRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if r.Limit < 1 || r.Limit > maxLimit {
+		ve.Errors["limit"] = ErrLimit.Error()
+	}
+	if r.Offset < 0 {
+		ve.Errors["offset"] = ErrOffset.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r RoleRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if r.Role != userpkg.RoleUser && r.Role != userpkg.RoleAdmin {
+		ve.Errors["role"] = ErrRole.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
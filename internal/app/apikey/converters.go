@@ -0,0 +1,38 @@
+package apikey
+
+import (
+	apikeypkg "note-service/internal/pkg/apikey"
+	"time"
+)
+
+func keyToKeyResponse(key apikeypkg.Key) KeyResponse {
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return KeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Hint:       key.Hint,
+		Scopes:     scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func keysToKeyResponses(keys []apikeypkg.Key) []KeyResponse {
+	res := make([]KeyResponse, len(keys))
+	for i, k := range keys {
+		res[i] = keyToKeyResponse(k)
+	}
+	return res
+}
+
+func expiresAtToTime(expiresAt *int64) *time.Time {
+	if expiresAt == nil {
+		return nil
+	}
+	t := time.Unix(*expiresAt, 0).UTC()
+	return &t
+}
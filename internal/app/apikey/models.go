@@ -0,0 +1,25 @@
+package apikey
+
+import "time"
+
+type KeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedKeyResponse is the only response with the secret of the key
+type CreatedKeyResponse struct {
+	KeyResponse
+	Key string `json:"key"`
+}
+
+type PostRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt *int64   `json:"expiresAt"`
+}
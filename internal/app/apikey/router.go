@@ -0,0 +1,88 @@
+package apikey
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"note-service/internal/app"
+	apikeypkg "note-service/internal/pkg/apikey"
+	"time"
+)
+
+type keyService interface {
+	CreateKey(userID, name string, scopes []string, expiresAt *time.Time) (apikeypkg.Key, string, error)
+	GetUserKeys(userID string) ([]apikeypkg.Key, error)
+	RevokeKey(id, userID string) error
+}
+
+type Router struct {
+	service keyService
+	logger  *zap.Logger
+}
+
+func NewRouter(service keyService, logger *zap.Logger) *Router {
+	return &Router{service: service, logger: logger}
+}
+
+// SetUpRouter registers routes that manage keys, they can't be called with an API key itself
+func (r *Router) SetUpRouter(engine *gin.Engine) {
+	engine.POST("/user/me/keys", app.AuthMiddleware(), r.sessionOnly, r.postKey)
+	engine.GET("/user/me/keys", app.AuthMiddleware(), r.sessionOnly, r.getKeys)
+	engine.DELETE("/user/me/keys/:id", app.AuthMiddleware(), r.sessionOnly, r.revokeKey)
+}
+
+func (r *Router) sessionOnly(c *gin.Context) {
+	if c.GetString("apiKeyId") != "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, app.ErrorModel{Error: app.ErrNoAccess.Error()})
+	}
+}
+
+func (r *Router) postKey(c *gin.Context) {
+	var request PostRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	key, secret, err := r.service.CreateKey(c.GetString("userId"), request.Name, request.Scopes, expiresAtToTime(request.ExpiresAt))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("api key is created", zap.String("keyID", key.ID))
+	c.IndentedJSON(http.StatusCreated, CreatedKeyResponse{KeyResponse: keyToKeyResponse(key), Key: secret})
+}
+
+func (r *Router) getKeys(c *gin.Context) {
+	keys, err := r.service.GetUserKeys(c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, keysToKeyResponses(keys))
+}
+
+func (r *Router) revokeKey(c *gin.Context) {
+	if err := r.service.RevokeKey(c.Param("id"), c.GetString("userId")); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("api key is revoked", zap.String("keyID", c.Param("id")))
+	c.IndentedJSON(http.StatusOK, gin.H{"key": "api key successfully revoked"})
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apikeypkg.ErrKeyNotFound):
+		c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, apikeypkg.ErrScope):
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+	default:
+		r.logger.Error("failed to handle api key", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+	}
+}
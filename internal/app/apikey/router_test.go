@@ -0,0 +1,196 @@
+package apikey
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/app"
+	"note-service/internal/pkg/apikey"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/scope"
+	userpkg "note-service/internal/pkg/user"
+	"testing"
+	"time"
+)
+
+type keyServiceMock struct {
+	CreateKeyFunc   func(userID, name string, scopes []string, expiresAt *time.Time) (apikey.Key, string, error)
+	GetUserKeysFunc func(userID string) ([]apikey.Key, error)
+	RevokeKeyFunc   func(id, userID string) error
+}
+
+func (m *keyServiceMock) CreateKey(userID, name string, scopes []string, expiresAt *time.Time) (apikey.Key, string, error) {
+	return m.CreateKeyFunc(userID, name, scopes, expiresAt)
+}
+
+func (m *keyServiceMock) GetUserKeys(userID string) ([]apikey.Key, error) {
+	return m.GetUserKeysFunc(userID)
+}
+
+func (m *keyServiceMock) RevokeKey(id, userID string) error {
+	return m.RevokeKeyFunc(id, userID)
+}
+
+func TestKeyRoutes(t *testing.T) {
+	created := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	expires := created.Add(24 * time.Hour)
+	key := apikey.Key{ID: "key", UserID: "123-123", Name: "ci", Hint: "abcd", Scopes: []string{scope.NotesRead},
+		ExpiresAt: &expires, CreatedAt: created}
+	past := time.Now().Add(-time.Hour).Unix()
+	tests := []struct {
+		name          string
+		service       keyServiceMock
+		method        string
+		path          string
+		body          any
+		expectedCode  int
+		expectedBody  any
+		expectedError *app.ErrorModel
+	}{
+		{
+			name:         "should validate key",
+			method:       http.MethodPost,
+			path:         "/user/me/keys",
+			body:         PostRequest{Scopes: []string{"notes:delete"}, ExpiresAt: &past},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ValidationErrors{Errors: map[string]string{
+				"name":      ErrNameEmpty.Error(),
+				"scopes":    ErrScopes.Error(),
+				"expiresAt": ErrExpiresAt.Error(),
+			}},
+		},
+		{
+			name:   "should create key and show its secret",
+			method: http.MethodPost,
+			path:   "/user/me/keys",
+			body:   PostRequest{Name: "ci", Scopes: []string{scope.NotesRead}},
+			service: keyServiceMock{
+				CreateKeyFunc: func(userID, name string, scopes []string, expiresAt *time.Time) (apikey.Key, string, error) {
+					return key, "nsk_secretabcd", nil
+				},
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: CreatedKeyResponse{KeyResponse: keyToKeyResponse(key), Key: "nsk_secretabcd"},
+		},
+		{
+			name:   "should list keys without secrets",
+			method: http.MethodGet,
+			path:   "/user/me/keys",
+			service: keyServiceMock{
+				GetUserKeysFunc: func(userID string) ([]apikey.Key, error) {
+					return []apikey.Key{key}, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: []KeyResponse{keyToKeyResponse(key)},
+		},
+		{
+			name:   "should return ErrKeyNotFound",
+			method: http.MethodDelete,
+			path:   "/user/me/keys/other",
+			service: keyServiceMock{
+				RevokeKeyFunc: func(id, userID string) error {
+					return apikey.ErrKeyNotFound
+				},
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: &app.ErrorModel{Error: apikey.ErrKeyNotFound.Error()},
+		},
+		{
+			name:   "should revoke key",
+			method: http.MethodDelete,
+			path:   "/user/me/keys/key",
+			service: keyServiceMock{
+				RevokeKeyFunc: func(id, userID string) error {
+					return nil
+				},
+			},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.service, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			var body []byte
+			if tt.body != nil {
+				body, _ = json.Marshal(tt.body)
+			}
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewReader(body))
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != nil {
+				expected, err := json.Marshal(tt.expectedBody)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), w.Body.String())
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
+
+func TestKeyAuthentication(t *testing.T) {
+	users := userpkg.NewInMemoryStore()
+	u, err := users.CreateUser("username1", "hash")
+	require.NoError(t, err)
+	keys := apikey.NewService(apikey.NewInMemoryStore(), users)
+	app.SetKeyAuthenticator(keys)
+	defer app.SetKeyAuthenticator(nil)
+	_, secret, err := keys.CreateKey(u.ID, "ci", nil, nil)
+	require.NoError(t, err)
+
+	g := gin.Default()
+	g.GET("/whoami", app.AuthMiddleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userId"))
+	})
+	NewRouter(keys, zap.NewNop()).SetUpRouter(g)
+
+	tests := []struct {
+		name         string
+		header       string
+		value        string
+		path         string
+		expectedCode int
+	}{
+		{name: "should accept bearer key", header: "Authorization", value: "Bearer " + secret, path: "/whoami", expectedCode: http.StatusOK},
+		{name: "should accept key as access token", header: app.AccessHeader, value: secret, path: "/whoami", expectedCode: http.StatusOK},
+		{name: "should reject unknown key", header: "Authorization", value: "Bearer nsk_unknown", path: "/whoami", expectedCode: http.StatusUnauthorized},
+		{name: "should not manage keys with a key", header: "Authorization", value: "Bearer " + secret, path: "/user/me/keys", expectedCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(tt.header, tt.value)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, u.ID, w.Body.String())
+			}
+		})
+	}
+
+	listed, err := keys.GetUserKeys(u.ID)
+	require.NoError(t, err)
+	require.NotNil(t, listed[0].LastUsedAt)
+}
@@ -0,0 +1,41 @@
+package apikey
+
+import (
+	"errors"
+	"note-service/internal/app"
+	"note-service/internal/pkg/scope"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNameEmpty = errors.New("empty name")
+	ErrName      = errors.New("name must be at most 100 characters")
+	ErrScopes    = errors.New("scopes must be of " + strings.Join(scope.Names, ", "))
+	ErrExpiresAt = errors.New("expiresAt must be in the future")
+)
+
+// maxName is the limit of name in characters
+const maxName = 100
+
+func (r PostRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Name) == 0 {
+		ve.Errors["name"] = ErrNameEmpty.Error()
+	}
+	if len([]rune(r.Name)) > maxName {
+		ve.Errors["name"] = ErrName.Error()
+	}
+	for _, name := range r.Scopes {
+		if !scope.Valid(name) {
+			ve.Errors["scopes"] = ErrScopes.Error()
+		}
+	}
+	if r.ExpiresAt != nil && *r.ExpiresAt <= time.Now().Unix() {
+		ve.Errors["expiresAt"] = ErrExpiresAt.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
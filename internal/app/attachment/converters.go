@@ -0,0 +1,25 @@
+package attachment
+
+import (
+	attachmentpkg "note-service/internal/pkg/attachment"
+)
+
+func attachmentToAttachmentResponse(a attachmentpkg.Attachment) AttachmentResponse {
+	return AttachmentResponse{
+		ID:          a.ID,
+		NoteID:      a.NoteID,
+		UserID:      a.UserID,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+func attachmentsToAttachmentResponses(attachments []attachmentpkg.Attachment) []AttachmentResponse {
+	res := make([]AttachmentResponse, len(attachments))
+	for i, a := range attachments {
+		res[i] = attachmentToAttachmentResponse(a)
+	}
+	return res
+}
@@ -0,0 +1,13 @@
+package attachment
+
+import "time"
+
+type AttachmentResponse struct {
+	ID          string    `json:"id"`
+	NoteID      string    `json:"noteId"`
+	UserID      string    `json:"userId"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
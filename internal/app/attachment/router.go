@@ -0,0 +1,134 @@
+package attachment
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"mime"
+	"net/http"
+	"note-service/internal/app"
+	attachmentpkg "note-service/internal/pkg/attachment"
+	notepkg "note-service/internal/pkg/note"
+	"note-service/internal/pkg/scope"
+)
+
+type attachmentService interface {
+	Upload(noteID, userID, name string, r io.Reader, size int64) (attachmentpkg.Attachment, error)
+	GetNoteAttachments(noteID, userID string) ([]attachmentpkg.Attachment, error)
+	Open(id, userID string) (attachmentpkg.Attachment, io.ReadCloser, error)
+	DeleteAttachment(id, userID string) error
+}
+
+// multipartOverhead leaves room for the multipart envelope around the file
+const multipartOverhead = 1 << 20
+
+type Router struct {
+	service attachmentService
+	maxSize int64
+	logger  *zap.Logger
+}
+
+// NewRouter rejects upload requests larger than maxSize with the multipart envelope before reading them
+func NewRouter(service attachmentService, maxSize int64, logger *zap.Logger) *Router {
+	return &Router{service: service, maxSize: maxSize, logger: logger}
+}
+
+func (r *Router) SetUpRouter(engine *gin.Engine) {
+	engine.POST("/note/:id/attachments", app.AuthMiddleware(scope.NotesWrite), r.postAttachment)
+	engine.GET("/note/:id/attachments", app.AuthMiddleware(scope.NotesRead), r.getAttachments)
+	engine.GET("/attachment/:id", app.AuthMiddleware(scope.NotesRead), r.download)
+	engine.DELETE("/attachment/:id", app.AuthMiddleware(scope.NotesWrite), r.deleteAttachment)
+}
+
+func (r *Router) postAttachment(c *gin.Context) {
+	limit := r.maxSize + multipartOverhead
+	if c.Request.ContentLength > limit {
+		c.IndentedJSON(http.StatusRequestEntityTooLarge, app.ErrorModel{Error: attachmentpkg.ErrTooLarge.Error()})
+		return
+	}
+	body := &countingBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, limit)}
+	c.Request.Body = body
+	header, err := c.FormFile("file")
+	if err != nil && body.read >= limit {
+		c.IndentedJSON(http.StatusRequestEntityTooLarge, app.ErrorModel{Error: attachmentpkg.ErrTooLarge.Error()})
+		return
+	}
+	if err != nil || header.Size == 0 {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: ErrFileEmpty.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		r.logger.Error("failed to open uploaded file", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+		return
+	}
+	defer file.Close()
+
+	a, err := r.service.Upload(c.Param("id"), c.GetString("userId"), header.Filename, file, header.Size)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("attachment is uploaded", zap.String("attachmentID", a.ID), zap.Int64("size", a.Size))
+	c.IndentedJSON(http.StatusCreated, attachmentToAttachmentResponse(a))
+}
+
+func (r *Router) getAttachments(c *gin.Context) {
+	attachments, err := r.service.GetNoteAttachments(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, attachmentsToAttachmentResponses(attachments))
+}
+
+func (r *Router) download(c *gin.Context) {
+	a, content, err := r.service.Open(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, a.Size, a.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (r *Router) deleteAttachment(c *gin.Context) {
+	if err := r.service.DeleteAttachment(c.Param("id"), c.GetString("userId")); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("attachment was deleted")
+	c.IndentedJSON(http.StatusOK, gin.H{"attachment": "attachment successfully deleted"})
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, attachmentpkg.ErrAttachmentNotFound), errors.Is(err, notepkg.ErrNoteNotFound):
+		c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, app.ErrNoAccess):
+		c.IndentedJSON(http.StatusForbidden, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, attachmentpkg.ErrTooLarge), errors.Is(err, attachmentpkg.ErrQuotaExceeded):
+		c.IndentedJSON(http.StatusRequestEntityTooLarge, app.ErrorModel{Error: err.Error()})
+	default:
+		r.logger.Error("failed to handle attachment", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+	}
+}
+
+// countingBody tells that the body reader stopped at the limit, a request without Content-Length is cut there
+type countingBody struct {
+	io.ReadCloser
+	read int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	return n, err
+}
@@ -0,0 +1,233 @@
+package attachment
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/app"
+	"note-service/internal/pkg/attachment"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/note"
+	"strings"
+	"testing"
+)
+
+type attachmentServiceMock struct {
+	UploadFunc             func(noteID, userID, name string, r io.Reader, size int64) (attachment.Attachment, error)
+	GetNoteAttachmentsFunc func(noteID, userID string) ([]attachment.Attachment, error)
+	OpenFunc               func(id, userID string) (attachment.Attachment, io.ReadCloser, error)
+	DeleteAttachmentFunc   func(id, userID string) error
+}
+
+func (m *attachmentServiceMock) Upload(noteID, userID, name string, r io.Reader, size int64) (attachment.Attachment, error) {
+	return m.UploadFunc(noteID, userID, name, r, size)
+}
+
+func (m *attachmentServiceMock) GetNoteAttachments(noteID, userID string) ([]attachment.Attachment, error) {
+	return m.GetNoteAttachmentsFunc(noteID, userID)
+}
+
+func (m *attachmentServiceMock) Open(id, userID string) (attachment.Attachment, io.ReadCloser, error) {
+	return m.OpenFunc(id, userID)
+}
+
+func (m *attachmentServiceMock) DeleteAttachment(id, userID string) error {
+	return m.DeleteAttachmentFunc(id, userID)
+}
+
+func serve(service *attachmentServiceMock, req *http.Request) *httptest.ResponseRecorder {
+	g := gin.Default()
+	NewRouter(service, 1<<10, zap.NewNop()).SetUpRouter(g)
+	token, _ := jwt.CreateToken("123-123")
+	req.Header.Set(app.AccessHeader, token)
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	return w
+}
+
+func multipartRequest(field, name, content string) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if field != "" {
+		fw, _ := mw.CreateFormFile(field, name)
+		fw.Write([]byte(content))
+	}
+	mw.Close()
+	req, _ := http.NewRequest(http.MethodPost, "/note/1/attachments", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestPostAttachment(t *testing.T) {
+	tests := []struct {
+		name          string
+		service       attachmentServiceMock
+		field         string
+		expectedCode  int
+		expectedError *app.ErrorModel
+	}{
+		{
+			name:          "should return ErrFileEmpty",
+			field:         "other",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: ErrFileEmpty.Error()},
+		},
+		{
+			name:  "should return ErrNoAccess",
+			field: "file",
+			service: attachmentServiceMock{
+				UploadFunc: func(noteID, userID, name string, r io.Reader, size int64) (attachment.Attachment, error) {
+					return attachment.Attachment{}, app.ErrNoAccess
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: app.ErrNoAccess.Error()},
+		},
+		{
+			name:  "should return ErrQuotaExceeded",
+			field: "file",
+			service: attachmentServiceMock{
+				UploadFunc: func(noteID, userID, name string, r io.Reader, size int64) (attachment.Attachment, error) {
+					return attachment.Attachment{}, attachment.ErrQuotaExceeded
+				},
+			},
+			expectedCode:  http.StatusRequestEntityTooLarge,
+			expectedError: &app.ErrorModel{Error: attachment.ErrQuotaExceeded.Error()},
+		},
+		{
+			name:  "should upload attachment",
+			field: "file",
+			service: attachmentServiceMock{
+				UploadFunc: func(noteID, userID, name string, r io.Reader, size int64) (attachment.Attachment, error) {
+					data, _ := io.ReadAll(r)
+					if noteID != "1" || userID != "123-123" || name != "a.txt" || string(data) != "hello" || size != 5 {
+						return attachment.Attachment{}, errors.New("unexpected upload")
+					}
+					return attachment.Attachment{ID: "2", NoteID: noteID, Name: name, Size: size}, nil
+				},
+			},
+			expectedCode: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(&tt.service, multipartRequest(tt.field, "a.txt", "hello"))
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
+
+func TestPostAttachmentTooLarge(t *testing.T) {
+	service := &attachmentServiceMock{
+		UploadFunc: func(noteID, userID, name string, r io.Reader, size int64) (attachment.Attachment, error) {
+			return attachment.Attachment{}, errors.New("body over the limit reached the service")
+		},
+	}
+	content := strings.Repeat("a", 1<<10+multipartOverhead)
+
+	req := multipartRequest("file", "a.txt", content)
+	w := serve(service, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	req = multipartRequest("file", "a.txt", content)
+	req.ContentLength = -1
+	w = serve(service, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "request without Content-Length is cut at the limit")
+}
+
+func TestDownloadAttachment(t *testing.T) {
+	tests := []struct {
+		name          string
+		service       attachmentServiceMock
+		expectedCode  int
+		expectedBody  string
+		expectedError *app.ErrorModel
+	}{
+		{
+			name: "should return ErrNoteNotFound",
+			service: attachmentServiceMock{
+				OpenFunc: func(id, userID string) (attachment.Attachment, io.ReadCloser, error) {
+					return attachment.Attachment{}, nil, note.ErrNoteNotFound
+				},
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: &app.ErrorModel{Error: note.ErrNoteNotFound.Error()},
+		},
+		{
+			name: "should return unknownError",
+			service: attachmentServiceMock{
+				OpenFunc: func(id, userID string) (attachment.Attachment, io.ReadCloser, error) {
+					return attachment.Attachment{}, nil, errors.New("something wrong")
+				},
+			},
+			expectedCode:  http.StatusInternalServerError,
+			expectedError: &app.UnknownError,
+		},
+		{
+			name: "should return content",
+			service: attachmentServiceMock{
+				OpenFunc: func(id, userID string) (attachment.Attachment, io.ReadCloser, error) {
+					a := attachment.Attachment{ID: id, Name: "a.txt", ContentType: "text/plain; charset=utf-8", Size: 5}
+					return a, io.NopCloser(strings.NewReader("hello")), nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: "hello",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/attachment/2", nil)
+			w := serve(&tt.service, req)
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+				assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+				assert.Equal(t, "attachment; filename=a.txt", w.Header().Get("Content-Disposition"))
+				assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	service := attachmentServiceMock{
+		DeleteAttachmentFunc: func(id, userID string) error {
+			if id != "2" {
+				return attachment.ErrAttachmentNotFound
+			}
+			return nil
+		},
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, "/attachment/2", nil)
+	assert.Equal(t, http.StatusOK, serve(&service, req).Code)
+
+	req, _ = http.NewRequest(http.MethodDelete, "/attachment/3", nil)
+	assert.Equal(t, http.StatusNotFound, serve(&service, req).Code)
+}
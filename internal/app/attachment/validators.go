@@ -0,0 +1,5 @@
+package attachment
+
+import "errors"
+
+var ErrFileEmpty = errors.New("empty file, send it as multipart form field \"file\"")
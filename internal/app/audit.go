@@ -0,0 +1,55 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"note-service/internal/pkg/audit"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLen = 128
+)
+
+// RequestIDMiddleware keeps X-Request-ID of the client or makes a new one, the id is sent back
+// and is written to audit events of the request
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Request.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set("requestId", id)
+		c.Header(RequestIDHeader, id)
+	}
+}
+
+// validRequestID lets only printable ASCII through, so the id can't break log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// AuditEvent fills who made the request and from where, the caller sets the action target.
+// Requests made with an API key name the key in details.
+func AuditEvent(c *gin.Context, action string) audit.Event {
+	e := audit.Event{
+		Action:    action,
+		ActorID:   c.GetString("userId"),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString("requestId"),
+	}
+	if keyID := c.GetString("apiKeyId"); keyID != "" {
+		e = e.With("apiKeyId", keyID)
+	}
+	return e
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	auditpkg "note-service/internal/pkg/audit"
+	"strconv"
+)
+
+// queryRequestToFilter expects a validated request
+func queryRequestToFilter(r QueryRequest) auditpkg.Filter {
+	since, _ := parseTime(r.Since)
+	until, _ := parseTime(r.Until)
+	limit, _ := strconv.Atoi(r.Limit)
+	return auditpkg.Filter{
+		ActorID:    r.ActorID,
+		OwnerID:    r.OwnerID,
+		TargetType: r.TargetType,
+		TargetID:   r.TargetID,
+		Action:     r.Action,
+		Since:      since,
+		Until:      until,
+		Limit:      limit,
+	}
+}
+
+func eventToEventResponse(e auditpkg.Event) EventResponse {
+	return EventResponse{
+		ID:         e.ID,
+		Time:       e.Time,
+		Action:     e.Action,
+		ActorID:    e.ActorID,
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		OwnerID:    e.OwnerID,
+		Details:    e.Details,
+	}
+}
+
+func eventsToEventsResponse(events []auditpkg.Event) EventsResponse {
+	res := EventsResponse{Events: make([]EventResponse, len(events))}
+	for i, e := range events {
+		res.Events[i] = eventToEventResponse(e)
+	}
+	return res
+}
@@ -0,0 +1,39 @@
+package audit
+
+import "time"
+
+const (
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+)
+
+type EventResponse struct {
+	ID         string            `json:"id"`
+	Time       time.Time         `json:"time"`
+	Action     string            `json:"action"`
+	ActorID    string            `json:"actorId"`
+	IP         string            `json:"ip"`
+	UserAgent  string            `json:"userAgent"`
+	RequestID  string            `json:"requestId"`
+	TargetType string            `json:"targetType"`
+	TargetID   string            `json:"targetId"`
+	OwnerID    string            `json:"ownerId"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+type EventsResponse struct {
+	Events []EventResponse `json:"events"`
+}
+
+// QueryRequest is read from the query string, Since and Until are RFC 3339 times
+type QueryRequest struct {
+	Action     string
+	ActorID    string
+	OwnerID    string
+	TargetType string
+	TargetID   string
+	Since      string
+	Until      string
+	Limit      string
+	Format     string
+}
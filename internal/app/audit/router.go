@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"note-service/internal/app"
+	auditpkg "note-service/internal/pkg/audit"
+	"note-service/internal/pkg/scope"
+)
+
+// defaultLimit is the page of events when the request sets no limit, jsonl export has no default limit
+const defaultLimit = 100
+
+type auditService interface {
+	Query(f auditpkg.Filter) ([]auditpkg.Event, error)
+}
+
+type Router struct {
+	service auditService
+	admins  app.AdminChecker
+	logger  *zap.Logger
+}
+
+func NewRouter(service auditService, admins app.AdminChecker, logger *zap.Logger) *Router {
+	return &Router{service: service, admins: admins, logger: logger}
+}
+
+// SetUpRouter lets owners read events of their notes, even deleted ones, and admins read every event
+func (r *Router) SetUpRouter(engine *gin.Engine) {
+	engine.GET("/notes/audit", app.AuthMiddleware(scope.NotesRead), r.getNotesEvents)
+	engine.GET("/note/:id/audit", app.AuthMiddleware(scope.NotesRead), r.getNoteEvents)
+	engine.GET("/admin/audit", app.AuthMiddleware(), app.AdminMiddleware(r.admins), r.getEvents)
+}
+
+func (r *Router) getNotesEvents(c *gin.Context) {
+	request := readQuery(c)
+	request.OwnerID = c.GetString("userId")
+	request.TargetType = auditpkg.TargetNote
+	r.respond(c, request)
+}
+
+// getNoteEvents answers with an empty list for notes of other users, so it doesn't tell which notes exist
+func (r *Router) getNoteEvents(c *gin.Context) {
+	request := readQuery(c)
+	request.OwnerID = c.GetString("userId")
+	request.TargetType = auditpkg.TargetNote
+	request.TargetID = c.Param("id")
+	r.respond(c, request)
+}
+
+func (r *Router) getEvents(c *gin.Context) {
+	request := readQuery(c)
+	request.ActorID = c.Query("actor")
+	request.OwnerID = c.Query("owner")
+	request.TargetType = c.Query("targetType")
+	request.TargetID = c.Query("target")
+	r.respond(c, request)
+}
+
+func readQuery(c *gin.Context) QueryRequest {
+	return QueryRequest{
+		Action: c.Query("action"),
+		Since:  c.Query("since"),
+		Until:  c.Query("until"),
+		Limit:  c.Query("limit"),
+		Format: c.DefaultQuery("format", FormatJSON),
+	}
+}
+
+func (r *Router) respond(c *gin.Context, request QueryRequest) {
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	filter := queryRequestToFilter(request)
+	if filter.Limit == 0 && request.Format == FormatJSON {
+		filter.Limit = defaultLimit
+	}
+	events, err := r.service.Query(filter)
+	if err != nil {
+		r.logger.Error("failed to query audit events", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+		return
+	}
+	if request.Format == FormatJSON {
+		c.IndentedJSON(http.StatusOK, eventsToEventsResponse(events))
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	for _, e := range events {
+		if err := encoder.Encode(eventToEventResponse(e)); err != nil {
+			// the status is already sent, the client gets a cut export
+			r.logger.Error("failed to export audit events", zap.Error(err))
+			return
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/app"
+	auditpkg "note-service/internal/pkg/audit"
+	"note-service/internal/pkg/jwt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type auditServiceMock struct {
+	QueryFunc func(f auditpkg.Filter) ([]auditpkg.Event, error)
+}
+
+func (m *auditServiceMock) Query(f auditpkg.Filter) ([]auditpkg.Event, error) {
+	return m.QueryFunc(f)
+}
+
+// adminCheckerMock treats only the user "admin" as an admin
+type adminCheckerMock struct{}
+
+func (adminCheckerMock) IsAdmin(id string) bool {
+	return id == "admin"
+}
+
+func TestQueryEvents(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	events := []auditpkg.Event{
+		{ID: "2", Time: at, Action: auditpkg.ActionNoteDelete, ActorID: "admin", TargetType: auditpkg.TargetNote, TargetID: "n1", OwnerID: "123-123"},
+		{ID: "1", Time: at, Action: auditpkg.ActionNoteCreate, ActorID: "123-123", TargetType: auditpkg.TargetNote, TargetID: "n1", OwnerID: "123-123",
+			Details: map[string]string{"apiKeyId": "k1"}},
+	}
+	tests := []struct {
+		name           string
+		actor          string
+		path           string
+		expectedFilter *auditpkg.Filter
+		expectedCode   int
+		expectedBody   interface{}
+		expectedLines  int
+	}{
+		{
+			name:           "should return events of the owner's note",
+			path:           "/note/n1/audit?owner=other",
+			expectedFilter: &auditpkg.Filter{OwnerID: "123-123", TargetType: auditpkg.TargetNote, TargetID: "n1", Limit: defaultLimit},
+			expectedCode:   http.StatusOK,
+			expectedBody:   eventsToEventsResponse(events),
+		},
+		{
+			name:  "should filter events of the owner's notes",
+			path:  "/notes/audit?action=note.delete&since=2024-05-01T00:00:00Z&limit=10",
+			actor: "123-123",
+			expectedFilter: &auditpkg.Filter{OwnerID: "123-123", TargetType: auditpkg.TargetNote, Action: auditpkg.ActionNoteDelete,
+				Since: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Limit: 10},
+			expectedCode: http.StatusOK,
+			expectedBody: eventsToEventsResponse(events),
+		},
+		{
+			name:         "should validate query",
+			path:         "/notes/audit?until=yesterday&limit=0&format=csv",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ValidationErrors{Errors: map[string]string{
+				"until":  ErrTime.Error(),
+				"limit":  ErrLimit.Error(),
+				"format": ErrFormat.Error(),
+			}},
+		},
+		{
+			name:         "should not let users read all events",
+			path:         "/admin/audit",
+			expectedCode: http.StatusForbidden,
+			expectedBody: app.ErrorModel{Error: app.ErrNoAccess.Error()},
+		},
+		{
+			name:           "should export events as json lines",
+			actor:          "admin",
+			path:           "/admin/audit?actor=admin&owner=123-123&target=n1&targetType=note&format=jsonl",
+			expectedFilter: &auditpkg.Filter{ActorID: "admin", OwnerID: "123-123", TargetType: auditpkg.TargetNote, TargetID: "n1"},
+			expectedCode:   http.StatusOK,
+			expectedLines:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var filter *auditpkg.Filter
+			service := &auditServiceMock{QueryFunc: func(f auditpkg.Filter) ([]auditpkg.Event, error) {
+				filter = &f
+				return events, nil
+			}}
+			g := gin.Default()
+			r := NewRouter(service, adminCheckerMock{}, zap.NewNop())
+			r.SetUpRouter(g)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			actor := tt.actor
+			if actor == "" {
+				actor = "123-123"
+			}
+			token, _ := jwt.CreateToken(actor)
+			req.Header.Set(app.AccessHeader, token)
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedFilter, filter)
+			if tt.expectedBody != nil {
+				expected, err := json.Marshal(tt.expectedBody)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), w.Body.String())
+			}
+			if tt.expectedLines > 0 {
+				assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+				lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+				if assert.Len(t, lines, tt.expectedLines) {
+					var e EventResponse
+					assert.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+					assert.Equal(t, eventToEventResponse(events[1]), e)
+				}
+			}
+		})
+	}
+}
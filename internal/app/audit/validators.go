@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"errors"
+	"note-service/internal/app"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrLimit  = errors.New("limit must be from 1 to 1000")
+	ErrTime   = errors.New("time must be in RFC 3339 format")
+	ErrFormat = errors.New("format must be json or jsonl")
+)
+
+// maxLimit is the largest page of events, jsonl export without limit returns every event
+const maxLimit = 1000
+
+func (r QueryRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if r.Limit != "" {
+		if limit, err := strconv.Atoi(r.Limit); err != nil || limit < 1 || limit > maxLimit {
+			ve.Errors["limit"] = ErrLimit.Error()
+		}
+	}
+	if _, err := parseTime(r.Since); err != nil {
+		ve.Errors["since"] = ErrTime.Error()
+	}
+	if _, err := parseTime(r.Until); err != nil {
+		ve.Errors["until"] = ErrTime.Error()
+	}
+	if r.Format != FormatJSON && r.Format != FormatJSONL {
+		ve.Errors["format"] = ErrFormat.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+// parseTime leaves empty time zero, which filters nothing
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/pkg/audit"
+	"strings"
+	"testing"
+)
+
+func TestAuditEvent(t *testing.T) {
+	var event audit.Event
+	g := gin.New()
+	g.Use(RequestIDMiddleware())
+	g.GET("/note", func(c *gin.Context) {
+		c.Set("userId", "123-123")
+		c.Set("apiKeyId", "key")
+		event = AuditEvent(c, audit.ActionNoteCreate)
+	})
+
+	tests := []struct {
+		name       string
+		requestID  string
+		expectedID string
+	}{
+		{name: "should keep request id", requestID: "abc-123", expectedID: "abc-123"},
+		{name: "should replace empty request id", requestID: ""},
+		{name: "should replace request id with spaces", requestID: "abc 123"},
+		{name: "should replace long request id", requestID: strings.Repeat("a", 200)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/note", nil)
+			req.Header.Set(RequestIDHeader, tt.requestID)
+			req.Header.Set("User-Agent", "test-agent")
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if tt.expectedID != "" {
+				assert.Equal(t, tt.expectedID, id)
+			} else {
+				assert.Len(t, id, 36)
+			}
+			assert.Equal(t, audit.Event{
+				Action:    audit.ActionNoteCreate,
+				ActorID:   "123-123",
+				UserAgent: "test-agent",
+				RequestID: id,
+				Details:   map[string]string{"apiKeyId": "key"},
+			}, event)
+		})
+	}
+}
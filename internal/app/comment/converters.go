@@ -0,0 +1,37 @@
+package comment
+
+import (
+	commentpkg "note-service/internal/pkg/comment"
+)
+
+func commentToCommentResponse(c commentpkg.Comment) CommentResponse {
+	return CommentResponse{
+		ID:        c.ID,
+		NoteID:    c.NoteID,
+		UserID:    c.UserID,
+		ParentID:  c.ParentID,
+		Text:      c.Text,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		Replies:   make([]CommentResponse, 0),
+	}
+}
+
+// commentsToThreads nests replies under their parents keeping the order of comments
+func commentsToThreads(comments []commentpkg.Comment) []CommentResponse {
+	children := make(map[string][]commentpkg.Comment)
+	for _, c := range comments {
+		children[c.ParentID] = append(children[c.ParentID], c)
+	}
+	var build func(parentID string) []CommentResponse
+	build = func(parentID string) []CommentResponse {
+		res := make([]CommentResponse, 0, len(children[parentID]))
+		for _, c := range children[parentID] {
+			response := commentToCommentResponse(c)
+			response.Replies = build(c.ID)
+			res = append(res, response)
+		}
+		return res
+	}
+	return build("")
+}
@@ -0,0 +1,23 @@
+package comment
+
+import "time"
+
+type CommentResponse struct {
+	ID        string            `json:"id"`
+	NoteID    string            `json:"noteId"`
+	UserID    string            `json:"userId"`
+	ParentID  string            `json:"parentId,omitempty"`
+	Text      string            `json:"text"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+	Replies   []CommentResponse `json:"replies"`
+}
+
+type PostRequest struct {
+	Text     string `json:"text"`
+	ParentID string `json:"parentId"`
+}
+
+type UpdateRequest struct {
+	Text string `json:"text"`
+}
@@ -0,0 +1,113 @@
+package comment
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"note-service/internal/app"
+	commentpkg "note-service/internal/pkg/comment"
+	notepkg "note-service/internal/pkg/note"
+	"note-service/internal/pkg/scope"
+)
+
+type commentService interface {
+	CreateComment(comment commentpkg.Comment) (commentpkg.Comment, error)
+	GetNoteComments(noteID, userID string) ([]commentpkg.Comment, error)
+	UpdateComment(id, userID, text string) (commentpkg.Comment, error)
+	DeleteComment(id, userID string) error
+}
+
+type Router struct {
+	service commentService
+	logger  *zap.Logger
+}
+
+func NewRouter(service commentService, logger *zap.Logger) *Router {
+	return &Router{service: service, logger: logger}
+}
+
+func (r *Router) SetUpRouter(engine *gin.Engine) {
+	engine.POST("/note/:id/comments", app.AuthMiddleware(scope.NotesWrite), r.postComment)
+	engine.GET("/note/:id/comments", app.AuthMiddleware(scope.NotesRead), r.getComments)
+	engine.PUT("/comment/:id", app.AuthMiddleware(scope.NotesWrite), r.updateComment)
+	engine.DELETE("/comment/:id", app.AuthMiddleware(scope.NotesWrite), r.deleteComment)
+}
+
+func (r *Router) postComment(c *gin.Context) {
+	var request PostRequest
+	if err := c.BindJSON(&request); err != nil {
+		r.logger.Error("failed to bind json", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	comment, err := r.service.CreateComment(commentpkg.Comment{
+		NoteID:   c.Param("id"),
+		UserID:   c.GetString("userId"),
+		ParentID: request.ParentID,
+		Text:     request.Text,
+	})
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("comment is created", zap.String("commentID", comment.ID))
+	c.IndentedJSON(http.StatusCreated, commentToCommentResponse(comment))
+}
+
+func (r *Router) getComments(c *gin.Context) {
+	comments, err := r.service.GetNoteComments(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, commentsToThreads(comments))
+}
+
+func (r *Router) updateComment(c *gin.Context) {
+	var request UpdateRequest
+	if err := c.BindJSON(&request); err != nil {
+		r.logger.Error("failed to bind json", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	comment, err := r.service.UpdateComment(c.Param("id"), c.GetString("userId"), request.Text)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, commentToCommentResponse(comment))
+}
+
+func (r *Router) deleteComment(c *gin.Context) {
+	if err := r.service.DeleteComment(c.Param("id"), c.GetString("userId")); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("comment was deleted")
+	c.IndentedJSON(http.StatusOK, gin.H{"comment": "comment successfully deleted"})
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, commentpkg.ErrCommentNotFound), errors.Is(err, notepkg.ErrNoteNotFound):
+		c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, app.ErrNoAccess):
+		c.IndentedJSON(http.StatusForbidden, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, commentpkg.ErrParentNotFound):
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+	default:
+		r.logger.Error("failed to handle comment", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+	}
+}
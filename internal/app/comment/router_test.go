@@ -0,0 +1,193 @@
+package comment
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/app"
+	"note-service/internal/pkg/comment"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/note"
+	"testing"
+	"time"
+)
+
+type commentServiceMock struct {
+	CreateCommentFunc   func(c comment.Comment) (comment.Comment, error)
+	GetNoteCommentsFunc func(noteID, userID string) ([]comment.Comment, error)
+	UpdateCommentFunc   func(id, userID, text string) (comment.Comment, error)
+	DeleteCommentFunc   func(id, userID string) error
+}
+
+func (m *commentServiceMock) CreateComment(c comment.Comment) (comment.Comment, error) {
+	return m.CreateCommentFunc(c)
+}
+
+func (m *commentServiceMock) GetNoteComments(noteID, userID string) ([]comment.Comment, error) {
+	return m.GetNoteCommentsFunc(noteID, userID)
+}
+
+func (m *commentServiceMock) UpdateComment(id, userID, text string) (comment.Comment, error) {
+	return m.UpdateCommentFunc(id, userID, text)
+}
+
+func (m *commentServiceMock) DeleteComment(id, userID string) error {
+	return m.DeleteCommentFunc(id, userID)
+}
+
+func TestCommentRoutes(t *testing.T) {
+	at := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	root := comment.Comment{ID: "1", NoteID: "n", UserID: "123-123", Text: "question", CreatedAt: at}
+	reply := comment.Comment{ID: "2", NoteID: "n", UserID: "321-321", ParentID: "1", Text: "answer", CreatedAt: at}
+	tests := []struct {
+		name           string
+		commentService commentServiceMock
+		method         string
+		path           string
+		body           any
+		expectedCode   int
+		expectedError  *app.ErrorModel
+		expectedBody   any
+	}{
+		{
+			name:         "should return ErrTextEmpty",
+			method:       http.MethodPost,
+			path:         "/note/n/comments",
+			body:         PostRequest{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return ErrParentNotFound",
+			method: http.MethodPost,
+			path:   "/note/n/comments",
+			body:   PostRequest{Text: "hi", ParentID: "3"},
+			commentService: commentServiceMock{
+				CreateCommentFunc: func(c comment.Comment) (comment.Comment, error) {
+					return comment.Comment{}, comment.ErrParentNotFound
+				},
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: comment.ErrParentNotFound.Error()},
+		},
+		{
+			name:   "should create comment",
+			method: http.MethodPost,
+			path:   "/note/n/comments",
+			body:   PostRequest{Text: "answer", ParentID: "1"},
+			commentService: commentServiceMock{
+				CreateCommentFunc: func(c comment.Comment) (comment.Comment, error) {
+					if c.NoteID != "n" || c.UserID != "123-123" || c.ParentID != "1" {
+						return comment.Comment{}, errors.New("unexpected comment")
+					}
+					return reply, nil
+				},
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: commentToCommentResponse(reply),
+		},
+		{
+			name:   "should return ErrNoAccess",
+			method: http.MethodGet,
+			path:   "/note/n/comments",
+			commentService: commentServiceMock{
+				GetNoteCommentsFunc: func(noteID, userID string) ([]comment.Comment, error) {
+					return nil, app.ErrNoAccess
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: app.ErrNoAccess.Error()},
+		},
+		{
+			name:   "should return threads",
+			method: http.MethodGet,
+			path:   "/note/n/comments",
+			commentService: commentServiceMock{
+				GetNoteCommentsFunc: func(noteID, userID string) ([]comment.Comment, error) {
+					return []comment.Comment{root, reply}, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: []CommentResponse{func() CommentResponse {
+				r := commentToCommentResponse(root)
+				r.Replies = []CommentResponse{commentToCommentResponse(reply)}
+				return r
+			}()},
+		},
+		{
+			name:   "should return ErrCommentNotFound",
+			method: http.MethodPut,
+			path:   "/comment/3",
+			body:   UpdateRequest{Text: "edited"},
+			commentService: commentServiceMock{
+				UpdateCommentFunc: func(id, userID, text string) (comment.Comment, error) {
+					return comment.Comment{}, comment.ErrCommentNotFound
+				},
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: &app.ErrorModel{Error: comment.ErrCommentNotFound.Error()},
+		},
+		{
+			name:   "should return errNoteNotFound",
+			method: http.MethodDelete,
+			path:   "/comment/1",
+			commentService: commentServiceMock{
+				DeleteCommentFunc: func(id, userID string) error {
+					return note.ErrNoteNotFound
+				},
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: &app.ErrorModel{Error: note.ErrNoteNotFound.Error()},
+		},
+		{
+			name:   "should delete comment",
+			method: http.MethodDelete,
+			path:   "/comment/1",
+			commentService: commentServiceMock{
+				DeleteCommentFunc: func(id, userID string) error {
+					return nil
+				},
+			},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.commentService, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			var body []byte
+			if tt.body != nil {
+				body, _ = json.Marshal(tt.body)
+			}
+			req, _ := http.NewRequestWithContext(c, tt.method, tt.path, bytes.NewReader(body))
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedBody != nil {
+				expected, err := json.Marshal(tt.expectedBody)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), w.Body.String())
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package comment
+
+import (
+	"errors"
+	"note-service/internal/app"
+)
+
+var ErrTextEmpty = errors.New("empty text")
+
+func (r PostRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if r.Text == "" {
+		ve.Errors["text"] = ErrTextEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r UpdateRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if r.Text == "" {
+		ve.Errors["text"] = ErrTextEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
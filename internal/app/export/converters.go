@@ -0,0 +1,19 @@
+package export
+
+import (
+	exportpkg "note-service/internal/pkg/export"
+)
+
+func jobToJobResponse(job exportpkg.Job) JobResponse {
+	return JobResponse{
+		ID:         job.ID,
+		Format:     job.Format,
+		Shared:     job.Shared,
+		Status:     job.Status,
+		Total:      job.Total,
+		Done:       job.Done,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
@@ -0,0 +1,20 @@
+package export
+
+import "time"
+
+type ExportRequest struct {
+	Format string `json:"format"`
+	Shared bool   `json:"shared"`
+}
+
+type JobResponse struct {
+	ID         string     `json:"id"`
+	Format     string     `json:"format"`
+	Shared     bool       `json:"shared"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Done       int        `json:"done"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
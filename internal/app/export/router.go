@@ -0,0 +1,137 @@
+package export
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"mime"
+	"net/http"
+	"note-service/internal/app"
+	exportpkg "note-service/internal/pkg/export"
+	"note-service/internal/pkg/scope"
+	"strconv"
+	"time"
+)
+
+type exportService interface {
+	Export(w io.Writer, userID, format string, shared bool) error
+	StartJob(userID, format string, shared bool) (exportpkg.Job, error)
+	GetJob(id, userID string) (exportpkg.Job, error)
+	OpenResult(id, userID string) (exportpkg.Job, io.ReadCloser, error)
+	DeleteJob(id, userID string) error
+}
+
+type Router struct {
+	service exportService
+	logger  *zap.Logger
+}
+
+func NewRouter(service exportService, logger *zap.Logger) *Router {
+	return &Router{service: service, logger: logger}
+}
+
+func (r *Router) SetUpRouter(engine *gin.Engine) {
+	engine.GET("/notes/export", app.AuthMiddleware(scope.NotesRead), r.export)
+	engine.POST("/notes/export", app.AuthMiddleware(scope.NotesRead), r.startJob)
+	engine.GET("/notes/export/:id", app.AuthMiddleware(scope.NotesRead), r.getJob)
+	engine.GET("/notes/export/:id/download", app.AuthMiddleware(scope.NotesRead), r.download)
+	engine.DELETE("/notes/export/:id", app.AuthMiddleware(scope.NotesWrite), r.deleteJob)
+}
+
+// export streams the archive right away, large accounts should start a job instead
+func (r *Router) export(c *gin.Context) {
+	request := ExportRequest{Format: c.DefaultQuery("format", exportpkg.FormatJSON)}
+	if shared := c.Query("shared"); shared != "" {
+		var err error
+		if request.Shared, err = strconv.ParseBool(shared); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: ErrShared.Error()})
+			return
+		}
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", archiveDisposition(time.Now()))
+	c.Status(http.StatusOK)
+	if err := r.service.Export(c.Writer, c.GetString("userId"), request.Format, request.Shared); err != nil {
+		// the status is already sent, the client gets a broken archive
+		r.logger.Error("failed to export notes", zap.Error(err))
+		return
+	}
+	r.logger.Info("notes are exported", zap.String("format", request.Format))
+}
+
+func (r *Router) startJob(c *gin.Context) {
+	var request ExportRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := r.service.StartJob(c.GetString("userId"), request.Format, request.Shared)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("export job is started", zap.String("jobID", job.ID))
+	c.IndentedJSON(http.StatusAccepted, jobToJobResponse(job))
+}
+
+func (r *Router) getJob(c *gin.Context) {
+	job, err := r.service.GetJob(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, jobToJobResponse(job))
+}
+
+func (r *Router) download(c *gin.Context) {
+	job, content, err := r.service.OpenResult(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, -1, "application/zip", content, map[string]string{
+		"Content-Disposition": archiveDisposition(job.CreatedAt),
+	})
+}
+
+func (r *Router) deleteJob(c *gin.Context) {
+	if err := r.service.DeleteJob(c.Param("id"), c.GetString("userId")); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("export job was deleted")
+	c.IndentedJSON(http.StatusOK, gin.H{"export": "export job successfully deleted"})
+}
+
+func archiveDisposition(at time.Time) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": "notes-" + at.UTC().Format("20060102-150405") + ".zip"})
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, exportpkg.ErrJobNotFound):
+		c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, app.ErrNoAccess):
+		c.IndentedJSON(http.StatusForbidden, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, exportpkg.ErrFormat):
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, exportpkg.ErrJobNotDone), errors.Is(err, exportpkg.ErrJobActive):
+		c.IndentedJSON(http.StatusConflict, app.ErrorModel{Error: err.Error()})
+	default:
+		r.logger.Error("failed to handle export", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+	}
+}
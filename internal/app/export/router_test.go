@@ -0,0 +1,228 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/app"
+	"note-service/internal/pkg/export"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/scope"
+	"strings"
+	"testing"
+	"time"
+)
+
+type exportServiceMock struct {
+	ExportFunc     func(w io.Writer, userID, format string, shared bool) error
+	StartJobFunc   func(userID, format string, shared bool) (export.Job, error)
+	GetJobFunc     func(id, userID string) (export.Job, error)
+	OpenResultFunc func(id, userID string) (export.Job, io.ReadCloser, error)
+	DeleteJobFunc  func(id, userID string) error
+}
+
+func (m *exportServiceMock) Export(w io.Writer, userID, format string, shared bool) error {
+	return m.ExportFunc(w, userID, format, shared)
+}
+
+func (m *exportServiceMock) StartJob(userID, format string, shared bool) (export.Job, error) {
+	return m.StartJobFunc(userID, format, shared)
+}
+
+func (m *exportServiceMock) GetJob(id, userID string) (export.Job, error) {
+	return m.GetJobFunc(id, userID)
+}
+
+func (m *exportServiceMock) OpenResult(id, userID string) (export.Job, io.ReadCloser, error) {
+	return m.OpenResultFunc(id, userID)
+}
+
+func (m *exportServiceMock) DeleteJob(id, userID string) error {
+	return m.DeleteJobFunc(id, userID)
+}
+
+func TestExportRoutes(t *testing.T) {
+	job := export.Job{ID: "1", UserID: "123-123", Format: export.FormatMarkdown, Status: export.JobRunning, Total: 10, Done: 4,
+		CreatedAt: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		name          string
+		exportService exportServiceMock
+		method        string
+		path          string
+		body          any
+		expectedCode  int
+		expectedError *app.ErrorModel
+		expectedBody  any
+		expectedData  string
+		scopes        []string
+	}{
+		{
+			name:         "should return ErrFormat",
+			method:       http.MethodGet,
+			path:         "/notes/export?format=pdf",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:          "should return ErrShared",
+			method:        http.MethodGet,
+			path:          "/notes/export?shared=maybe",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: ErrShared.Error()},
+		},
+		{
+			name:   "should stream archive",
+			method: http.MethodGet,
+			path:   "/notes/export?format=html&shared=true",
+			exportService: exportServiceMock{
+				ExportFunc: func(w io.Writer, userID, format string, shared bool) error {
+					if userID != "123-123" || format != export.FormatHTML || !shared {
+						return errors.New("unexpected export")
+					}
+					_, err := io.WriteString(w, "zip")
+					return err
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedData: "zip",
+		},
+		{
+			name:   "should start job",
+			method: http.MethodPost,
+			path:   "/notes/export",
+			body:   ExportRequest{Format: export.FormatMarkdown},
+			exportService: exportServiceMock{
+				StartJobFunc: func(userID, format string, shared bool) (export.Job, error) {
+					return job, nil
+				},
+			},
+			expectedCode: http.StatusAccepted,
+			expectedBody: jobToJobResponse(job),
+		},
+		{
+			name:   "should return ErrJobActive",
+			method: http.MethodPost,
+			path:   "/notes/export",
+			body:   ExportRequest{Format: export.FormatJSON},
+			exportService: exportServiceMock{
+				StartJobFunc: func(userID, format string, shared bool) (export.Job, error) {
+					return export.Job{}, export.ErrJobActive
+				},
+			},
+			expectedCode:  http.StatusConflict,
+			expectedError: &app.ErrorModel{Error: export.ErrJobActive.Error()},
+		},
+		{
+			name:   "should return ErrNoAccess",
+			method: http.MethodGet,
+			path:   "/notes/export/1",
+			exportService: exportServiceMock{
+				GetJobFunc: func(id, userID string) (export.Job, error) {
+					return export.Job{}, app.ErrNoAccess
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: app.ErrNoAccess.Error()},
+		},
+		{
+			name:   "should return job progress",
+			method: http.MethodGet,
+			path:   "/notes/export/1",
+			exportService: exportServiceMock{
+				GetJobFunc: func(id, userID string) (export.Job, error) {
+					return job, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: jobToJobResponse(job),
+		},
+		{
+			name:   "should return ErrJobNotDone",
+			method: http.MethodGet,
+			path:   "/notes/export/1/download",
+			exportService: exportServiceMock{
+				OpenResultFunc: func(id, userID string) (export.Job, io.ReadCloser, error) {
+					return export.Job{}, nil, export.ErrJobNotDone
+				},
+			},
+			expectedCode:  http.StatusConflict,
+			expectedError: &app.ErrorModel{Error: export.ErrJobNotDone.Error()},
+		},
+		{
+			name:   "should download archive",
+			method: http.MethodGet,
+			path:   "/notes/export/1/download",
+			exportService: exportServiceMock{
+				OpenResultFunc: func(id, userID string) (export.Job, io.ReadCloser, error) {
+					return job, io.NopCloser(strings.NewReader("zip")), nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedData: "zip",
+		},
+		{
+			name:   "should return ErrJobNotFound",
+			method: http.MethodDelete,
+			path:   "/notes/export/2",
+			exportService: exportServiceMock{
+				DeleteJobFunc: func(id, userID string) error {
+					return export.ErrJobNotFound
+				},
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: &app.ErrorModel{Error: export.ErrJobNotFound.Error()},
+		},
+		{
+			name:         "should need notes:write to delete job",
+			method:       http.MethodDelete,
+			path:         "/notes/export/2",
+			scopes:       []string{scope.NotesRead},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.exportService, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			var body []byte
+			if tt.body != nil {
+				body, _ = json.Marshal(tt.body)
+			}
+			req, _ := http.NewRequestWithContext(c, tt.method, tt.path, bytes.NewReader(body))
+			token, _ := jwt.CreateToken("123-123", tt.scopes...)
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedData != "" {
+				assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+				assert.Equal(t, tt.expectedData, w.Body.String())
+			}
+			if tt.expectedBody != nil {
+				expected, err := json.Marshal(tt.expectedBody)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), w.Body.String())
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package export
+
+import (
+	"errors"
+	"note-service/internal/app"
+	exportpkg "note-service/internal/pkg/export"
+)
+
+var ErrShared = errors.New("shared must be true or false")
+
+func (r ExportRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if !exportpkg.ValidFormat(r.Format) {
+		ve.Errors["format"] = exportpkg.ErrFormat.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"note-service/internal/pkg/idempotency"
+	"os"
+	"time"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+	ReplayedHeader    = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+	// maxBufferedBody is the largest body kept in memory, larger ones are spooled to a temporary file
+	maxBufferedBody = 1 << 20
+	// maxSpooledBody is the largest body of a request with Idempotency-Key
+	maxSpooledBody = 128 << 20
+)
+
+var (
+	ErrIdempotencyKey      = errors.New("Idempotency-Key must be at most 255 characters")
+	ErrIdempotencyMismatch = errors.New("Idempotency-Key was already used with another request")
+	ErrIdempotencyInFlight = errors.New("request with this Idempotency-Key is still in progress")
+	ErrIdempotencyBody     = errors.New("request with Idempotency-Key must be at most 128 MB")
+)
+
+type idempotencyStore interface {
+	Reserve(userID, key, fingerprint string, window time.Duration) (idempotency.Record, bool)
+	Complete(userID, key string, response idempotency.Response)
+	Release(userID, key string)
+}
+
+// IdempotencyMiddleware replays the saved response when a mutating request of a user comes again
+// with the same Idempotency-Key during the window. Keys are kept only for requests with a valid token,
+// anonymous requests and server errors are never replayed.
+func IdempotencyMiddleware(store idempotencyStore, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.Request.Header.Get(IdempotencyHeader)
+		if key == "" || !mutating(c.Request.Method) {
+			return
+		}
+		identity, err := ContextIdentity(c)
+		if err != nil {
+			return
+		}
+		userID := identity.UserID
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorModel{Error: ErrIdempotencyKey.Error()})
+			return
+		}
+
+		body, fingerprint, err := fingerprintRequest(c.Request)
+		if errors.Is(err, ErrIdempotencyBody) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorModel{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorModel{Error: err.Error()})
+			return
+		}
+		defer body.Close()
+		c.Request.Body = body
+
+		record, reserved := store.Reserve(userID, key, fingerprint, window)
+		if !reserved {
+			switch {
+			case record.Fingerprint != fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, ErrorModel{Error: ErrIdempotencyMismatch.Error()})
+			case record.Response == nil:
+				c.AbortWithStatusJSON(http.StatusConflict, ErrorModel{Error: ErrIdempotencyInFlight.Error()})
+			default:
+				c.Header(ReplayedHeader, "true")
+				c.Data(record.Response.Status, record.Response.ContentType, record.Response.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() {
+			// a panic is turned into 500 by the recovery middleware only after this
+			if p := recover(); p != nil {
+				store.Release(userID, key)
+				panic(p)
+			}
+			if !c.Writer.Written() || c.Writer.Status() >= http.StatusInternalServerError {
+				store.Release(userID, key)
+				return
+			}
+			store.Complete(userID, key, idempotency.Response{
+				Status:      c.Writer.Status(),
+				ContentType: c.Writer.Header().Get("Content-Type"),
+				Body:        w.body.Bytes(),
+			})
+		}()
+		c.Next()
+	}
+}
+
+func mutating(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// fingerprintRequest tells apart requests reusing the key for another route or body. It reads the body
+// and returns a copy for the handler, large bodies like uploads are hashed while they are spooled to disk.
+func fingerprintRequest(r *http.Request) (io.ReadCloser, string, error) {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	head, err := io.ReadAll(io.LimitReader(r.Body, maxBufferedBody+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(head) <= maxBufferedBody {
+		h.Write(head)
+		return io.NopCloser(bytes.NewReader(head)), hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	f, err := os.CreateTemp("", "idempotency-*")
+	if err != nil {
+		return nil, "", err
+	}
+	spooled := &spooledBody{File: f}
+	written, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(io.MultiReader(bytes.NewReader(head), r.Body), maxSpooledBody+1))
+	if err == nil && written > maxSpooledBody {
+		err = ErrIdempotencyBody
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	return spooled, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// spooledBody removes its temporary file when closed
+type spooledBody struct {
+	*os.File
+}
+
+func (b *spooledBody) Close() error {
+	err := b.File.Close()
+	os.Remove(b.File.Name())
+	return err
+}
+
+// recordingWriter keeps a copy of the response body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
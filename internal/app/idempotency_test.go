@@ -0,0 +1,202 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/pkg/idempotency"
+	"note-service/internal/pkg/jwt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	created := 0
+	g := gin.New()
+	g.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, UnknownError)
+	}))
+	g.Use(IdempotencyMiddleware(idempotency.NewInMemoryStore(), time.Hour))
+	g.POST("/note", AuthMiddleware(), func(c *gin.Context) {
+		created++
+		c.IndentedJSON(http.StatusCreated, gin.H{"id": strconv.Itoa(created)})
+	})
+	g.POST("/fail", AuthMiddleware(), func(c *gin.Context) {
+		created++
+		c.IndentedJSON(http.StatusInternalServerError, UnknownError)
+	})
+	g.POST("/panic", AuthMiddleware(), func(c *gin.Context) {
+		created++
+		panic("handler failed")
+	})
+	g.POST("/upload", AuthMiddleware(), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		created++
+		c.IndentedJSON(http.StatusCreated, gin.H{"size": len(body)})
+	})
+	large := strings.Repeat("a", maxBufferedBody+10)
+	token, _ := jwt.CreateToken("123-123")
+	otherToken, _ := jwt.CreateToken("321-321")
+
+	send := func(path, token, key, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(AccessHeader, token)
+		if key != "" {
+			req.Header.Set(IdempotencyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name             string
+		path             string
+		token            string
+		key              string
+		body             string
+		expectedCode     int
+		expectedBody     string
+		expectedReplayed bool
+		expectedCreated  int
+	}{
+		{
+			name:            "should handle first request",
+			path:            "/note",
+			token:           token,
+			key:             "a",
+			body:            `{"text":"hi"}`,
+			expectedCode:    http.StatusCreated,
+			expectedBody:    `{"id":"1"}`,
+			expectedCreated: 1,
+		},
+		{
+			name:             "should replay response",
+			path:             "/note",
+			token:            token,
+			key:              "a",
+			body:             `{"text":"hi"}`,
+			expectedCode:     http.StatusCreated,
+			expectedBody:     `{"id":"1"}`,
+			expectedReplayed: true,
+			expectedCreated:  1,
+		},
+		{
+			name:            "should return ErrIdempotencyMismatch",
+			path:            "/note",
+			token:           token,
+			key:             "a",
+			body:            `{"text":"bye"}`,
+			expectedCode:    http.StatusUnprocessableEntity,
+			expectedBody:    `{"error":"` + ErrIdempotencyMismatch.Error() + `"}`,
+			expectedCreated: 1,
+		},
+		{
+			name:            "should keep keys per user",
+			path:            "/note",
+			token:           otherToken,
+			key:             "a",
+			body:            `{"text":"hi"}`,
+			expectedCode:    http.StatusCreated,
+			expectedBody:    `{"id":"2"}`,
+			expectedCreated: 2,
+		},
+		{
+			name:            "should not keep requests without key",
+			path:            "/note",
+			token:           token,
+			body:            `{"text":"hi"}`,
+			expectedCode:    http.StatusCreated,
+			expectedBody:    `{"id":"3"}`,
+			expectedCreated: 3,
+		},
+		{
+			name:            "should return ErrIdempotencyKey",
+			path:            "/note",
+			token:           token,
+			key:             strings.Repeat("k", 256),
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    `{"error":"` + ErrIdempotencyKey.Error() + `"}`,
+			expectedCreated: 3,
+		},
+		{
+			name:            "should release key after server error",
+			path:            "/fail",
+			token:           token,
+			key:             "b",
+			expectedCode:    http.StatusInternalServerError,
+			expectedCreated: 4,
+		},
+		{
+			name:            "should retry after server error",
+			path:            "/fail",
+			token:           token,
+			key:             "b",
+			expectedCode:    http.StatusInternalServerError,
+			expectedCreated: 5,
+		},
+		{
+			name:            "should release key after panic",
+			path:            "/panic",
+			token:           token,
+			key:             "c",
+			expectedCode:    http.StatusInternalServerError,
+			expectedCreated: 6,
+		},
+		{
+			name:            "should retry after panic",
+			path:            "/panic",
+			token:           token,
+			key:             "c",
+			expectedCode:    http.StatusInternalServerError,
+			expectedCreated: 7,
+		},
+		{
+			name:            "should pass large body to handler",
+			path:            "/upload",
+			token:           token,
+			key:             "d",
+			body:            large,
+			expectedCode:    http.StatusCreated,
+			expectedBody:    `{"size":` + strconv.Itoa(len(large)) + `}`,
+			expectedCreated: 8,
+		},
+		{
+			name:             "should replay response to large body",
+			path:             "/upload",
+			token:            token,
+			key:              "d",
+			body:             large,
+			expectedCode:     http.StatusCreated,
+			expectedBody:     `{"size":` + strconv.Itoa(len(large)) + `}`,
+			expectedReplayed: true,
+			expectedCreated:  8,
+		},
+		{
+			name:            "should tell apart large bodies",
+			path:            "/upload",
+			token:           token,
+			key:             "d",
+			body:            large + "b",
+			expectedCode:    http.StatusUnprocessableEntity,
+			expectedCreated: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send(tt.path, tt.token, tt.key, tt.body)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+			assert.Equal(t, tt.expectedReplayed, w.Header().Get(ReplayedHeader) == "true")
+			assert.Equal(t, tt.expectedCreated, created)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package importer
+
+import (
+	importerpkg "note-service/internal/pkg/importer"
+)
+
+func jobToJobResponse(job importerpkg.Job) JobResponse {
+	items := make([]ItemResultResponse, len(job.Items))
+	for i, item := range job.Items {
+		items[i] = ItemResultResponse{
+			Index:  item.Index,
+			Name:   item.Name,
+			Status: item.Status,
+			NoteID: item.NoteID,
+			Error:  item.Error,
+		}
+	}
+	return JobResponse{
+		ID:         job.ID,
+		Status:     job.Status,
+		Created:    job.Created,
+		Skipped:    job.Skipped,
+		Failed:     job.Failed,
+		Items:      items,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
@@ -0,0 +1,23 @@
+package importer
+
+import "time"
+
+type JobResponse struct {
+	ID         string               `json:"id"`
+	Status     string               `json:"status"`
+	Created    int                  `json:"created"`
+	Skipped    int                  `json:"skipped"`
+	Failed     int                  `json:"failed"`
+	Items      []ItemResultResponse `json:"items"`
+	Error      string               `json:"error,omitempty"`
+	CreatedAt  time.Time            `json:"createdAt"`
+	FinishedAt *time.Time           `json:"finishedAt,omitempty"`
+}
+
+type ItemResultResponse struct {
+	Index  int    `json:"index"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	NoteID string `json:"noteId,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"note-service/internal/app"
+	importerpkg "note-service/internal/pkg/importer"
+	"note-service/internal/pkg/scope"
+)
+
+type importService interface {
+	StartJob(userID string, r io.Reader) (importerpkg.Job, error)
+	GetJob(id, userID string) (importerpkg.Job, error)
+	ResumeJob(id, userID string) (importerpkg.Job, error)
+	DeleteJob(id, userID string) error
+}
+
+// maxRequestSize leaves room for the multipart envelope around the file
+const maxRequestSize = importerpkg.MaxFileSize + 1<<20
+
+type Router struct {
+	service importService
+	logger  *zap.Logger
+}
+
+func NewRouter(service importService, logger *zap.Logger) *Router {
+	return &Router{service: service, logger: logger}
+}
+
+func (r *Router) SetUpRouter(engine *gin.Engine) {
+	engine.POST("/notes/import", app.AuthMiddleware(scope.NotesWrite), r.startJob)
+	engine.GET("/notes/import/:id", app.AuthMiddleware(scope.NotesWrite), r.getJob)
+	engine.POST("/notes/import/:id/resume", app.AuthMiddleware(scope.NotesWrite), r.resumeJob)
+	engine.DELETE("/notes/import/:id", app.AuthMiddleware(scope.NotesWrite), r.deleteJob)
+}
+
+func (r *Router) startJob(c *gin.Context) {
+	if c.Request.ContentLength > maxRequestSize {
+		c.IndentedJSON(http.StatusRequestEntityTooLarge, app.ErrorModel{Error: importerpkg.ErrTooLarge.Error()})
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestSize)
+	header, err := c.FormFile("file")
+	if err != nil || header.Size == 0 {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: ErrFileEmpty.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		r.logger.Error("failed to open uploaded file", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+		return
+	}
+	defer file.Close()
+
+	job, err := r.service.StartJob(c.GetString("userId"), file)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("import job is started", zap.String("jobID", job.ID))
+	c.IndentedJSON(http.StatusAccepted, jobToJobResponse(job))
+}
+
+func (r *Router) getJob(c *gin.Context) {
+	job, err := r.service.GetJob(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, jobToJobResponse(job))
+}
+
+func (r *Router) resumeJob(c *gin.Context) {
+	job, err := r.service.ResumeJob(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("import job is resumed", zap.String("jobID", job.ID))
+	c.IndentedJSON(http.StatusAccepted, jobToJobResponse(job))
+}
+
+func (r *Router) deleteJob(c *gin.Context) {
+	if err := r.service.DeleteJob(c.Param("id"), c.GetString("userId")); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("import job was deleted")
+	c.IndentedJSON(http.StatusOK, gin.H{"import": "import job successfully deleted"})
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, importerpkg.ErrJobNotFound):
+		c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, app.ErrNoAccess):
+		c.IndentedJSON(http.StatusForbidden, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, importerpkg.ErrFormat), errors.Is(err, importerpkg.ErrTooManyEntries):
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, importerpkg.ErrTooLarge):
+		c.IndentedJSON(http.StatusRequestEntityTooLarge, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, importerpkg.ErrJobNotResumable):
+		c.IndentedJSON(http.StatusConflict, app.ErrorModel{Error: err.Error()})
+	default:
+		r.logger.Error("failed to handle import", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+	}
+}
@@ -0,0 +1,192 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/app"
+	"note-service/internal/pkg/importer"
+	"note-service/internal/pkg/jwt"
+	"testing"
+	"time"
+)
+
+type importServiceMock struct {
+	StartJobFunc  func(userID string, r io.Reader) (importer.Job, error)
+	GetJobFunc    func(id, userID string) (importer.Job, error)
+	ResumeJobFunc func(id, userID string) (importer.Job, error)
+	DeleteJobFunc func(id, userID string) error
+}
+
+func (m *importServiceMock) StartJob(userID string, r io.Reader) (importer.Job, error) {
+	return m.StartJobFunc(userID, r)
+}
+
+func (m *importServiceMock) GetJob(id, userID string) (importer.Job, error) {
+	return m.GetJobFunc(id, userID)
+}
+
+func (m *importServiceMock) ResumeJob(id, userID string) (importer.Job, error) {
+	return m.ResumeJobFunc(id, userID)
+}
+
+func (m *importServiceMock) DeleteJob(id, userID string) error {
+	return m.DeleteJobFunc(id, userID)
+}
+
+func multipartBody(field, content string) (io.Reader, string) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if field != "" {
+		fw, _ := mw.CreateFormFile(field, "notes.zip")
+		fw.Write([]byte(content))
+	}
+	mw.Close()
+	return &body, mw.FormDataContentType()
+}
+
+func TestImportRoutes(t *testing.T) {
+	job := importer.Job{ID: "1", UserID: "123-123", Status: importer.JobDone, Created: 1, Skipped: 1,
+		Items: []importer.ItemResult{
+			{Index: 0, Name: "notes/a.md", Status: importer.ItemCreated, NoteID: "n"},
+			{Index: 1, Name: "notes/b.md", Status: importer.ItemSkipped, Error: importer.ErrDuplicate.Error()},
+		},
+		CreatedAt: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		name          string
+		importService importServiceMock
+		method        string
+		path          string
+		field         string
+		expectedCode  int
+		expectedError *app.ErrorModel
+		expectedBody  any
+	}{
+		{
+			name:          "should return ErrFileEmpty",
+			method:        http.MethodPost,
+			path:          "/notes/import",
+			field:         "other",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: ErrFileEmpty.Error()},
+		},
+		{
+			name:   "should return ErrFormat",
+			method: http.MethodPost,
+			path:   "/notes/import",
+			field:  "file",
+			importService: importServiceMock{
+				StartJobFunc: func(userID string, r io.Reader) (importer.Job, error) {
+					return importer.Job{}, importer.ErrFormat
+				},
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: importer.ErrFormat.Error()},
+		},
+		{
+			name:   "should return ErrTooLarge",
+			method: http.MethodPost,
+			path:   "/notes/import",
+			field:  "file",
+			importService: importServiceMock{
+				StartJobFunc: func(userID string, r io.Reader) (importer.Job, error) {
+					return importer.Job{}, importer.ErrTooLarge
+				},
+			},
+			expectedCode:  http.StatusRequestEntityTooLarge,
+			expectedError: &app.ErrorModel{Error: importer.ErrTooLarge.Error()},
+		},
+		{
+			name:   "should start job",
+			method: http.MethodPost,
+			path:   "/notes/import",
+			field:  "file",
+			importService: importServiceMock{
+				StartJobFunc: func(userID string, r io.Reader) (importer.Job, error) {
+					data, _ := io.ReadAll(r)
+					if userID != "123-123" || string(data) != "zip" {
+						return importer.Job{}, errors.New("unexpected import")
+					}
+					return importer.Job{ID: "1", Status: importer.JobPending, CreatedAt: job.CreatedAt}, nil
+				},
+			},
+			expectedCode: http.StatusAccepted,
+			expectedBody: JobResponse{ID: "1", Status: importer.JobPending, Items: []ItemResultResponse{}, CreatedAt: job.CreatedAt},
+		},
+		{
+			name:   "should return job report",
+			method: http.MethodGet,
+			path:   "/notes/import/1",
+			importService: importServiceMock{
+				GetJobFunc: func(id, userID string) (importer.Job, error) {
+					return job, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: jobToJobResponse(job),
+		},
+		{
+			name:   "should return ErrJobNotResumable",
+			method: http.MethodPost,
+			path:   "/notes/import/1/resume",
+			importService: importServiceMock{
+				ResumeJobFunc: func(id, userID string) (importer.Job, error) {
+					return importer.Job{}, importer.ErrJobNotResumable
+				},
+			},
+			expectedCode:  http.StatusConflict,
+			expectedError: &app.ErrorModel{Error: importer.ErrJobNotResumable.Error()},
+		},
+		{
+			name:   "should return ErrNoAccess",
+			method: http.MethodDelete,
+			path:   "/notes/import/1",
+			importService: importServiceMock{
+				DeleteJobFunc: func(id, userID string) error {
+					return app.ErrNoAccess
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: app.ErrNoAccess.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.importService, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			body, contentType := multipartBody(tt.field, "zip")
+			req, _ := http.NewRequest(tt.method, tt.path, body)
+			req.Header.Set("Content-Type", contentType)
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedBody != nil {
+				expected, err := json.Marshal(tt.expectedBody)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), w.Body.String())
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
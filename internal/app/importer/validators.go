@@ -0,0 +1,5 @@
+package importer
+
+import "errors"
+
+var ErrFileEmpty = errors.New("empty file, send it as multipart form field \"file\"")
@@ -4,21 +4,115 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"note-service/internal/pkg/apikey"
 	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/scope"
+	"strings"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// KeyAuthenticator checks personal API keys
+type KeyAuthenticator interface {
+	Authenticate(secret string) (apikey.Key, error)
+}
+
+var keyAuthenticator KeyAuthenticator
+
+// SetKeyAuthenticator makes AuthMiddleware accept API keys besides jwt-tokens
+func SetKeyAuthenticator(authenticator KeyAuthenticator) {
+	keyAuthenticator = authenticator
+}
+
+// Identity is who made the request, KeyID is set when it is authenticated by an API key
+type Identity struct {
+	UserID string
+	KeyID  string
+	Scopes []string
+}
+
+// Authenticate reads a jwt-token or an API key from X-Access-Token or from Authorization: Bearer
+func Authenticate(r *http.Request) (Identity, error) {
+	token := r.Header.Get(AccessHeader)
+	if token == "" {
+		if scheme, credentials, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+			token = strings.TrimSpace(credentials)
+		}
+	}
+	if token == "" {
+		return Identity{}, errors.New("empty token")
+	}
+	if strings.HasPrefix(token, apikey.Prefix) {
+		if keyAuthenticator == nil {
+			return Identity{}, apikey.ErrInvalidKey
+		}
+		key, err := keyAuthenticator.Authenticate(token)
+		if err != nil {
+			return Identity{}, err
+		}
+		return Identity{UserID: key.UserID, KeyID: key.ID, Scopes: key.Scopes}, nil
+	}
+	claims, err := jwt.ParseClaims(token)
+	if err != nil {
+		return Identity{}, errors.New("jwt parse error")
+	}
+	return Identity{UserID: claims.UserID, Scopes: claims.Scopes}, nil
+}
+
+// identityKey keeps the result of the first authentication of the request in the gin context
+const identityKey = "identity"
+
+type authResult struct {
+	identity Identity
+	err      error
+}
+
+// ContextIdentity authenticates the request once, later middlewares and handlers get the same result,
+// so an API key is looked up and touched once per request
+func ContextIdentity(c *gin.Context) (Identity, error) {
+	if v, ok := c.Get(identityKey); ok {
+		res := v.(authResult)
+		return res.identity, res.err
+	}
+	identity, err := Authenticate(c.Request)
+	c.Set(identityKey, authResult{identity: identity, err: err})
+	return identity, err
+}
+
+// AuthMiddleware authenticates the request and checks that its token or API key has the scopes
+// the route needs. A route without scopes needs full access, which only credentials without scopes have.
+func AuthMiddleware(required ...string) gin.HandlerFunc {
+	if len(required) == 0 {
+		required = []string{scope.Full}
+	}
 	return func(c *gin.Context) {
-		userToken := c.Request.Header.Get("X-Access-Token")
-		if userToken == "" {
-			c.AbortWithError(http.StatusUnauthorized, errors.New("empty token"))
+		identity, err := ContextIdentity(c)
+		if err != nil {
+			c.AbortWithError(http.StatusUnauthorized, err)
 			return
 		}
-		userID, err := jwt.ParseToken(userToken)
-		if err != nil {
-			c.AbortWithError(http.StatusUnauthorized, errors.New("jwt parse error"))
+		for _, name := range required {
+			if !scope.Allows(identity.Scopes, name) {
+				c.AbortWithStatusJSON(http.StatusForbidden, ScopeErrorModel{Error: ErrMissingScope.Error(), Scope: name})
+				return
+			}
+		}
+		c.Set("userId", identity.UserID)
+		if identity.KeyID != "" {
+			c.Set("apiKeyId", identity.KeyID)
+		}
+	}
+}
+
+// AdminChecker tells whether the user is an enabled admin
+type AdminChecker interface {
+	IsAdmin(userID string) bool
+}
+
+// AdminMiddleware lets through only admins, it goes after AuthMiddleware
+func AdminMiddleware(admins AdminChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !admins.IsAdmin(c.GetString("userId")) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorModel{Error: ErrNoAccess.Error()})
 			return
 		}
-		c.Set("userId", userID)
 	}
 }
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/pkg/apikey"
+	"note-service/internal/pkg/idempotency"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/ratelimit"
+	"note-service/internal/pkg/scope"
+	"strings"
+	"testing"
+	"time"
+)
+
+type countingKeyAuthenticator struct {
+	calls int
+}
+
+func (a *countingKeyAuthenticator) Authenticate(secret string) (apikey.Key, error) {
+	a.calls++
+	return apikey.Key{ID: "key-1", UserID: "123-123"}, nil
+}
+
+func TestAuthenticateOnce(t *testing.T) {
+	keys := &countingKeyAuthenticator{}
+	SetKeyAuthenticator(keys)
+	defer SetKeyAuthenticator(nil)
+	rules, err := ratelimit.ParseRules("*=600/m")
+	require.NoError(t, err)
+
+	g := gin.New()
+	g.Use(RateLimitMiddleware(ratelimit.NewInMemoryStore(), rules), IdempotencyMiddleware(idempotency.NewInMemoryStore(), time.Hour))
+	g.POST("/note", AuthMiddleware(scope.NotesWrite), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("apiKeyId"))
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/note", strings.NewReader("{}"))
+	req.Header.Set("Authorization", "Bearer "+apikey.Prefix+"secret")
+	req.Header.Set(IdempotencyHeader, "key")
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "key-1", w.Body.String())
+	require.Equal(t, 1, keys.calls, "the key is looked up once per request")
+}
+
+func TestAuthMiddlewareScopes(t *testing.T) {
+	g := gin.New()
+	g.GET("/note", AuthMiddleware(scope.NotesRead), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userId"))
+	})
+	g.POST("/note", AuthMiddleware(scope.NotesWrite), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userId"))
+	})
+	g.DELETE("/user/me", AuthMiddleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userId"))
+	})
+	full, _ := jwt.CreateToken("123-123")
+	readOnly, _ := jwt.CreateToken("123-123", scope.NotesRead)
+	writer, _ := jwt.CreateToken("123-123", scope.NotesWrite)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		token         string
+		expectedCode  int
+		expectedScope string
+	}{
+		{name: "should allow full token", method: http.MethodPost, path: "/note", token: full, expectedCode: http.StatusOK},
+		{name: "should allow read scope", method: http.MethodGet, path: "/note", token: readOnly, expectedCode: http.StatusOK},
+		{name: "should name missing write scope", method: http.MethodPost, path: "/note", token: readOnly,
+			expectedCode: http.StatusForbidden, expectedScope: scope.NotesWrite},
+		{name: "should read with write scope", method: http.MethodGet, path: "/note", token: writer, expectedCode: http.StatusOK},
+		{name: "should require full access without declared scope", method: http.MethodDelete, path: "/user/me", token: writer,
+			expectedCode: http.StatusForbidden, expectedScope: scope.Full},
+		{name: "should reject bad token", method: http.MethodGet, path: "/note", token: "bad", expectedCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedScope != "" {
+				var model ScopeErrorModel
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &model))
+				assert.Equal(t, ScopeErrorModel{Error: ErrMissingScope.Error(), Scope: tt.expectedScope}, model)
+			}
+		})
+	}
+}
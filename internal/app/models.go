@@ -1,11 +1,20 @@
 package app
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+)
 
 type ErrorModel struct {
 	Error string `json:"error"`
 }
 
+// ScopeErrorModel names the scope the token or API key lacks
+type ScopeErrorModel struct {
+	Error string `json:"error"`
+	Scope string `json:"scope"`
+}
+
 type TokenModel struct {
 	Token string `json:"token"`
 }
@@ -25,6 +34,8 @@ func (ve ValidationErrors) Error() string {
 	return string(res)
 }
 
+var ErrMissingScope = errors.New("token has no scope for this action")
+
 var UnknownError = ErrorModel{"Unknown error"}
 var UrlIDError = ErrorModel{"id in url and json not equal"}
 var AccessHeader = "X-Access-Token"
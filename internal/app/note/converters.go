@@ -6,45 +6,149 @@ import (
 
 func updateRequestToNote(request UpdateRequest) notepkg.Note {
 	return notepkg.Note{
-		ID:          request.ID,
-		UserID:      request.UserID,
-		Subject:     request.Subject,
-		Text:        request.Text,
-		TTL:         request.TTL,
-		IsPublic:    request.IsPublic,
-		PublicUsers: request.PublicUsers,
+		ID:               request.ID,
+		UserID:           request.UserID,
+		Subject:          request.Subject,
+		Text:             request.Text,
+		ContentType:      request.ContentType,
+		Kind:             request.Kind,
+		Items:            itemRequestsToItems(request.Items),
+		TTL:              request.TTL,
+		IsPublic:         request.IsPublic,
+		PublicUsers:      request.PublicUsers,
+		NotifyExpiration: request.NotifyExpiration,
+		PublishAt:        request.PublishAt,
+		DueAt:            request.DueAt,
 	}
 }
 
 func postRequestToNote(request PostRequest) notepkg.Note {
 	return notepkg.Note{
-		UserID:      request.UserID,
-		Subject:     request.Subject,
-		Text:        request.Text,
-		TTL:         request.TTL,
-		IsPublic:    request.IsPublic,
-		PublicUsers: request.PublicUsers,
+		UserID:           request.UserID,
+		Subject:          request.Subject,
+		Text:             request.Text,
+		ContentType:      request.ContentType,
+		Kind:             request.Kind,
+		Items:            itemRequestsToItems(request.Items),
+		TTL:              request.TTL,
+		IsPublic:         request.IsPublic,
+		PublicUsers:      request.PublicUsers,
+		NotifyExpiration: request.NotifyExpiration,
+		PublishAt:        request.PublishAt,
+		DueAt:            request.DueAt,
 	}
 }
 
-func noteToNoteResponse(note notepkg.Note) NoteResponse {
-	return NoteResponse{
-		ID:          note.ID,
-		UserID:      note.UserID,
-		Subject:     note.Subject,
-		Text:        note.Text,
-		TTL:         note.TTL,
-		IsPublic:    note.IsPublic,
-		PublicUsers: note.PublicUsers,
-		CreatedAt:   note.CreatedAt,
-		UpdatedAt:   note.UpdatedAt,
+func NoteToNoteResponse(note notepkg.Note) NoteResponse {
+	res := NoteResponse{
+		ID:               note.ID,
+		UserID:           note.UserID,
+		Subject:          note.Subject,
+		Text:             note.Text,
+		ContentType:      note.ContentType,
+		Kind:             note.Kind,
+		TTL:              note.TTL,
+		IsPublic:         note.IsPublic,
+		PublicUsers:      note.PublicUsers,
+		NotifyExpiration: note.NotifyExpiration,
+		PublishAt:        note.PublishAt,
+		Published:        note.Published,
+		DueAt:            note.DueAt,
+		Pinned:           note.Marks.Pinned,
+		Starred:          note.Marks.Starred,
+		Archived:         note.Marks.Archived,
+		CreatedAt:        note.CreatedAt,
+		UpdatedAt:        note.UpdatedAt,
 	}
+	if note.Kind == notepkg.KindChecklist {
+		items := itemsToItemResponses(note.Items)
+		done, total := note.Progress()
+		res.Items = &items
+		res.Progress = &ProgressResponse{Done: done, Total: total}
+	}
+	return res
 }
 
 func notesToNoteResponses(notes []notepkg.Note) []NoteResponse {
 	res := make([]NoteResponse, len(notes))
 	for i, note := range notes {
-		res[i] = noteToNoteResponse(note)
+		res[i] = NoteToNoteResponse(note)
+	}
+	return res
+}
+
+func itemRequestsToItems(requests *[]ItemRequest) []notepkg.ChecklistItem {
+	if requests == nil {
+		return nil
+	}
+	res := make([]notepkg.ChecklistItem, len(*requests))
+	for i, r := range *requests {
+		res[i] = notepkg.ChecklistItem{ID: r.ID, Text: r.Text, Checked: r.Checked}
+	}
+	return res
+}
+
+func itemsToItemResponses(items []notepkg.ChecklistItem) []ChecklistItemResponse {
+	res := make([]ChecklistItemResponse, len(items))
+	for i, item := range items {
+		res[i] = ChecklistItemResponse{
+			ID:       item.ID,
+			Text:     item.Text,
+			Checked:  item.Checked,
+			Position: item.Position,
+		}
+	}
+	return res
+}
+
+func linksToLinkResponses(links []notepkg.Link) []LinkResponse {
+	res := make([]LinkResponse, len(links))
+	for i, l := range links {
+		res[i] = LinkResponse{Target: l.Target, Broken: l.Broken}
+		if !l.Broken {
+			n := NoteToNoteResponse(l.Note)
+			res[i].Note = &n
+		}
 	}
 	return res
 }
+
+func marksRequestToMarksUpdate(request MarksRequest) notepkg.MarksUpdate {
+	return notepkg.MarksUpdate{
+		Pinned:   request.Pinned,
+		Starred:  request.Starred,
+		Archived: request.Archived,
+	}
+}
+
+func batchOperationToUpdateRequest(op BatchOperation, userID string) UpdateRequest {
+	n := op.Note
+	return UpdateRequest{
+		ID:               op.ID,
+		UserID:           userID,
+		Subject:          n.Subject,
+		Text:             n.Text,
+		ContentType:      n.ContentType,
+		Kind:             n.Kind,
+		TTL:              n.TTL,
+		IsPublic:         n.IsPublic,
+		PublicUsers:      n.PublicUsers,
+		NotifyExpiration: n.NotifyExpiration,
+		PublishAt:        n.PublishAt,
+		DueAt:            n.DueAt,
+		Items:            n.Items,
+	}
+}
+
+func batchOperationToBatchOp(op BatchOperation, userID string) notepkg.BatchOp {
+	switch op.Op {
+	case notepkg.OpCreate:
+		request := *op.Note
+		request.UserID = userID
+		return notepkg.BatchOp{Type: op.Op, Note: postRequestToNote(request)}
+	case notepkg.OpUpdate:
+		return notepkg.BatchOp{Type: op.Op, Note: updateRequestToNote(batchOperationToUpdateRequest(op, userID))}
+	default:
+		return notepkg.BatchOp{Type: op.Op, Note: notepkg.Note{ID: op.ID, IsPublic: op.IsPublic, PublicUsers: op.PublicUsers}}
+	}
+}
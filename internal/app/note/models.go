@@ -3,32 +3,126 @@ package note
 import "time"
 
 type NoteResponse struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"userId"`
-	Subject     string    `json:"subject"`
-	Text        string    `json:"text"`
-	TTL         *int64    `json:"ttl"`
-	IsPublic    bool      `json:"isPublic"`
-	PublicUsers *[]string `json:"publicUsers"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID               string                   `json:"id"`
+	UserID           string                   `json:"userId"`
+	Subject          string                   `json:"subject"`
+	Text             string                   `json:"text"`
+	ContentType      string                   `json:"contentType"`
+	Kind             string                   `json:"kind"`
+	TTL              *int64                   `json:"ttl"`
+	IsPublic         bool                     `json:"isPublic"`
+	PublicUsers      *[]string                `json:"publicUsers"`
+	NotifyExpiration bool                     `json:"notifyExpiration"`
+	PublishAt        *int64                   `json:"publishAt"`
+	Published        bool                     `json:"published"`
+	DueAt            *int64                   `json:"dueAt"`
+	Pinned           bool                     `json:"pinned"`
+	Starred          bool                     `json:"starred"`
+	Archived         bool                     `json:"archived"`
+	HTML             string                   `json:"html,omitempty"`
+	Items            *[]ChecklistItemResponse `json:"items,omitempty"`
+	Progress         *ProgressResponse        `json:"progress,omitempty"`
+	CreatedAt        time.Time                `json:"createdAt"`
+	UpdatedAt        time.Time                `json:"updatedAt"`
 }
 
 type PostRequest struct {
-	UserID      string    `json:"userId"`
-	Subject     string    `json:"subject"`
-	Text        string    `json:"text"`
-	TTL         *int64    `json:"ttl"`
-	IsPublic    bool      `json:"isPublic"`
-	PublicUsers *[]string `json:"publicUsers"`
+	UserID           string         `json:"userId"`
+	Subject          string         `json:"subject"`
+	Text             string         `json:"text"`
+	ContentType      string         `json:"contentType"`
+	Kind             string         `json:"kind"`
+	TTL              *int64         `json:"ttl"`
+	IsPublic         bool           `json:"isPublic"`
+	PublicUsers      *[]string      `json:"publicUsers"`
+	NotifyExpiration bool           `json:"notifyExpiration"`
+	PublishAt        *int64         `json:"publishAt"`
+	DueAt            *int64         `json:"dueAt"`
+	Items            *[]ItemRequest `json:"items"`
 }
 
 type UpdateRequest struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"userId"`
-	Subject     string    `json:"subject"`
-	Text        string    `json:"text"`
-	TTL         *int64    `json:"ttl"`
-	IsPublic    bool      `json:"isPublic"`
-	PublicUsers *[]string `json:"publicUsers"`
+	ID               string         `json:"id"`
+	UserID           string         `json:"userId"`
+	Subject          string         `json:"subject"`
+	Text             string         `json:"text"`
+	ContentType      string         `json:"contentType"`
+	Kind             string         `json:"kind"`
+	TTL              *int64         `json:"ttl"`
+	IsPublic         bool           `json:"isPublic"`
+	PublicUsers      *[]string      `json:"publicUsers"`
+	NotifyExpiration bool           `json:"notifyExpiration"`
+	PublishAt        *int64         `json:"publishAt"`
+	DueAt            *int64         `json:"dueAt"`
+	Items            *[]ItemRequest `json:"items"`
+}
+
+type ChecklistItemResponse struct {
+	ID       string `json:"id"`
+	Text     string `json:"text"`
+	Checked  bool   `json:"checked"`
+	Position int    `json:"position"`
+}
+
+type ProgressResponse struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// ItemRequest keeps the item with ID when it is sent in update, an item without ID is new
+type ItemRequest struct {
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	Checked bool   `json:"checked"`
+}
+
+type AddItemRequest struct {
+	Text string `json:"text"`
+}
+
+type ReorderItemsRequest struct {
+	ItemIDs []string `json:"itemIds"`
+}
+
+type LinkResponse struct {
+	Target string        `json:"target"`
+	Broken bool          `json:"broken"`
+	Note   *NoteResponse `json:"note,omitempty"`
+}
+
+type MarksRequest struct {
+	Pinned   *bool `json:"pinned"`
+	Starred  *bool `json:"starred"`
+	Archived *bool `json:"archived"`
+}
+
+type BulkMarksRequest struct {
+	NoteIDs []string `json:"noteIds"`
+	MarksRequest
+}
+
+type BatchRequest struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation takes note for create and update, isPublic and publicUsers for share
+type BatchOperation struct {
+	Op          string       `json:"op"`
+	ID          string       `json:"id"`
+	Note        *PostRequest `json:"note"`
+	IsPublic    bool         `json:"isPublic"`
+	PublicUsers *[]string    `json:"publicUsers"`
+}
+
+type BatchResult struct {
+	Status int               `json:"status"`
+	Note   *NoteResponse     `json:"note,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+type BatchResponse struct {
+	Applied bool          `json:"applied"`
+	Results []BatchResult `json:"results"`
 }
@@ -6,32 +6,61 @@ import (
 	"go.uber.org/zap"
 	"net/http"
 	"note-service/internal/app"
+	"note-service/internal/pkg/audit"
 	notepkg "note-service/internal/pkg/note"
+	"note-service/internal/pkg/scope"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type noteService interface {
 	CreateNote(note notepkg.Note) (notepkg.Note, error)
 	FindNoteByID(id, userIDs string) (notepkg.Note, error)
-	GetNotes(userID, param string) ([]notepkg.Note, error)
+	GetNotes(userID string, opts notepkg.ListOptions) ([]notepkg.Note, error)
 	UpdateNote(note notepkg.Note) (notepkg.Note, error)
 	DeleteNote(id, userID string) error
+	RenderNote(id, userID string) (notepkg.Note, string, error)
+	AddItem(noteID, userID, text string) (notepkg.Note, error)
+	ToggleItem(noteID, itemID, userID string) (notepkg.Note, error)
+	ReorderItems(noteID, userID string, itemIDs []string) (notepkg.Note, error)
+	DeleteItem(noteID, itemID, userID string) (notepkg.Note, error)
+	GetLinks(id, userID string) ([]notepkg.Link, error)
+	GetBacklinks(id, userID string) ([]notepkg.Note, error)
+	SetMarks(noteIDs []string, userID string, update notepkg.MarksUpdate) ([]notepkg.Note, error)
+	Batch(userID string, ops []notepkg.BatchOp, atomic bool) []notepkg.BatchResult
+}
+
+type auditLog interface {
+	Record(e audit.Event)
 }
 
 type Router struct {
 	service noteService
+	audit   auditLog
 	logger  *zap.Logger
 }
 
-func NewRouter(service noteService, logger *zap.Logger) *Router {
-	return &Router{service: service, logger: logger}
+func NewRouter(service noteService, auditLog auditLog, logger *zap.Logger) *Router {
+	return &Router{service: service, audit: auditLog, logger: logger}
 }
 
+// SetUpRouter declares the scope of each route, reading needs notes:read and changes need notes:write
 func (r *Router) SetUpRouter(engine *gin.Engine) {
-	engine.GET("/notes", app.AuthMiddleware(), r.getNotes)
-	engine.GET("/note/:id", app.AuthMiddleware(), r.getNoteByID)
-	engine.POST("/note", app.AuthMiddleware(), r.postNote)
-	engine.PUT("/note/:id", app.AuthMiddleware(), r.updateNote)
-	engine.DELETE("/note/:id", app.AuthMiddleware(), r.deleteNote)
+	engine.GET("/notes", app.AuthMiddleware(scope.NotesRead), r.getNotes)
+	engine.GET("/note/:id", app.AuthMiddleware(scope.NotesRead), r.getNoteByID)
+	engine.POST("/note", app.AuthMiddleware(scope.NotesWrite), r.postNote)
+	engine.PUT("/note/:id", app.AuthMiddleware(scope.NotesWrite), r.updateNote)
+	engine.DELETE("/note/:id", app.AuthMiddleware(scope.NotesWrite), r.deleteNote)
+	engine.POST("/note/:id/items", app.AuthMiddleware(scope.NotesWrite), r.addItem)
+	engine.POST("/note/:id/items/:itemId/toggle", app.AuthMiddleware(scope.NotesWrite), r.toggleItem)
+	engine.PUT("/note/:id/items/order", app.AuthMiddleware(scope.NotesWrite), r.reorderItems)
+	engine.DELETE("/note/:id/items/:itemId", app.AuthMiddleware(scope.NotesWrite), r.deleteItem)
+	engine.GET("/note/:id/links", app.AuthMiddleware(scope.NotesRead), r.getLinks)
+	engine.GET("/note/:id/backlinks", app.AuthMiddleware(scope.NotesRead), r.getBacklinks)
+	engine.PUT("/note/:id/marks", app.AuthMiddleware(scope.NotesWrite), r.setMarks)
+	engine.POST("/notes/marks", app.AuthMiddleware(scope.NotesWrite), r.setBulkMarks)
+	engine.POST("/notes/batch", app.AuthMiddleware(scope.NotesWrite), r.postBatch)
 }
 
 func (r *Router) postNote(c *gin.Context) {
@@ -52,11 +81,12 @@ func (r *Router) postNote(c *gin.Context) {
 	note := postRequestToNote(request)
 	n, err := r.service.CreateNote(note)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+		r.handleError(c, err)
 		return
 	}
-	r.logger.Info("note is created", zap.Any("note", noteToNoteResponse(n)))
-	c.IndentedJSON(http.StatusCreated, noteToNoteResponse(n))
+	r.record(c, audit.ActionNoteCreate, n)
+	r.logger.Info("note is created", zap.Any("note", NoteToNoteResponse(n)))
+	c.IndentedJSON(http.StatusCreated, NoteToNoteResponse(n))
 }
 
 func (r *Router) updateNote(c *gin.Context) {
@@ -79,19 +109,24 @@ func (r *Router) updateNote(c *gin.Context) {
 		return
 	}
 
+	// the note before the update tells whether the sharing was changed
+	current, err := r.service.FindNoteByID(request.ID, request.UserID)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
 	note := updateRequestToNote(request)
 	n, err := r.service.UpdateNote(note)
 	if err != nil {
-		if errors.Is(err, notepkg.ErrNoteNotFound) {
-			c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
-		} else {
-			r.logger.Error("failed to update note", zap.Error(err))
-			c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
-		}
+		r.handleError(c, err)
 		return
 	}
-	r.logger.Info("note was updated", zap.Any("note", noteToNoteResponse(n)))
-	c.IndentedJSON(http.StatusOK, noteToNoteResponse(n))
+	r.record(c, audit.ActionNoteUpdate, n)
+	if sharingChanged(current, n) {
+		r.record(c, audit.ActionNoteShare, n)
+	}
+	r.logger.Info("note was updated", zap.Any("note", NoteToNoteResponse(n)))
+	c.IndentedJSON(http.StatusOK, NoteToNoteResponse(n))
 }
 
 func (r *Router) deleteNote(c *gin.Context) {
@@ -99,13 +134,10 @@ func (r *Router) deleteNote(c *gin.Context) {
 	UserID := c.GetString("userId")
 	err := r.service.DeleteNote(id, UserID)
 	if err != nil {
-		if errors.Is(err, notepkg.ErrNoteNotFound) {
-			c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
-		} else {
-			c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
-		}
+		r.handleError(c, err)
 		return
 	}
+	r.audit.Record(app.AuditEvent(c, audit.ActionNoteDelete).On(audit.TargetNote, id, UserID))
 	r.logger.Info("note was deleted")
 	c.IndentedJSON(http.StatusOK, gin.H{"note": "note successfully deleted"})
 }
@@ -117,8 +149,14 @@ func (r *Router) getNotes(c *gin.Context) {
 		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
 		return
 	}
+	archived, errArchived := strconv.ParseBool(c.DefaultQuery("archived", "false"))
+	shared, errShared := strconv.ParseBool(c.DefaultQuery("shared", "false"))
+	if errArchived != nil || errShared != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: ErrListFlag.Error()})
+		return
+	}
 	userID := c.GetString("userId")
-	notes, err := r.service.GetNotes(userID, param)
+	notes, err := r.service.GetNotes(userID, notepkg.ListOptions{Sort: param, Archived: archived, Shared: shared})
 	if err != nil {
 		r.logger.Error("failed to get notes", zap.Error(err))
 		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
@@ -130,16 +168,259 @@ func (r *Router) getNotes(c *gin.Context) {
 func (r *Router) getNoteByID(c *gin.Context) {
 	id := c.Param("id")
 	userID := c.GetString("userId")
-	n, err := r.service.FindNoteByID(id, userID)
+	render := c.Query("render")
+	if render != "" && render != "html" {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: ErrRenderMode.Error()})
+		return
+	}
+
+	var n notepkg.Note
+	var html string
+	var err error
+	if render == "html" {
+		n, html, err = r.service.RenderNote(id, userID)
+	} else {
+		n, err = r.service.FindNoteByID(id, userID)
+	}
 	if err != nil {
-		if errors.Is(err, notepkg.ErrNoteNotFound) {
-			c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
-		} else {
-			r.logger.Error("failed to get note by id", zap.Error(err))
-			c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+		r.handleError(c, err)
+		return
+	}
+
+	response := NoteToNoteResponse(n)
+	response.HTML = html
+	c.IndentedJSON(http.StatusOK, response)
+}
+
+func (r *Router) addItem(c *gin.Context) {
+	var request AddItemRequest
+	if err := c.BindJSON(&request); err != nil {
+		r.logger.Error("failed to bind json", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	n, err := r.service.AddItem(c.Param("id"), c.GetString("userId"), request.Text)
+	r.respondChecklist(c, http.StatusCreated, n, err)
+}
+
+func (r *Router) toggleItem(c *gin.Context) {
+	n, err := r.service.ToggleItem(c.Param("id"), c.Param("itemId"), c.GetString("userId"))
+	r.respondChecklist(c, http.StatusOK, n, err)
+}
+
+func (r *Router) reorderItems(c *gin.Context) {
+	var request ReorderItemsRequest
+	if err := c.BindJSON(&request); err != nil {
+		r.logger.Error("failed to bind json", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	n, err := r.service.ReorderItems(c.Param("id"), c.GetString("userId"), request.ItemIDs)
+	r.respondChecklist(c, http.StatusOK, n, err)
+}
+
+func (r *Router) deleteItem(c *gin.Context) {
+	n, err := r.service.DeleteItem(c.Param("id"), c.Param("itemId"), c.GetString("userId"))
+	r.respondChecklist(c, http.StatusOK, n, err)
+}
+
+func (r *Router) respondChecklist(c *gin.Context, code int, n notepkg.Note, err error) {
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.record(c, audit.ActionNoteUpdate, n)
+	c.IndentedJSON(code, NoteToNoteResponse(n))
+}
+
+func (r *Router) getLinks(c *gin.Context) {
+	links, err := r.service.GetLinks(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, linksToLinkResponses(links))
+}
+
+func (r *Router) getBacklinks(c *gin.Context) {
+	notes, err := r.service.GetBacklinks(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, notesToNoteResponses(notes))
+}
+
+func (r *Router) setMarks(c *gin.Context) {
+	var request MarksRequest
+	if err := c.BindJSON(&request); err != nil {
+		r.logger.Error("failed to bind json", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	notes, err := r.service.SetMarks([]string{c.Param("id")}, c.GetString("userId"), marksRequestToMarksUpdate(request))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, NoteToNoteResponse(notes[0]))
+}
+
+func (r *Router) setBulkMarks(c *gin.Context) {
+	var request BulkMarksRequest
+	if err := c.BindJSON(&request); err != nil {
+		r.logger.Error("failed to bind json", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	notes, err := r.service.SetMarks(request.NoteIDs, c.GetString("userId"), marksRequestToMarksUpdate(request.MarksRequest))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, notesToNoteResponses(notes))
+}
+
+func (r *Router) postBatch(c *gin.Context) {
+	var request BatchRequest
+	if err := c.BindJSON(&request); err != nil {
+		r.logger.Error("failed to bind json", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	userID := c.GetString("userId")
+	results := make([]BatchResult, len(request.Operations))
+	ops := make([]notepkg.BatchOp, 0, len(request.Operations))
+	// positions[i] is the index of ops[i] in request
+	positions := make([]int, 0, len(request.Operations))
+	for i, op := range request.Operations {
+		if err := op.Validate(userID); err != nil {
+			var ve app.ValidationErrors
+			errors.As(err, &ve)
+			results[i] = BatchResult{Status: http.StatusBadRequest, Errors: ve.Errors}
+			continue
 		}
+		ops = append(ops, batchOperationToBatchOp(op, userID))
+		positions = append(positions, i)
+	}
+
+	if request.Atomic && len(ops) < len(request.Operations) {
+		for _, i := range positions {
+			results[i] = r.batchResult(notepkg.OpCreate, notepkg.BatchResult{Err: notepkg.ErrBatchAborted})
+		}
+		c.IndentedJSON(http.StatusOK, BatchResponse{Results: results})
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, noteToNoteResponse(n))
+	applied := len(ops) == len(request.Operations)
+	for j, res := range r.service.Batch(userID, ops, request.Atomic) {
+		results[positions[j]] = r.batchResult(ops[j].Type, res)
+		if res.Err != nil {
+			applied = false
+			continue
+		}
+		r.record(c, batchAuditActions[ops[j].Type], res.Note)
+	}
+	r.logger.Info("notes batch is done", zap.Int("operations", len(ops)), zap.Bool("applied", applied))
+	c.IndentedJSON(http.StatusOK, BatchResponse{Applied: applied, Results: results})
+}
+
+func (r *Router) batchResult(op string, res notepkg.BatchResult) BatchResult {
+	if res.Err != nil {
+		code, model := r.errorResponse(res.Err)
+		return BatchResult{Status: code, Error: model.Error}
+	}
+	n := NoteToNoteResponse(res.Note)
+	if op == notepkg.OpCreate {
+		return BatchResult{Status: http.StatusCreated, Note: &n}
+	}
+	return BatchResult{Status: http.StatusOK, Note: &n}
+}
+
+var batchAuditActions = map[string]string{
+	notepkg.OpCreate: audit.ActionNoteCreate,
+	notepkg.OpUpdate: audit.ActionNoteUpdate,
+	notepkg.OpDelete: audit.ActionNoteDelete,
+	notepkg.OpShare:  audit.ActionNoteShare,
+}
+
+// record writes a change of the note to the audit log, share events carry the new sharing
+func (r *Router) record(c *gin.Context, action string, n notepkg.Note) {
+	e := app.AuditEvent(c, action).On(audit.TargetNote, n.ID, n.UserID)
+	if action == audit.ActionNoteShare {
+		e = e.With("public", strconv.FormatBool(n.IsPublic)).With("users", strings.Join(publicUsers(n), ","))
+	}
+	r.audit.Record(e)
+}
+
+func sharingChanged(before, after notepkg.Note) bool {
+	if before.IsPublic != after.IsPublic {
+		return true
+	}
+	a, b := publicUsers(before), publicUsers(after)
+	if len(a) != len(b) {
+		return true
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// publicUsers returns a sorted copy, the order of users doesn't matter for sharing
+func publicUsers(n notepkg.Note) []string {
+	if n.PublicUsers == nil {
+		return nil
+	}
+	users := append([]string(nil), *n.PublicUsers...)
+	sort.Strings(users)
+	return users
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	c.IndentedJSON(r.errorResponse(err))
+}
+
+// errorResponse maps service errors of every note route, batch operations included
+func (r *Router) errorResponse(err error) (int, app.ErrorModel) {
+	switch {
+	case errors.Is(err, notepkg.ErrNoteNotFound), errors.Is(err, notepkg.ErrItemNotFound):
+		return http.StatusNotFound, app.ErrorModel{Error: err.Error()}
+	case errors.Is(err, app.ErrNoAccess):
+		return http.StatusForbidden, app.ErrorModel{Error: err.Error()}
+	case errors.Is(err, notepkg.ErrNotChecklist), errors.Is(err, notepkg.ErrItemOrder), errors.Is(err, notepkg.ErrUnknownOp):
+		return http.StatusBadRequest, app.ErrorModel{Error: err.Error()}
+	case errors.Is(err, notepkg.ErrBatchAborted):
+		return http.StatusFailedDependency, app.ErrorModel{Error: err.Error()}
+	default:
+		r.logger.Error("failed to handle note", zap.Error(err))
+		return http.StatusInternalServerError, app.UnknownError
+	}
 }
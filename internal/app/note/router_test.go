@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"note-service/internal/app"
+	"note-service/internal/pkg/audit"
 	"note-service/internal/pkg/jwt"
 	"note-service/internal/pkg/note"
 	"testing"
@@ -18,9 +19,18 @@ import (
 type noteServiceMock struct {
 	CreateNoteFunc   func(note note.Note) (note.Note, error)
 	FindNoteByIDFunc func(id, userIDs string) (note.Note, error)
-	GetNotesFunc     func(userID, param string) ([]note.Note, error)
+	GetNotesFunc     func(userID string, opts note.ListOptions) ([]note.Note, error)
 	UpdateNoteFunc   func(note note.Note) (note.Note, error)
 	DeleteNoteFunc   func(id, userID string) error
+	RenderNoteFunc   func(id, userID string) (note.Note, string, error)
+	AddItemFunc      func(noteID, userID, text string) (note.Note, error)
+	ToggleItemFunc   func(noteID, itemID, userID string) (note.Note, error)
+	ReorderItemsFunc func(noteID, userID string, itemIDs []string) (note.Note, error)
+	DeleteItemFunc   func(noteID, itemID, userID string) (note.Note, error)
+	GetLinksFunc     func(id, userID string) ([]note.Link, error)
+	GetBacklinksFunc func(id, userID string) ([]note.Note, error)
+	SetMarksFunc     func(noteIDs []string, userID string, update note.MarksUpdate) ([]note.Note, error)
+	BatchFunc        func(userID string, ops []note.BatchOp, atomic bool) []note.BatchResult
 }
 
 func (n *noteServiceMock) CreateNote(note note.Note) (note.Note, error) {
@@ -31,8 +41,8 @@ func (n *noteServiceMock) FindNoteByID(id, userID string) (note.Note, error) {
 	return n.FindNoteByIDFunc(id, userID)
 }
 
-func (n *noteServiceMock) GetNotes(userID, param string) ([]note.Note, error) {
-	return n.GetNotesFunc(userID, param)
+func (n *noteServiceMock) GetNotes(userID string, opts note.ListOptions) ([]note.Note, error) {
+	return n.GetNotesFunc(userID, opts)
 }
 
 func (n *noteServiceMock) UpdateNote(note note.Note) (note.Note, error) {
@@ -43,7 +53,52 @@ func (n *noteServiceMock) DeleteNote(id, userID string) error {
 	return n.DeleteNoteFunc(id, userID)
 }
 
+func (n *noteServiceMock) RenderNote(id, userID string) (note.Note, string, error) {
+	return n.RenderNoteFunc(id, userID)
+}
+
+func (n *noteServiceMock) AddItem(noteID, userID, text string) (note.Note, error) {
+	return n.AddItemFunc(noteID, userID, text)
+}
+
+func (n *noteServiceMock) ToggleItem(noteID, itemID, userID string) (note.Note, error) {
+	return n.ToggleItemFunc(noteID, itemID, userID)
+}
+
+func (n *noteServiceMock) ReorderItems(noteID, userID string, itemIDs []string) (note.Note, error) {
+	return n.ReorderItemsFunc(noteID, userID, itemIDs)
+}
+
+func (n *noteServiceMock) DeleteItem(noteID, itemID, userID string) (note.Note, error) {
+	return n.DeleteItemFunc(noteID, itemID, userID)
+}
+
+func (n *noteServiceMock) GetLinks(id, userID string) ([]note.Link, error) {
+	return n.GetLinksFunc(id, userID)
+}
+
+func (n *noteServiceMock) GetBacklinks(id, userID string) ([]note.Note, error) {
+	return n.GetBacklinksFunc(id, userID)
+}
+
+func (n *noteServiceMock) SetMarks(noteIDs []string, userID string, update note.MarksUpdate) ([]note.Note, error) {
+	return n.SetMarksFunc(noteIDs, userID, update)
+}
+
+func (n *noteServiceMock) Batch(userID string, ops []note.BatchOp, atomic bool) []note.BatchResult {
+	return n.BatchFunc(userID, ops, atomic)
+}
+
+type auditLogMock struct {
+	events []audit.Event
+}
+
+func (m *auditLogMock) Record(e audit.Event) {
+	m.events = append(m.events, e)
+}
+
 func TestCreateNote(t *testing.T) {
+	ttl, publishAt := int64(100), int64(200)
 	tests := []struct {
 		name          string
 		noteService   noteServiceMock
@@ -61,6 +116,16 @@ func TestCreateNote(t *testing.T) {
 			},
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			name:    "should return request error, publishAt after ttl",
+			Request: PostRequest{Text: "123", UserID: "123-123", TTL: &ttl, PublishAt: &publishAt},
+			noteService: noteServiceMock{
+				CreateNoteFunc: func(n note.Note) (note.Note, error) {
+					return note.Note{}, nil
+				},
+			},
+			expectedCode: http.StatusBadRequest,
+		},
 		{
 			name:    "should return unknownError",
 			Request: PostRequest{Text: "123", UserID: "123-123"},
@@ -81,7 +146,7 @@ func TestCreateNote(t *testing.T) {
 				},
 			},
 			expectedCode: http.StatusCreated,
-			expectedNote: noteToNoteResponse(note.Note{ID: "123-123-123", Text: "123", UserID: "123-123"}),
+			expectedNote: NoteToNoteResponse(note.Note{ID: "123-123-123", Text: "123", UserID: "123-123"}),
 		},
 	}
 
@@ -90,7 +155,7 @@ func TestCreateNote(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := gin.Default()
 			logger, _ := zap.NewProduction()
-			r := NewRouter(&tt.noteService, logger.Named(""))
+			r := NewRouter(&tt.noteService, &auditLogMock{}, logger.Named(""))
 			r.SetUpRouter(g)
 
 			jsonValue, _ := json.Marshal(tt.Request)
@@ -158,6 +223,9 @@ func TestUpdateNote(t *testing.T) {
 			Request: UpdateRequest{ID: "123-123", Text: "123"},
 			id:      "123-123",
 			noteService: noteServiceMock{
+				FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+					return note.Note{ID: id, UserID: userID}, nil
+				},
 				UpdateNoteFunc: func(n note.Note) (note.Note, error) {
 					return note.Note{}, note.ErrNoteNotFound
 				},
@@ -170,6 +238,9 @@ func TestUpdateNote(t *testing.T) {
 			Request: UpdateRequest{ID: "123-123", Text: "123"},
 			id:      "123-123",
 			noteService: noteServiceMock{
+				FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+					return note.Note{ID: id, UserID: userID}, nil
+				},
 				UpdateNoteFunc: func(n note.Note) (note.Note, error) {
 					return note.Note{}, errors.New("something wrong")
 				},
@@ -182,12 +253,15 @@ func TestUpdateNote(t *testing.T) {
 			Request: UpdateRequest{ID: "123-123", Text: "123"},
 			id:      "123-123",
 			noteService: noteServiceMock{
+				FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+					return note.Note{ID: id, UserID: userID}, nil
+				},
 				UpdateNoteFunc: func(n note.Note) (note.Note, error) {
 					return note.Note{ID: "123-123", Text: "123"}, nil
 				},
 			},
 			expectedCode: http.StatusOK,
-			expectedNote: noteToNoteResponse(note.Note{ID: "123-123", Text: "123"}),
+			expectedNote: NoteToNoteResponse(note.Note{ID: "123-123", Text: "123"}),
 		},
 	}
 
@@ -196,7 +270,7 @@ func TestUpdateNote(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := gin.Default()
 			logger, _ := zap.NewProduction()
-			r := NewRouter(&tt.noteService, logger.Named(""))
+			r := NewRouter(&tt.noteService, &auditLogMock{}, logger.Named(""))
 			r.SetUpRouter(g)
 
 			jsonValue, _ := json.Marshal(tt.Request)
@@ -274,7 +348,7 @@ func TestDeleteNote(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := gin.Default()
 			logger, _ := zap.NewProduction()
-			r := NewRouter(&tt.noteService, logger.Named(""))
+			r := NewRouter(&tt.noteService, &auditLogMock{}, logger.Named(""))
 			r.SetUpRouter(g)
 
 			w := httptest.NewRecorder()
@@ -316,7 +390,7 @@ func TestGetNotes(t *testing.T) {
 		{
 			name: "should return unknownError",
 			noteService: noteServiceMock{
-				GetNotesFunc: func(userID, param string) ([]note.Note, error) {
+				GetNotesFunc: func(userID string, opts note.ListOptions) ([]note.Note, error) {
 					return []note.Note{}, errors.New("something wrong")
 				},
 			},
@@ -326,7 +400,7 @@ func TestGetNotes(t *testing.T) {
 		{
 			name: "should return Notes",
 			noteService: noteServiceMock{
-				GetNotesFunc: func(userID, param string) ([]note.Note, error) {
+				GetNotesFunc: func(userID string, opts note.ListOptions) ([]note.Note, error) {
 					return []note.Note{{ID: "123-123", Text: "123-123"}, {ID: "123-124", Text: "123-123"}}, nil
 				},
 			},
@@ -339,7 +413,7 @@ func TestGetNotes(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := gin.Default()
 			logger, _ := zap.NewProduction()
-			r := NewRouter(&tt.noteService, logger.Named(""))
+			r := NewRouter(&tt.noteService, &auditLogMock{}, logger.Named(""))
 			r.SetUpRouter(g)
 
 			w := httptest.NewRecorder()
@@ -409,7 +483,7 @@ func TestGetNoteByID(t *testing.T) {
 				},
 			},
 			expectedCode: http.StatusOK,
-			expectedNote: noteToNoteResponse(note.Note{ID: "123-123", Text: "123"}),
+			expectedNote: NoteToNoteResponse(note.Note{ID: "123-123", Text: "123"}),
 		},
 	}
 
@@ -418,7 +492,7 @@ func TestGetNoteByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := gin.Default()
 			logger, _ := zap.NewProduction()
-			r := NewRouter(&tt.noteService, logger.Named(""))
+			r := NewRouter(&tt.noteService, &auditLogMock{}, logger.Named(""))
 			r.SetUpRouter(g)
 
 			w := httptest.NewRecorder()
@@ -447,3 +521,641 @@ func TestGetNoteByID(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRenderedNote(t *testing.T) {
+	tests := []struct {
+		name          string
+		noteService   noteServiceMock
+		render        string
+		expectedCode  int
+		expectedError *app.ErrorModel
+		expectedNote  NoteResponse
+	}{
+		{
+			name:          "should return ErrRenderMode",
+			render:        "pdf",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: ErrRenderMode.Error()},
+		},
+		{
+			name:   "should return errNoteNotFound",
+			render: "html",
+			noteService: noteServiceMock{
+				RenderNoteFunc: func(id, userID string) (note.Note, string, error) {
+					return note.Note{}, "", note.ErrNoteNotFound
+				},
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: &app.ErrorModel{Error: note.ErrNoteNotFound.Error()},
+		},
+		{
+			name:   "should return rendered note",
+			render: "html",
+			noteService: noteServiceMock{
+				RenderNoteFunc: func(id, userID string) (note.Note, string, error) {
+					return note.Note{ID: "123-123", Text: "**1**", ContentType: note.ContentTypeMarkdown}, "<p><strong>1</strong></p>", nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedNote: NoteResponse{ID: "123-123", Text: "**1**", ContentType: note.ContentTypeMarkdown, HTML: "<p><strong>1</strong></p>"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			logger, _ := zap.NewProduction()
+			r := NewRouter(&tt.noteService, &auditLogMock{}, logger.Named(""))
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			req, _ := http.NewRequestWithContext(c, http.MethodGet, "/note/123-123?render="+tt.render, nil)
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			emptyResponse := NoteResponse{}
+			if tt.expectedNote != emptyResponse {
+				var response NoteResponse
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedNote, response)
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
+
+func TestChecklistItems(t *testing.T) {
+	checklist := note.Note{
+		ID:   "123-123",
+		Kind: note.KindChecklist,
+		Items: []note.ChecklistItem{
+			{ID: "1", Text: "milk", Checked: true},
+			{ID: "2", Text: "bread", Position: 1},
+		},
+	}
+	tests := []struct {
+		name          string
+		noteService   noteServiceMock
+		method        string
+		path          string
+		body          any
+		expectedCode  int
+		expectedError *app.ErrorModel
+		expectedNote  NoteResponse
+	}{
+		{
+			name:   "should add item",
+			method: http.MethodPost,
+			path:   "/note/123-123/items",
+			body:   AddItemRequest{Text: "bread"},
+			noteService: noteServiceMock{
+				AddItemFunc: func(noteID, userID, text string) (note.Note, error) {
+					return checklist, nil
+				},
+			},
+			expectedCode: http.StatusCreated,
+			expectedNote: NoteToNoteResponse(checklist),
+		},
+		{
+			name:         "should return ErrTextEmpty",
+			method:       http.MethodPost,
+			path:         "/note/123-123/items",
+			body:         AddItemRequest{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return ErrNotChecklist",
+			method: http.MethodPost,
+			path:   "/note/123-123/items",
+			body:   AddItemRequest{Text: "bread"},
+			noteService: noteServiceMock{
+				AddItemFunc: func(noteID, userID, text string) (note.Note, error) {
+					return note.Note{}, note.ErrNotChecklist
+				},
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: note.ErrNotChecklist.Error()},
+		},
+		{
+			name:   "should toggle item",
+			method: http.MethodPost,
+			path:   "/note/123-123/items/1/toggle",
+			noteService: noteServiceMock{
+				ToggleItemFunc: func(noteID, itemID, userID string) (note.Note, error) {
+					return checklist, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedNote: NoteToNoteResponse(checklist),
+		},
+		{
+			name:   "should return ErrNoAccess",
+			method: http.MethodPost,
+			path:   "/note/123-123/items/1/toggle",
+			noteService: noteServiceMock{
+				ToggleItemFunc: func(noteID, itemID, userID string) (note.Note, error) {
+					return note.Note{}, app.ErrNoAccess
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: app.ErrNoAccess.Error()},
+		},
+		{
+			name:   "should reorder items",
+			method: http.MethodPut,
+			path:   "/note/123-123/items/order",
+			body:   ReorderItemsRequest{ItemIDs: []string{"1", "2"}},
+			noteService: noteServiceMock{
+				ReorderItemsFunc: func(noteID, userID string, itemIDs []string) (note.Note, error) {
+					return checklist, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedNote: NoteToNoteResponse(checklist),
+		},
+		{
+			name:   "should return ErrItemOrder",
+			method: http.MethodPut,
+			path:   "/note/123-123/items/order",
+			body:   ReorderItemsRequest{ItemIDs: []string{"1"}},
+			noteService: noteServiceMock{
+				ReorderItemsFunc: func(noteID, userID string, itemIDs []string) (note.Note, error) {
+					return note.Note{}, note.ErrItemOrder
+				},
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: note.ErrItemOrder.Error()},
+		},
+		{
+			name:   "should return ErrItemNotFound",
+			method: http.MethodDelete,
+			path:   "/note/123-123/items/3",
+			noteService: noteServiceMock{
+				DeleteItemFunc: func(noteID, itemID, userID string) (note.Note, error) {
+					return note.Note{}, note.ErrItemNotFound
+				},
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: &app.ErrorModel{Error: note.ErrItemNotFound.Error()},
+		},
+		{
+			name:   "should return unknownError",
+			method: http.MethodDelete,
+			path:   "/note/123-123/items/1",
+			noteService: noteServiceMock{
+				DeleteItemFunc: func(noteID, itemID, userID string) (note.Note, error) {
+					return note.Note{}, errors.New("something wrong")
+				},
+			},
+			expectedCode:  http.StatusInternalServerError,
+			expectedError: &app.UnknownError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.noteService, &auditLogMock{}, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body, _ := json.Marshal(tt.body)
+			req, _ := http.NewRequestWithContext(c, tt.method, tt.path, bytes.NewReader(body))
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			emptyResponse := NoteResponse{}
+			if tt.expectedNote != emptyResponse {
+				var response NoteResponse
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedNote, response)
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
+
+func TestGetLinks(t *testing.T) {
+	target := note.Note{ID: "321-321", Subject: "Plan"}
+	tests := []struct {
+		name          string
+		noteService   noteServiceMock
+		path          string
+		expectedCode  int
+		expectedError *app.ErrorModel
+		expectedBody  any
+	}{
+		{
+			name: "should return ErrNoAccess",
+			path: "/note/123-123/links",
+			noteService: noteServiceMock{
+				GetLinksFunc: func(id, userID string) ([]note.Link, error) {
+					return nil, app.ErrNoAccess
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: app.ErrNoAccess.Error()},
+		},
+		{
+			name: "should return links",
+			path: "/note/123-123/links",
+			noteService: noteServiceMock{
+				GetLinksFunc: func(id, userID string) ([]note.Link, error) {
+					return []note.Link{{Target: "Plan", Note: target}, {Target: "Gone", Broken: true}}, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: []LinkResponse{
+				{Target: "Plan", Note: func() *NoteResponse { n := NoteToNoteResponse(target); return &n }()},
+				{Target: "Gone", Broken: true},
+			},
+		},
+		{
+			name: "should return errNoteNotFound",
+			path: "/note/123-123/backlinks",
+			noteService: noteServiceMock{
+				GetBacklinksFunc: func(id, userID string) ([]note.Note, error) {
+					return nil, note.ErrNoteNotFound
+				},
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: &app.ErrorModel{Error: note.ErrNoteNotFound.Error()},
+		},
+		{
+			name: "should return backlinks",
+			path: "/note/123-123/backlinks",
+			noteService: noteServiceMock{
+				GetBacklinksFunc: func(id, userID string) ([]note.Note, error) {
+					return []note.Note{target}, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: []NoteResponse{NoteToNoteResponse(target)},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.noteService, &auditLogMock{}, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			req, _ := http.NewRequestWithContext(c, http.MethodGet, tt.path, nil)
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedBody != nil {
+				expected, err := json.Marshal(tt.expectedBody)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), w.Body.String())
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
+
+func TestSetMarks(t *testing.T) {
+	yes := true
+	pinned := note.Note{ID: "123-123", Marks: note.Marks{Pinned: true}}
+	tests := []struct {
+		name          string
+		noteService   noteServiceMock
+		method        string
+		path          string
+		body          any
+		expectedCode  int
+		expectedError *app.ErrorModel
+		expectedBody  any
+	}{
+		{
+			name:         "should return ErrMarksEmpty",
+			method:       http.MethodPut,
+			path:         "/note/123-123/marks",
+			body:         MarksRequest{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should pin note",
+			method: http.MethodPut,
+			path:   "/note/123-123/marks",
+			body:   MarksRequest{Pinned: &yes},
+			noteService: noteServiceMock{
+				SetMarksFunc: func(noteIDs []string, userID string, update note.MarksUpdate) ([]note.Note, error) {
+					if len(noteIDs) != 1 || noteIDs[0] != "123-123" || update.Pinned == nil || update.Starred != nil {
+						return nil, errors.New("unexpected marks")
+					}
+					return []note.Note{pinned}, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: NoteToNoteResponse(pinned),
+		},
+		{
+			name:         "should return ErrNoteIDs",
+			method:       http.MethodPost,
+			path:         "/notes/marks",
+			body:         BulkMarksRequest{MarksRequest: MarksRequest{Archived: &yes}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should return ErrNoAccess",
+			method: http.MethodPost,
+			path:   "/notes/marks",
+			body:   BulkMarksRequest{NoteIDs: []string{"1", "2"}, MarksRequest: MarksRequest{Archived: &yes}},
+			noteService: noteServiceMock{
+				SetMarksFunc: func(noteIDs []string, userID string, update note.MarksUpdate) ([]note.Note, error) {
+					return nil, app.ErrNoAccess
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: app.ErrNoAccess.Error()},
+		},
+		{
+			name:   "should return notes with archived",
+			method: http.MethodGet,
+			path:   "/notes?archived=true&shared=true",
+			body:   "subject",
+			noteService: noteServiceMock{
+				GetNotesFunc: func(userID string, opts note.ListOptions) ([]note.Note, error) {
+					if opts != (note.ListOptions{Sort: "subject", Archived: true, Shared: true}) {
+						return nil, errors.New("unexpected options")
+					}
+					return []note.Note{pinned}, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: []NoteResponse{NoteToNoteResponse(pinned)},
+		},
+		{
+			name:          "should return ErrListFlag",
+			method:        http.MethodGet,
+			path:          "/notes?archived=maybe",
+			body:          "",
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: ErrListFlag.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.noteService, &auditLogMock{}, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body, _ := json.Marshal(tt.body)
+			req, _ := http.NewRequestWithContext(c, tt.method, tt.path, bytes.NewReader(body))
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedBody != nil {
+				expected, err := json.Marshal(tt.expectedBody)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), w.Body.String())
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
+
+func TestPostBatch(t *testing.T) {
+	created := note.Note{ID: "1", UserID: "123-123", Text: "new"}
+	createdResponse := NoteToNoteResponse(created)
+	tests := []struct {
+		name         string
+		noteService  noteServiceMock
+		request      BatchRequest
+		expectedCode int
+		expectedBody any
+	}{
+		{
+			name:         "should return ErrOperations",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ValidationErrors{Errors: map[string]string{"operations": ErrOperations.Error()}},
+		},
+		{
+			name: "should return result of every operation",
+			request: BatchRequest{Operations: []BatchOperation{
+				{Op: note.OpCreate, Note: &PostRequest{Text: "new"}},
+				{Op: note.OpUpdate, ID: "2", Note: &PostRequest{}},
+				{Op: note.OpDelete, ID: "3"},
+				{Op: note.OpShare, ID: "4", IsPublic: true},
+			}},
+			noteService: noteServiceMock{
+				BatchFunc: func(userID string, ops []note.BatchOp, atomic bool) []note.BatchResult {
+					if atomic || len(ops) != 3 || ops[0].Note.UserID != userID || ops[2].Type != note.OpShare || !ops[2].Note.IsPublic {
+						return nil
+					}
+					return []note.BatchResult{
+						{Note: created},
+						{Err: app.ErrNoAccess},
+						{Err: errors.New("something wrong")},
+					}
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: BatchResponse{Results: []BatchResult{
+				{Status: http.StatusCreated, Note: &createdResponse},
+				{Status: http.StatusBadRequest, Errors: map[string]string{"text": ErrTextEmpty.Error()}},
+				{Status: http.StatusForbidden, Error: app.ErrNoAccess.Error()},
+				{Status: http.StatusInternalServerError, Error: app.UnknownError.Error},
+			}},
+		},
+		{
+			name: "should not call service when atomic batch has invalid operation",
+			request: BatchRequest{Atomic: true, Operations: []BatchOperation{
+				{Op: note.OpCreate, Note: &PostRequest{Text: "new"}},
+				{Op: note.OpDelete},
+			}},
+			expectedCode: http.StatusOK,
+			expectedBody: BatchResponse{Results: []BatchResult{
+				{Status: http.StatusFailedDependency, Error: note.ErrBatchAborted.Error()},
+				{Status: http.StatusBadRequest, Errors: map[string]string{"id": ErrIDEmpty.Error()}},
+			}},
+		},
+		{
+			name: "should apply atomic batch",
+			request: BatchRequest{Atomic: true, Operations: []BatchOperation{
+				{Op: note.OpCreate, Note: &PostRequest{Text: "new"}},
+			}},
+			noteService: noteServiceMock{
+				BatchFunc: func(userID string, ops []note.BatchOp, atomic bool) []note.BatchResult {
+					return []note.BatchResult{{Note: created}}
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: BatchResponse{Applied: true, Results: []BatchResult{
+				{Status: http.StatusCreated, Note: &createdResponse},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.noteService, &auditLogMock{}, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body, _ := json.Marshal(tt.request)
+			req, _ := http.NewRequestWithContext(c, http.MethodPost, "/notes/batch", bytes.NewReader(body))
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			expected, err := json.Marshal(tt.expectedBody)
+			assert.NoError(t, err)
+			assert.JSONEq(t, string(expected), w.Body.String())
+		})
+	}
+}
+
+func TestNoteAudit(t *testing.T) {
+	users := []string{"b", "a"}
+	shared := note.Note{ID: "123-123", UserID: "123-123", IsPublic: true, PublicUsers: &users}
+	tests := []struct {
+		name           string
+		noteService    noteServiceMock
+		method         string
+		url            string
+		body           interface{}
+		expectedAudit  []string
+		expectedDetail map[string]string
+	}{
+		{
+			name:   "should record update without share",
+			method: http.MethodPut,
+			url:    "/note/123-123",
+			body:   UpdateRequest{ID: "123-123", Text: "123", IsPublic: true, PublicUsers: &[]string{"a", "b"}},
+			noteService: noteServiceMock{
+				FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+					return shared, nil
+				},
+				UpdateNoteFunc: func(n note.Note) (note.Note, error) {
+					return n, nil
+				},
+			},
+			expectedAudit: []string{audit.ActionNoteUpdate},
+		},
+		{
+			name:   "should record share change",
+			method: http.MethodPut,
+			url:    "/note/123-123",
+			body:   UpdateRequest{ID: "123-123", Text: "123"},
+			noteService: noteServiceMock{
+				FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+					return shared, nil
+				},
+				UpdateNoteFunc: func(n note.Note) (note.Note, error) {
+					return n, nil
+				},
+			},
+			expectedAudit:  []string{audit.ActionNoteUpdate, audit.ActionNoteShare},
+			expectedDetail: map[string]string{"public": "false", "users": ""},
+		},
+		{
+			name:   "should not record failed delete",
+			method: http.MethodDelete,
+			url:    "/note/123-123",
+			noteService: noteServiceMock{
+				DeleteNoteFunc: func(id, userID string) error {
+					return app.ErrNoAccess
+				},
+			},
+		},
+		{
+			name:   "should record applied batch operations",
+			method: http.MethodPost,
+			url:    "/notes/batch",
+			body: BatchRequest{Operations: []BatchOperation{
+				{Op: note.OpDelete, ID: "1"},
+				{Op: note.OpDelete, ID: "2"},
+			}},
+			noteService: noteServiceMock{
+				BatchFunc: func(userID string, ops []note.BatchOp, atomic bool) []note.BatchResult {
+					return []note.BatchResult{{Note: note.Note{ID: "1", UserID: userID}}, {Err: note.ErrNoteNotFound}}
+				},
+			},
+			expectedAudit: []string{audit.ActionNoteDelete},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			auditLog := &auditLogMock{}
+			r := NewRouter(&tt.noteService, auditLog, zap.NewNop())
+			r.SetUpRouter(g)
+
+			jsonValue, _ := json.Marshal(tt.body)
+			req, _ := http.NewRequest(tt.method, tt.url, bytes.NewBuffer(jsonValue))
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, req)
+
+			actions := make([]string, 0)
+			for _, e := range auditLog.events {
+				actions = append(actions, e.Action)
+				assert.Equal(t, "123-123", e.ActorID)
+				assert.Equal(t, "123-123", e.OwnerID)
+				assert.Equal(t, audit.TargetNote, e.TargetType)
+			}
+			assert.ElementsMatch(t, tt.expectedAudit, actions)
+			if tt.expectedDetail != nil {
+				assert.Equal(t, tt.expectedDetail, auditLog.events[len(auditLog.events)-1].Details)
+			}
+		})
+	}
+}
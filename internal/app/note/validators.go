@@ -3,22 +3,41 @@ package note
 import (
 	"errors"
 	"note-service/internal/app"
+	notepkg "note-service/internal/pkg/note"
 )
 
 var (
 	ErrTextEmpty   = errors.New("empty text")
 	ErrIDEmpty     = errors.New("empty id")
 	ErrUserIDEmpty = errors.New("empty userID")
+	ErrPublishAt   = errors.New("publishAt must be before ttl")
+	ErrContentType = errors.New("contentType must be plain or markdown")
+	ErrRenderMode  = errors.New("render must be html")
+	ErrKind        = errors.New("kind must be text or checklist")
+	ErrItems       = errors.New("items are allowed only in checklist with non-empty text")
+	ErrItemIDs     = errors.New("empty itemIds")
+	ErrMarksEmpty  = errors.New("set at least one of pinned, starred, archived")
+	ErrNoteIDs     = errors.New("empty noteIds")
+	ErrListFlag    = errors.New("archived and shared must be true or false")
+	ErrOperations  = errors.New("empty operations")
+	ErrNoteEmpty   = errors.New("empty note")
 )
 
 func (r PostRequest) Validate() error {
 	ve := app.NewValidationErrors()
-	if len(r.Text) == 0 {
+	if len(r.Text) == 0 && r.Kind != notepkg.KindChecklist {
 		ve.Errors["text"] = ErrTextEmpty.Error()
 	}
+	validateKind(ve, r.Kind, r.Items)
 	if len(r.UserID) == 0 {
 		ve.Errors["userid"] = ErrUserIDEmpty.Error()
 	}
+	if !validVisibilityWindow(r.PublishAt, r.TTL) {
+		ve.Errors["publishAt"] = ErrPublishAt.Error()
+	}
+	if !validContentType(r.ContentType) {
+		ve.Errors["contentType"] = ErrContentType.Error()
+	}
 	if len(ve.Errors) == 0 {
 		return nil
 	}
@@ -27,17 +46,134 @@ func (r PostRequest) Validate() error {
 
 func (r UpdateRequest) Validate() error {
 	ve := app.NewValidationErrors()
-	if len(r.Text) == 0 {
+	if len(r.Text) == 0 && r.Kind != notepkg.KindChecklist {
 		ve.Errors["text"] = ErrTextEmpty.Error()
 	}
+	validateKind(ve, r.Kind, r.Items)
 	if len(r.UserID) == 0 {
 		ve.Errors["userid"] = ErrUserIDEmpty.Error()
 	}
 	if len(r.ID) == 0 {
 		ve.Errors["id"] = ErrIDEmpty.Error()
 	}
+	if !validVisibilityWindow(r.PublishAt, r.TTL) {
+		ve.Errors["publishAt"] = ErrPublishAt.Error()
+	}
+	if !validContentType(r.ContentType) {
+		ve.Errors["contentType"] = ErrContentType.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+// validVisibilityWindow checks that a note isn't deleted before it is published
+func validVisibilityWindow(publishAt, ttl *int64) bool {
+	return publishAt == nil || ttl == nil || *publishAt < *ttl
+}
+
+func validContentType(contentType string) bool {
+	return contentType == "" || contentType == notepkg.ContentTypePlain || contentType == notepkg.ContentTypeMarkdown
+}
+
+func validateKind(ve app.ValidationErrors, kind string, items *[]ItemRequest) {
+	if kind != "" && kind != notepkg.KindText && kind != notepkg.KindChecklist {
+		ve.Errors["kind"] = ErrKind.Error()
+	}
+	if items == nil {
+		return
+	}
+	if kind != notepkg.KindChecklist {
+		ve.Errors["items"] = ErrItems.Error()
+		return
+	}
+	for _, item := range *items {
+		if len(item.Text) == 0 {
+			ve.Errors["items"] = ErrItems.Error()
+		}
+	}
+}
+
+func (r AddItemRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Text) == 0 {
+		ve.Errors["text"] = ErrTextEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r ReorderItemsRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.ItemIDs) == 0 {
+		ve.Errors["itemIds"] = ErrItemIDs.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r MarksRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if r.Pinned == nil && r.Starred == nil && r.Archived == nil {
+		ve.Errors["marks"] = ErrMarksEmpty.Error()
+	}
 	if len(ve.Errors) == 0 {
 		return nil
 	}
 	return ve
 }
+
+func (r BulkMarksRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.NoteIDs) == 0 {
+		ve.Errors["noteIds"] = ErrNoteIDs.Error()
+	}
+	if r.Pinned == nil && r.Starred == nil && r.Archived == nil {
+		ve.Errors["marks"] = ErrMarksEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r BatchRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Operations) == 0 {
+		ve.Errors["operations"] = ErrOperations.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+// Validate checks the operation the same way its single-note route checks the request
+func (r BatchOperation) Validate(userID string) error {
+	switch r.Op {
+	case notepkg.OpCreate, notepkg.OpUpdate:
+		if r.Note == nil {
+			ve := app.NewValidationErrors()
+			ve.Errors["note"] = ErrNoteEmpty.Error()
+			return ve
+		}
+		if r.Op == notepkg.OpCreate {
+			request := *r.Note
+			request.UserID = userID
+			return request.Validate()
+		}
+		return batchOperationToUpdateRequest(r, userID).Validate()
+	case notepkg.OpDelete, notepkg.OpShare:
+		if r.ID == "" {
+			ve := app.NewValidationErrors()
+			ve.Errors["id"] = ErrIDEmpty.Error()
+			return ve
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package oidc
+
+import (
+	oidcpkg "note-service/internal/pkg/oidc"
+	userpkg "note-service/internal/pkg/user"
+)
+
+func claimsToIdentity(provider string, claims oidcpkg.Claims) userpkg.ExternalIdentity {
+	return userpkg.ExternalIdentity{Provider: provider, Subject: claims.Subject, Email: claims.Email}
+}
+
+func claimsToProfile(claims oidcpkg.Claims) userpkg.ExternalProfile {
+	return userpkg.ExternalProfile{
+		Username:      claims.PreferredUsername,
+		DisplayName:   claims.Name,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}
+}
+
+func identityToIdentityResponse(identity userpkg.ExternalIdentity) IdentityResponse {
+	return IdentityResponse{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		LinkedAt: identity.LinkedAt,
+	}
+}
+
+func identitiesToIdentitiesResponse(identities []userpkg.ExternalIdentity) IdentitiesResponse {
+	res := IdentitiesResponse{Identities: make([]IdentityResponse, len(identities))}
+	for i, identity := range identities {
+		res.Identities[i] = identityToIdentityResponse(identity)
+	}
+	return res
+}
@@ -0,0 +1,36 @@
+package oidc
+
+import "time"
+
+type ProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+// AuthURLResponse is where the frontend sends the user, it keeps the state of the URL to check it on return
+type AuthURLResponse struct {
+	URL string `json:"url"`
+}
+
+// CallbackRequest carries code and state from the redirect of the provider, scopes limit the token like in login
+type CallbackRequest struct {
+	Code   string   `json:"code"`
+	State  string   `json:"state"`
+	Scopes []string `json:"scopes"`
+}
+
+// MFAChallengeResponse is returned instead of a token when 2FA is enabled, like in login
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	Challenge   string `json:"challenge"`
+}
+
+type IdentityResponse struct {
+	Provider string    `json:"provider"`
+	Subject  string    `json:"subject"`
+	Email    string    `json:"email"`
+	LinkedAt time.Time `json:"linkedAt"`
+}
+
+type IdentitiesResponse struct {
+	Identities []IdentityResponse `json:"identities"`
+}
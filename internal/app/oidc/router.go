@@ -0,0 +1,181 @@
+package oidc
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"note-service/internal/app"
+	"note-service/internal/pkg/audit"
+	"note-service/internal/pkg/jwt"
+	oidcpkg "note-service/internal/pkg/oidc"
+	"note-service/internal/pkg/scope"
+	userpkg "note-service/internal/pkg/user"
+)
+
+type oidcService interface {
+	Providers() []string
+	Start(provider, userID string) (string, error)
+	Finish(provider, code, state string) (oidcpkg.Flow, oidcpkg.Claims, error)
+}
+
+type ssoService interface {
+	Login(identity userpkg.ExternalIdentity, profile userpkg.ExternalProfile) (userpkg.User, error)
+	Link(userID string, identity userpkg.ExternalIdentity) (userpkg.ExternalIdentity, error)
+	GetIdentities(userID string) ([]userpkg.ExternalIdentity, error)
+}
+
+type mfaService interface {
+	Challenge(u userpkg.User) string
+}
+
+type auditLog interface {
+	Record(e audit.Event)
+}
+
+// Router signs in users with OpenID Connect providers, the result is the same as of POST /user/login
+type Router struct {
+	oidc   oidcService
+	sso    ssoService
+	mfa    mfaService
+	audit  auditLog
+	logger *zap.Logger
+}
+
+func NewRouter(oidc oidcService, sso ssoService, mfa mfaService, auditLog auditLog, logger *zap.Logger) *Router {
+	return &Router{oidc: oidc, sso: sso, mfa: mfa, audit: auditLog, logger: logger}
+}
+
+func (r *Router) SetUpRouter(engine *gin.Engine) {
+	engine.GET("/user/oidc", r.getProviders)
+	engine.POST("/user/oidc/:provider", r.startLogin)
+	engine.POST("/user/oidc/:provider/callback", r.callback)
+	engine.POST("/user/me/oidc/:provider", app.AuthMiddleware(), r.startLink)
+	engine.GET("/user/me/identities", app.AuthMiddleware(), r.getIdentities)
+}
+
+func (r *Router) getProviders(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, ProvidersResponse{Providers: r.oidc.Providers()})
+}
+
+func (r *Router) startLogin(c *gin.Context) {
+	authURL, err := r.oidc.Start(c.Param("provider"), "")
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, AuthURLResponse{URL: authURL})
+}
+
+// startLink begins a flow which links the identity to the signed in user instead of logging in
+func (r *Router) startLink(c *gin.Context) {
+	authURL, err := r.oidc.Start(c.Param("provider"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, AuthURLResponse{URL: authURL})
+}
+
+// callback finishes a login with a token or a 2FA challenge, and a link with the linked identity
+func (r *Router) callback(c *gin.Context) {
+	var request CallbackRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	provider := c.Param("provider")
+	flow, claims, err := r.oidc.Finish(provider, request.Code, request.State)
+	if err != nil {
+		r.recordFailure(c, provider, err)
+		r.handleError(c, err)
+		return
+	}
+	if flow.UserID != "" {
+		r.link(c, flow, claims)
+		return
+	}
+
+	u, err := r.sso.Login(claimsToIdentity(provider, claims), claimsToProfile(claims))
+	if err != nil {
+		r.recordFailure(c, provider, err)
+		r.handleError(c, err)
+		return
+	}
+	if u.TOTPEnabled {
+		r.logger.Info("user passed the identity provider step")
+		c.IndentedJSON(http.StatusOK, MFAChallengeResponse{MFARequired: true, Challenge: r.mfa.Challenge(u)})
+		return
+	}
+	token, err := jwt.CreateToken(u.ID, request.Scopes...)
+	if err != nil {
+		r.logger.Error("failed to create jwt-token", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+		return
+	}
+	e := app.AuditEvent(c, audit.ActionLogin).On(audit.TargetUser, u.ID, u.ID).With("provider", provider)
+	e.ActorID = u.ID
+	r.audit.Record(e)
+	r.logger.Info("user was authorized", zap.String("provider", provider))
+	c.IndentedJSON(http.StatusOK, app.TokenModel{Token: token})
+}
+
+// link needs the full access session of the user who started the flow, so nobody can link
+// their identity to another user by making them finish the flow
+func (r *Router) link(c *gin.Context, flow oidcpkg.Flow, claims oidcpkg.Claims) {
+	identity, err := app.ContextIdentity(c)
+	if err != nil || identity.UserID != flow.UserID || identity.KeyID != "" || !scope.Allows(identity.Scopes, scope.Full) {
+		c.IndentedJSON(http.StatusForbidden, app.ErrorModel{Error: app.ErrNoAccess.Error()})
+		return
+	}
+	linked, err := r.sso.Link(flow.UserID, claimsToIdentity(flow.Provider, claims))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.Set("userId", flow.UserID)
+	r.audit.Record(app.AuditEvent(c, audit.ActionIdentityLink).
+		On(audit.TargetUser, flow.UserID, flow.UserID).
+		With("provider", linked.Provider).
+		With("subject", linked.Subject))
+	r.logger.Info("identity was linked", zap.String("provider", linked.Provider))
+	c.IndentedJSON(http.StatusOK, identityToIdentityResponse(linked))
+}
+
+func (r *Router) getIdentities(c *gin.Context) {
+	identities, err := r.sso.GetIdentities(c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, identitiesToIdentitiesResponse(identities))
+}
+
+func (r *Router) recordFailure(c *gin.Context, provider string, err error) {
+	r.audit.Record(app.AuditEvent(c, audit.ActionLoginFailed).
+		With("provider", provider).
+		With("reason", err.Error()))
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, oidcpkg.ErrUnknownProvider):
+		c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, oidcpkg.ErrInvalidState), errors.Is(err, oidcpkg.ErrExchange):
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, oidcpkg.ErrIDToken), errors.Is(err, userpkg.ErrUserDisabled):
+		c.IndentedJSON(http.StatusForbidden, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, userpkg.ErrIdentityLinked):
+		c.IndentedJSON(http.StatusConflict, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, oidcpkg.ErrDiscovery):
+		r.logger.Error("identity provider is unavailable", zap.Error(err))
+		c.IndentedJSON(http.StatusBadGateway, app.ErrorModel{Error: oidcpkg.ErrDiscovery.Error()})
+	default:
+		r.logger.Error("failed to handle oidc", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+	}
+}
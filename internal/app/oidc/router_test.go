@@ -0,0 +1,202 @@
+package oidc
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/app"
+	"note-service/internal/pkg/audit"
+	"note-service/internal/pkg/jwt"
+	oidcpkg "note-service/internal/pkg/oidc"
+	"note-service/internal/pkg/oidc/oidctest"
+	"note-service/internal/pkg/scope"
+	userpkg "note-service/internal/pkg/user"
+	"testing"
+	"time"
+)
+
+type mfaServiceMock struct{}
+
+func (mfaServiceMock) Challenge(u userpkg.User) string {
+	return "challenge-" + u.ID
+}
+
+type auditLogMock struct {
+	events []audit.Event
+}
+
+func (m *auditLogMock) Record(e audit.Event) {
+	m.events = append(m.events, e)
+}
+
+// testEnv runs the router against the mock identity provider with real user and flow stores
+type testEnv struct {
+	engine *gin.Engine
+	idp    *oidctest.Server
+	users  *userpkg.InMemoryStore
+	audit  *auditLogMock
+}
+
+func newTestEnv(t *testing.T) testEnv {
+	idp := oidctest.NewServer("note-service", "secret")
+	t.Cleanup(idp.Close)
+	provider := oidcpkg.NewProvider(oidcpkg.Config{
+		Name:         "corp",
+		Issuer:       idp.Issuer(),
+		ClientID:     "note-service",
+		ClientSecret: "secret",
+		RedirectURL:  "https://notes.example.com/oidc/corp/callback",
+	}, &http.Client{Timeout: 5 * time.Second})
+	users := userpkg.NewInMemoryStore()
+	auditLog := &auditLogMock{}
+	g := gin.Default()
+	NewRouter(oidcpkg.NewService(oidcpkg.NewInMemoryStore(), provider), userpkg.NewSSO(users), mfaServiceMock{}, auditLog, zap.NewNop()).
+		SetUpRouter(g)
+	return testEnv{engine: g, idp: idp, users: users, audit: auditLog}
+}
+
+func (env testEnv) do(t *testing.T, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req, _ := http.NewRequest(method, path, bytes.NewReader(payload))
+	if token != "" {
+		req.Header.Set(app.AccessHeader, token)
+	}
+	w := httptest.NewRecorder()
+	env.engine.ServeHTTP(w, req)
+	return w
+}
+
+// signIn starts the flow at path, signs in at the provider and returns code and state for the callback
+func (env testEnv) signIn(t *testing.T, path, token string) CallbackRequest {
+	w := env.do(t, http.MethodPost, path, token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var start AuthURLResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &start))
+	code, state, err := env.idp.Authorize(start.URL)
+	require.NoError(t, err)
+	return CallbackRequest{Code: code, State: state}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	env := newTestEnv(t)
+	env.idp.SetUser(oidctest.User{Subject: "42", PreferredUsername: "alice", Email: "alice@example.com", EmailVerified: true})
+
+	w := env.do(t, http.MethodGet, "/user/oidc", "", nil)
+	assert.JSONEq(t, `{"providers": ["corp"]}`, w.Body.String())
+
+	var userID string
+	t.Run("should create user and return token", func(t *testing.T) {
+		request := env.signIn(t, "/user/oidc/corp", "")
+		request.Scopes = []string{scope.NotesRead}
+		w := env.do(t, http.MethodPost, "/user/oidc/corp/callback", "", request)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var token app.TokenModel
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &token))
+		claims, err := jwt.ParseClaims(token.Token)
+		require.NoError(t, err)
+		assert.Equal(t, []string{scope.NotesRead}, claims.Scopes)
+		userID = claims.UserID
+
+		u, err := env.users.FindUserByID(userID)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", u.Username)
+		assert.Equal(t, "alice@example.com", u.Email)
+		if assert.NotEmpty(t, env.audit.events) {
+			e := env.audit.events[len(env.audit.events)-1]
+			assert.Equal(t, audit.ActionLogin, e.Action)
+			assert.Equal(t, userID, e.ActorID)
+			assert.Equal(t, "corp", e.Details["provider"])
+		}
+
+		w = env.do(t, http.MethodPost, "/user/oidc/corp/callback", "", request)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "state is used once")
+	})
+
+	t.Run("should sign in the same user again", func(t *testing.T) {
+		w := env.do(t, http.MethodPost, "/user/oidc/corp/callback", "", env.signIn(t, "/user/oidc/corp", ""))
+		require.Equal(t, http.StatusOK, w.Code)
+		var token app.TokenModel
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &token))
+		id, err := jwt.ParseToken(token.Token)
+		require.NoError(t, err)
+		assert.Equal(t, userID, id)
+	})
+
+	t.Run("should ask for 2FA code", func(t *testing.T) {
+		setTOTP := func(enabled bool) func(u *userpkg.User) error {
+			return func(u *userpkg.User) error {
+				u.TOTPEnabled = enabled
+				return nil
+			}
+		}
+		_, _ = env.users.UpdateUser(userID, setTOTP(true))
+		defer env.users.UpdateUser(userID, setTOTP(false))
+
+		w := env.do(t, http.MethodPost, "/user/oidc/corp/callback", "", env.signIn(t, "/user/oidc/corp", ""))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"mfaRequired": true, "challenge": "challenge-`+userID+`"}`, w.Body.String())
+	})
+
+	t.Run("should reject unknown provider", func(t *testing.T) {
+		w := env.do(t, http.MethodPost, "/user/oidc/other", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestOIDCLink(t *testing.T) {
+	env := newTestEnv(t)
+	env.idp.SetUser(oidctest.User{Subject: "7", PreferredUsername: "bob"})
+	u, err := env.users.CreateUser("username1", "hash")
+	require.NoError(t, err)
+	other, err := env.users.CreateUser("username2", "hash")
+	require.NoError(t, err)
+	session, _ := jwt.CreateToken(u.ID)
+	otherSession, _ := jwt.CreateToken(other.ID)
+	scoped, _ := jwt.CreateToken(u.ID, scope.NotesWrite)
+
+	t.Run("should need the session of the user who started", func(t *testing.T) {
+		request := env.signIn(t, "/user/me/oidc/corp", session)
+		w := env.do(t, http.MethodPost, "/user/oidc/corp/callback", otherSession, request)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		request = env.signIn(t, "/user/me/oidc/corp", session)
+		w = env.do(t, http.MethodPost, "/user/oidc/corp/callback", scoped, request)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("should link identity", func(t *testing.T) {
+		request := env.signIn(t, "/user/me/oidc/corp", session)
+		w := env.do(t, http.MethodPost, "/user/oidc/corp/callback", session, request)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = env.do(t, http.MethodGet, "/user/me/identities", session, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var identities IdentitiesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &identities))
+		if assert.Len(t, identities.Identities, 1) {
+			assert.Equal(t, "7", identities.Identities[0].Subject)
+		}
+
+		w = env.do(t, http.MethodPost, "/user/oidc/corp/callback", "", env.signIn(t, "/user/oidc/corp", ""))
+		require.Equal(t, http.StatusOK, w.Code)
+		var token app.TokenModel
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &token))
+		id, err := jwt.ParseToken(token.Token)
+		require.NoError(t, err)
+		assert.Equal(t, u.ID, id, "login goes to the linked user")
+	})
+
+	t.Run("should not link identity of another user", func(t *testing.T) {
+		request := env.signIn(t, "/user/me/oidc/corp", otherSession)
+		w := env.do(t, http.MethodPost, "/user/oidc/corp/callback", otherSession, request)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
@@ -0,0 +1,33 @@
+package oidc
+
+import (
+	"errors"
+	"note-service/internal/app"
+	"note-service/internal/pkg/scope"
+	"strings"
+)
+
+var (
+	ErrCodeEmpty  = errors.New("empty code")
+	ErrStateEmpty = errors.New("empty state")
+	ErrScopes     = errors.New("scopes must be of " + strings.Join(scope.Names, ", "))
+)
+
+func (r CallbackRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if r.Code == "" {
+		ve.Errors["code"] = ErrCodeEmpty.Error()
+	}
+	if r.State == "" {
+		ve.Errors["state"] = ErrStateEmpty.Error()
+	}
+	for _, name := range r.Scopes {
+		if !scope.Valid(name) {
+			ve.Errors["scopes"] = ErrScopes.Error()
+		}
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"note-service/internal/pkg/ratelimit"
+	"strconv"
+	"time"
+)
+
+var ErrRateLimited = errors.New("too many requests, try again later")
+
+// RateLimiter keeps token buckets, a backend shared by several instances implements it to limit them together
+type RateLimiter interface {
+	Take(key string, limit ratelimit.Limit) ratelimit.Result
+}
+
+// RateLimitMiddleware limits requests by the rule of the matched route. Requests with a valid token
+// or API key are counted per user, the rest per client IP. Routes without a rule are not limited.
+func RateLimitMiddleware(limiter RateLimiter, rules ratelimit.Rules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, limit, ok := rules.Find(c.Request.Method, c.FullPath())
+		if !ok {
+			return
+		}
+		client := "ip:" + c.ClientIP()
+		if identity, err := ContextIdentity(c); err == nil {
+			client = "user:" + identity.UserID
+		}
+
+		result := limiter.Take(name+" "+client, limit)
+		c.Header("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+seconds(limit.Period))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", seconds(result.Reset))
+		if !result.Allowed {
+			c.Header("Retry-After", seconds(result.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorModel{Error: ErrRateLimited.Error()})
+		}
+	}
+}
+
+// seconds rounds up, so a client waiting that long is not rejected again
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	g := gin.New()
+	g.Use(RateLimitMiddleware(ratelimit.NewInMemoryStore(), ratelimit.Rules{
+		"POST /note":           {Requests: 2, Period: time.Minute},
+		ratelimit.DefaultRoute: {Requests: 3, Period: time.Minute},
+	}))
+	g.POST("/note", AuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusCreated) })
+	g.POST("/user", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	token, _ := jwt.CreateToken("123-123")
+	otherToken, _ := jwt.CreateToken("321-321")
+
+	send := func(path, token, ip string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, path, nil)
+		if token != "" {
+			req.Header.Set(AccessHeader, token)
+		}
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name              string
+		path              string
+		token             string
+		ip                string
+		expectedCode      int
+		expectedRemaining string
+		expectedRetry     string
+	}{
+		{
+			name:              "should allow first request of user",
+			path:              "/note",
+			token:             token,
+			ip:                "10.0.0.1",
+			expectedCode:      http.StatusCreated,
+			expectedRemaining: "1",
+		},
+		{
+			name:              "should count user from another IP",
+			path:              "/note",
+			token:             token,
+			ip:                "10.0.0.2",
+			expectedCode:      http.StatusCreated,
+			expectedRemaining: "0",
+		},
+		{
+			name:              "should return ErrRateLimited",
+			path:              "/note",
+			token:             token,
+			ip:                "10.0.0.1",
+			expectedCode:      http.StatusTooManyRequests,
+			expectedRemaining: "0",
+			expectedRetry:     "30",
+		},
+		{
+			name:              "should keep buckets per user",
+			path:              "/note",
+			token:             otherToken,
+			ip:                "10.0.0.1",
+			expectedCode:      http.StatusCreated,
+			expectedRemaining: "1",
+		},
+		{
+			name:              "should use default rule for other routes",
+			path:              "/user",
+			token:             token,
+			ip:                "10.0.0.1",
+			expectedCode:      http.StatusCreated,
+			expectedRemaining: "2",
+		},
+		{
+			name:              "should count anonymous requests per IP",
+			path:              "/user",
+			ip:                "10.0.0.1",
+			expectedCode:      http.StatusCreated,
+			expectedRemaining: "2",
+		},
+		{
+			name:              "should count invalid token per IP",
+			path:              "/user",
+			token:             "invalid",
+			ip:                "10.0.0.1",
+			expectedCode:      http.StatusCreated,
+			expectedRemaining: "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send(tt.path, tt.token, tt.ip)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedRemaining, w.Header().Get("RateLimit-Remaining"))
+			assert.Equal(t, tt.expectedRetry, w.Header().Get("Retry-After"))
+			if tt.expectedCode == http.StatusTooManyRequests {
+				assert.JSONEq(t, `{"error":"`+ErrRateLimited.Error()+`"}`, w.Body.String())
+				assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+				assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+			}
+		})
+	}
+}
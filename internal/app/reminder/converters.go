@@ -0,0 +1,25 @@
+package reminder
+
+import (
+	reminderpkg "note-service/internal/pkg/reminder"
+)
+
+func reminderToReminderResponse(r reminderpkg.Reminder) ReminderResponse {
+	return ReminderResponse{
+		ID:        r.ID,
+		NoteID:    r.NoteID,
+		UserID:    r.UserID,
+		RemindAt:  r.RemindAt.Unix(),
+		Status:    r.Status,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+func remindersToReminderResponses(reminders []reminderpkg.Reminder) []ReminderResponse {
+	res := make([]ReminderResponse, len(reminders))
+	for i, r := range reminders {
+		res[i] = reminderToReminderResponse(r)
+	}
+	return res
+}
@@ -0,0 +1,21 @@
+package reminder
+
+import "time"
+
+type ReminderResponse struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"noteId"`
+	UserID    string    `json:"userId"`
+	RemindAt  int64     `json:"remindAt"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type PostRequest struct {
+	RemindAt int64 `json:"remindAt"`
+}
+
+type SnoozeRequest struct {
+	Until int64 `json:"until"`
+}
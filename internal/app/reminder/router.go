@@ -0,0 +1,156 @@
+package reminder
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"note-service/internal/app"
+	notepkg "note-service/internal/pkg/note"
+	"note-service/internal/pkg/notify"
+	reminderpkg "note-service/internal/pkg/reminder"
+	"time"
+)
+
+const defaultUpcomingWindow = 24 * time.Hour
+
+type reminderService interface {
+	CreateReminder(noteID, userID string, remindAt time.Time) (reminderpkg.Reminder, error)
+	GetUpcoming(userID string, within time.Duration) ([]reminderpkg.Reminder, error)
+	Snooze(id, userID string, until time.Time) (reminderpkg.Reminder, error)
+	Dismiss(id, userID string) (reminderpkg.Reminder, error)
+}
+
+type eventSubscriber interface {
+	Subscribe(handler func(notify.Event)) func()
+}
+
+type Router struct {
+	service reminderService
+	events  eventSubscriber
+	logger  *zap.Logger
+}
+
+func NewRouter(service reminderService, events eventSubscriber, logger *zap.Logger) *Router {
+	return &Router{service: service, events: events, logger: logger}
+}
+
+func (r *Router) SetUpRouter(engine *gin.Engine) {
+	engine.POST("/note/:id/reminders", app.AuthMiddleware(), r.postReminder)
+	engine.GET("/reminders/upcoming", app.AuthMiddleware(), r.getUpcoming)
+	engine.GET("/reminders/stream", app.AuthMiddleware(), r.streamReminders)
+	engine.POST("/reminder/:id/snooze", app.AuthMiddleware(), r.snooze)
+	engine.POST("/reminder/:id/dismiss", app.AuthMiddleware(), r.dismiss)
+}
+
+func (r *Router) postReminder(c *gin.Context) {
+	var request PostRequest
+	if err := c.BindJSON(&request); err != nil {
+		r.logger.Error("failed to bind json", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	rem, err := r.service.CreateReminder(c.Param("id"), c.GetString("userId"), time.Unix(request.RemindAt, 0).UTC())
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("reminder is created", zap.String("reminderID", rem.ID))
+	c.IndentedJSON(http.StatusCreated, reminderToReminderResponse(rem))
+}
+
+func (r *Router) getUpcoming(c *gin.Context) {
+	within := defaultUpcomingWindow
+	if param := c.Query("within"); param != "" {
+		d, err := time.ParseDuration(param)
+		if err != nil || d <= 0 {
+			c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: ErrWithinInvalid.Error()})
+			return
+		}
+		within = d
+	}
+
+	reminders, err := r.service.GetUpcoming(c.GetString("userId"), within)
+	if err != nil {
+		r.logger.Error("failed to get upcoming reminders", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, remindersToReminderResponses(reminders))
+}
+
+// streamReminders sends due reminders of the user as server-sent events
+func (r *Router) streamReminders(c *gin.Context) {
+	userID := c.GetString("userId")
+	events := make(chan notify.Event, 16)
+	unsubscribe := r.events.Subscribe(func(e notify.Event) {
+		if e.Type != notify.EventReminderDue || e.UserID != userID {
+			return
+		}
+		select {
+		case events <- e:
+		default:
+			r.logger.Warn("reminder stream is full, event dropped", zap.String("reminderID", e.ReminderID))
+		}
+	})
+	defer unsubscribe()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e := <-events:
+			c.SSEvent(e.Type, e)
+			return true
+		}
+	})
+}
+
+func (r *Router) snooze(c *gin.Context) {
+	var request SnoozeRequest
+	if err := c.BindJSON(&request); err != nil {
+		r.logger.Error("failed to bind json", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	rem, err := r.service.Snooze(c.Param("id"), c.GetString("userId"), time.Unix(request.Until, 0).UTC())
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, reminderToReminderResponse(rem))
+}
+
+func (r *Router) dismiss(c *gin.Context) {
+	rem, err := r.service.Dismiss(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, reminderToReminderResponse(rem))
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, reminderpkg.ErrReminderNotFound), errors.Is(err, notepkg.ErrNoteNotFound):
+		c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, app.ErrNoAccess):
+		c.IndentedJSON(http.StatusForbidden, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, reminderpkg.ErrRemindAtPassed):
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+	default:
+		r.logger.Error("failed to handle reminder", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+	}
+}
@@ -0,0 +1,219 @@
+package reminder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/app"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/note"
+	"note-service/internal/pkg/notify"
+	"note-service/internal/pkg/reminder"
+	"testing"
+	"time"
+)
+
+type reminderServiceMock struct {
+	CreateReminderFunc func(noteID, userID string, remindAt time.Time) (reminder.Reminder, error)
+	GetUpcomingFunc    func(userID string, within time.Duration) ([]reminder.Reminder, error)
+	SnoozeFunc         func(id, userID string, until time.Time) (reminder.Reminder, error)
+	DismissFunc        func(id, userID string) (reminder.Reminder, error)
+}
+
+func (m *reminderServiceMock) CreateReminder(noteID, userID string, remindAt time.Time) (reminder.Reminder, error) {
+	return m.CreateReminderFunc(noteID, userID, remindAt)
+}
+
+func (m *reminderServiceMock) GetUpcoming(userID string, within time.Duration) ([]reminder.Reminder, error) {
+	return m.GetUpcomingFunc(userID, within)
+}
+
+func (m *reminderServiceMock) Snooze(id, userID string, until time.Time) (reminder.Reminder, error) {
+	return m.SnoozeFunc(id, userID, until)
+}
+
+func (m *reminderServiceMock) Dismiss(id, userID string) (reminder.Reminder, error) {
+	return m.DismissFunc(id, userID)
+}
+
+func TestPostReminder(t *testing.T) {
+	tests := []struct {
+		name            string
+		reminderService reminderServiceMock
+		Request         PostRequest
+		expectedCode    int
+		expectedError   *app.ErrorModel
+	}{
+		{
+			name:         "should return request error",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:    "should return errNoteNotFound",
+			Request: PostRequest{RemindAt: 100},
+			reminderService: reminderServiceMock{
+				CreateReminderFunc: func(noteID, userID string, remindAt time.Time) (reminder.Reminder, error) {
+					return reminder.Reminder{}, note.ErrNoteNotFound
+				},
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: &app.ErrorModel{Error: note.ErrNoteNotFound.Error()},
+		},
+		{
+			name:    "should return ErrNoAccess",
+			Request: PostRequest{RemindAt: 100},
+			reminderService: reminderServiceMock{
+				CreateReminderFunc: func(noteID, userID string, remindAt time.Time) (reminder.Reminder, error) {
+					return reminder.Reminder{}, app.ErrNoAccess
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: app.ErrNoAccess.Error()},
+		},
+		{
+			name:    "should create reminder",
+			Request: PostRequest{RemindAt: 100},
+			reminderService: reminderServiceMock{
+				CreateReminderFunc: func(noteID, userID string, remindAt time.Time) (reminder.Reminder, error) {
+					return reminder.Reminder{ID: "1", NoteID: noteID, UserID: userID, RemindAt: remindAt}, nil
+				},
+			},
+			expectedCode: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.reminderService, notify.NewBus(), zap.NewNop())
+			r.SetUpRouter(g)
+
+			jsonValue, _ := json.Marshal(tt.Request)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/note/123-123/reminders", bytes.NewBuffer(jsonValue))
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+		})
+	}
+}
+
+func TestGetUpcoming(t *testing.T) {
+	tests := []struct {
+		name            string
+		reminderService reminderServiceMock
+		query           string
+		expectedCode    int
+		expectedWithin  time.Duration
+	}{
+		{
+			name:         "should return request error",
+			query:        "?within=soon",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:  "should return unknownError",
+			query: "",
+			reminderService: reminderServiceMock{
+				GetUpcomingFunc: func(userID string, within time.Duration) ([]reminder.Reminder, error) {
+					return nil, errors.New("something wrong")
+				},
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			name:           "should return reminders within window",
+			query:          "?within=2h",
+			expectedCode:   http.StatusOK,
+			expectedWithin: 2 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var within time.Duration
+			if tt.reminderService.GetUpcomingFunc == nil {
+				tt.reminderService.GetUpcomingFunc = func(userID string, w time.Duration) ([]reminder.Reminder, error) {
+					within = w
+					return []reminder.Reminder{{ID: "1"}}, nil
+				}
+			}
+			g := gin.Default()
+			r := NewRouter(&tt.reminderService, notify.NewBus(), zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/reminders/upcoming"+tt.query, nil)
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				var response []ReminderResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, 1, len(response))
+				assert.Equal(t, tt.expectedWithin, within)
+			}
+		})
+	}
+}
+
+func TestDismiss(t *testing.T) {
+	tests := []struct {
+		name            string
+		reminderService reminderServiceMock
+		expectedCode    int
+	}{
+		{
+			name: "should return errReminderNotFound",
+			reminderService: reminderServiceMock{
+				DismissFunc: func(id, userID string) (reminder.Reminder, error) {
+					return reminder.Reminder{}, reminder.ErrReminderNotFound
+				},
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name: "should dismiss reminder",
+			reminderService: reminderServiceMock{
+				DismissFunc: func(id, userID string) (reminder.Reminder, error) {
+					return reminder.Reminder{ID: id, Status: reminder.StatusDismissed}, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.reminderService, notify.NewBus(), zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/reminder/1/dismiss", nil)
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
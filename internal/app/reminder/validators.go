@@ -0,0 +1,34 @@
+package reminder
+
+import (
+	"errors"
+	"note-service/internal/app"
+)
+
+var (
+	ErrRemindAtEmpty = errors.New("empty remindAt")
+	ErrUntilEmpty    = errors.New("empty until")
+	ErrWithinInvalid = errors.New("invalid within duration")
+)
+
+func (r PostRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if r.RemindAt <= 0 {
+		ve.Errors["remindAt"] = ErrRemindAtEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r SnoozeRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if r.Until <= 0 {
+		ve.Errors["until"] = ErrUntilEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
@@ -26,6 +26,16 @@ func NewRouter(logger *zap.Logger, subRouters ...subRouter) *Router {
 	return &Router{r, subRouters}
 }
 
+// Use adds middleware to all routes, it must be called before SetUpRouter
+func (r *Router) Use(middleware ...gin.HandlerFunc) {
+	r.ginContext.Use(middleware...)
+}
+
+// SetTrustedProxies sets proxies whose X-Forwarded-For is used as the client IP, nil trusts none
+func (r *Router) SetTrustedProxies(proxies []string) error {
+	return r.ginContext.SetTrustedProxies(proxies)
+}
+
 func (r *Router) SetUpRouter() {
 	for _, s := range r.subRouters {
 		s.SetUpRouter(r.ginContext)
@@ -0,0 +1,38 @@
+package template
+
+import (
+	templatepkg "note-service/internal/pkg/template"
+)
+
+func postRequestToTemplate(request PostRequest) templatepkg.Template {
+	return templatepkg.Template{
+		UserID:  request.UserID,
+		Name:    request.Name,
+		Subject: request.Subject,
+		Text:    request.Text,
+		Cron:    request.Cron,
+	}
+}
+
+func templateToTemplateResponse(t templatepkg.Template) TemplateResponse {
+	return TemplateResponse{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Name:      t.Name,
+		Subject:   t.Subject,
+		Text:      t.Text,
+		Cron:      t.Cron,
+		Paused:    t.Paused,
+		NextRunAt: t.NextRunAt,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+func templatesToTemplateResponses(templates []templatepkg.Template) []TemplateResponse {
+	res := make([]TemplateResponse, len(templates))
+	for i, t := range templates {
+		res[i] = templateToTemplateResponse(t)
+	}
+	return res
+}
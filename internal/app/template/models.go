@@ -0,0 +1,24 @@
+package template
+
+import "time"
+
+type TemplateResponse struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"userId"`
+	Name      string     `json:"name"`
+	Subject   string     `json:"subject"`
+	Text      string     `json:"text"`
+	Cron      string     `json:"cron"`
+	Paused    bool       `json:"paused"`
+	NextRunAt *time.Time `json:"nextRunAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+type PostRequest struct {
+	UserID  string `json:"userId"`
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	Cron    string `json:"cron"`
+}
@@ -0,0 +1,138 @@
+package template
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"note-service/internal/app"
+	"note-service/internal/app/note"
+	notepkg "note-service/internal/pkg/note"
+	templatepkg "note-service/internal/pkg/template"
+)
+
+type templateService interface {
+	CreateTemplate(t templatepkg.Template) (templatepkg.Template, error)
+	GetTemplates(userID string) ([]templatepkg.Template, error)
+	DeleteTemplate(id, userID string) error
+	CreateNote(id, userID string) (notepkg.Note, error)
+	GetSchedules(userID string) ([]templatepkg.Template, error)
+	PauseSchedule(id, userID string) (templatepkg.Template, error)
+	ResumeSchedule(id, userID string) (templatepkg.Template, error)
+	DeleteSchedule(id, userID string) (templatepkg.Template, error)
+}
+
+type Router struct {
+	service templateService
+	logger  *zap.Logger
+}
+
+func NewRouter(service templateService, logger *zap.Logger) *Router {
+	return &Router{service: service, logger: logger}
+}
+
+func (r *Router) SetUpRouter(engine *gin.Engine) {
+	engine.POST("/template", app.AuthMiddleware(), r.postTemplate)
+	engine.GET("/templates", app.AuthMiddleware(), r.getTemplates)
+	engine.DELETE("/template/:id", app.AuthMiddleware(), r.deleteTemplate)
+	engine.POST("/note/from-template/:id", app.AuthMiddleware(), r.postNoteFromTemplate)
+	engine.GET("/schedules", app.AuthMiddleware(), r.getSchedules)
+	engine.POST("/schedule/:id/pause", app.AuthMiddleware(), r.pauseSchedule)
+	engine.POST("/schedule/:id/resume", app.AuthMiddleware(), r.resumeSchedule)
+	engine.DELETE("/schedule/:id", app.AuthMiddleware(), r.deleteSchedule)
+}
+
+func (r *Router) postTemplate(c *gin.Context) {
+	var request PostRequest
+	if err := c.BindJSON(&request); err != nil {
+		r.logger.Error("failed to bind json", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
+		return
+	}
+
+	request.UserID = c.GetString("userId")
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+
+	t, err := r.service.CreateTemplate(postRequestToTemplate(request))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("template is created", zap.String("templateID", t.ID))
+	c.IndentedJSON(http.StatusCreated, templateToTemplateResponse(t))
+}
+
+func (r *Router) getTemplates(c *gin.Context) {
+	templates, err := r.service.GetTemplates(c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, templatesToTemplateResponses(templates))
+}
+
+func (r *Router) deleteTemplate(c *gin.Context) {
+	if err := r.service.DeleteTemplate(c.Param("id"), c.GetString("userId")); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("template was deleted")
+	c.IndentedJSON(http.StatusOK, gin.H{"template": "template successfully deleted"})
+}
+
+func (r *Router) postNoteFromTemplate(c *gin.Context) {
+	n, err := r.service.CreateNote(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("note is created from template", zap.String("noteID", n.ID))
+	c.IndentedJSON(http.StatusCreated, note.NoteToNoteResponse(n))
+}
+
+func (r *Router) getSchedules(c *gin.Context) {
+	templates, err := r.service.GetSchedules(c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, templatesToTemplateResponses(templates))
+}
+
+func (r *Router) pauseSchedule(c *gin.Context) {
+	r.respondTemplate(c, r.service.PauseSchedule)
+}
+
+func (r *Router) resumeSchedule(c *gin.Context) {
+	r.respondTemplate(c, r.service.ResumeSchedule)
+}
+
+func (r *Router) deleteSchedule(c *gin.Context) {
+	r.respondTemplate(c, r.service.DeleteSchedule)
+}
+
+func (r *Router) respondTemplate(c *gin.Context, action func(id, userID string) (templatepkg.Template, error)) {
+	t, err := action(c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, templateToTemplateResponse(t))
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, templatepkg.ErrTemplateNotFound):
+		c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, app.ErrNoAccess):
+		c.IndentedJSON(http.StatusForbidden, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, templatepkg.ErrNoSchedule):
+		c.IndentedJSON(http.StatusConflict, app.ErrorModel{Error: err.Error()})
+	default:
+		r.logger.Error("failed to handle template", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+	}
+}
@@ -0,0 +1,212 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"note-service/internal/app"
+	appnote "note-service/internal/app/note"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/note"
+	"note-service/internal/pkg/template"
+	"testing"
+)
+
+type templateServiceMock struct {
+	CreateTemplateFunc func(t template.Template) (template.Template, error)
+	GetTemplatesFunc   func(userID string) ([]template.Template, error)
+	DeleteTemplateFunc func(id, userID string) error
+	CreateNoteFunc     func(id, userID string) (note.Note, error)
+	GetSchedulesFunc   func(userID string) ([]template.Template, error)
+	PauseScheduleFunc  func(id, userID string) (template.Template, error)
+	ResumeScheduleFunc func(id, userID string) (template.Template, error)
+	DeleteScheduleFunc func(id, userID string) (template.Template, error)
+}
+
+func (m *templateServiceMock) CreateTemplate(t template.Template) (template.Template, error) {
+	return m.CreateTemplateFunc(t)
+}
+
+func (m *templateServiceMock) GetTemplates(userID string) ([]template.Template, error) {
+	return m.GetTemplatesFunc(userID)
+}
+
+func (m *templateServiceMock) DeleteTemplate(id, userID string) error {
+	return m.DeleteTemplateFunc(id, userID)
+}
+
+func (m *templateServiceMock) CreateNote(id, userID string) (note.Note, error) {
+	return m.CreateNoteFunc(id, userID)
+}
+
+func (m *templateServiceMock) GetSchedules(userID string) ([]template.Template, error) {
+	return m.GetSchedulesFunc(userID)
+}
+
+func (m *templateServiceMock) PauseSchedule(id, userID string) (template.Template, error) {
+	return m.PauseScheduleFunc(id, userID)
+}
+
+func (m *templateServiceMock) ResumeSchedule(id, userID string) (template.Template, error) {
+	return m.ResumeScheduleFunc(id, userID)
+}
+
+func (m *templateServiceMock) DeleteSchedule(id, userID string) (template.Template, error) {
+	return m.DeleteScheduleFunc(id, userID)
+}
+
+func TestPostTemplate(t *testing.T) {
+	tests := []struct {
+		name            string
+		templateService templateServiceMock
+		Request         PostRequest
+		expectedCode    int
+	}{
+		{
+			name:         "should return request error",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "should return request error, invalid cron",
+			Request:      PostRequest{Name: "retro", Text: "123", Cron: "every friday"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:    "should create template",
+			Request: PostRequest{Name: "retro", Text: "123", Cron: "0 16 * * 5"},
+			templateService: templateServiceMock{
+				CreateTemplateFunc: func(t template.Template) (template.Template, error) {
+					t.ID = "123"
+					return t, nil
+				},
+			},
+			expectedCode: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.templateService, zap.NewNop())
+			r.SetUpRouter(g)
+
+			jsonValue, _ := json.Marshal(tt.Request)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/template", bytes.NewBuffer(jsonValue))
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestPostNoteFromTemplate(t *testing.T) {
+	tests := []struct {
+		name            string
+		templateService templateServiceMock
+		expectedCode    int
+		expectedNote    appnote.NoteResponse
+	}{
+		{
+			name: "should return errTemplateNotFound",
+			templateService: templateServiceMock{
+				CreateNoteFunc: func(id, userID string) (note.Note, error) {
+					return note.Note{}, template.ErrTemplateNotFound
+				},
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name: "should return ErrNoAccess",
+			templateService: templateServiceMock{
+				CreateNoteFunc: func(id, userID string) (note.Note, error) {
+					return note.Note{}, app.ErrNoAccess
+				},
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name: "should create note",
+			templateService: templateServiceMock{
+				CreateNoteFunc: func(id, userID string) (note.Note, error) {
+					return note.Note{ID: "123-123", UserID: userID, Text: "123"}, nil
+				},
+			},
+			expectedCode: http.StatusCreated,
+			expectedNote: appnote.NoteToNoteResponse(note.Note{ID: "123-123", UserID: "123-123", Text: "123"}),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.templateService, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/note/from-template/1", nil)
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusCreated {
+				var response appnote.NoteResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedNote, response)
+			}
+		})
+	}
+}
+
+func TestPauseSchedule(t *testing.T) {
+	tests := []struct {
+		name            string
+		templateService templateServiceMock
+		expectedCode    int
+	}{
+		{
+			name: "should return ErrNoSchedule",
+			templateService: templateServiceMock{
+				PauseScheduleFunc: func(id, userID string) (template.Template, error) {
+					return template.Template{}, template.ErrNoSchedule
+				},
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "should pause schedule",
+			templateService: templateServiceMock{
+				PauseScheduleFunc: func(id, userID string) (template.Template, error) {
+					return template.Template{ID: id, Paused: true}, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.templateService, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/schedule/1/pause", nil)
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
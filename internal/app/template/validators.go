@@ -0,0 +1,35 @@
+package template
+
+import (
+	"errors"
+	"note-service/internal/app"
+	"note-service/internal/pkg/cron"
+)
+
+var (
+	ErrNameEmpty   = errors.New("empty name")
+	ErrTextEmpty   = errors.New("empty text")
+	ErrUserIDEmpty = errors.New("empty userID")
+)
+
+func (r PostRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Name) == 0 {
+		ve.Errors["name"] = ErrNameEmpty.Error()
+	}
+	if len(r.Text) == 0 {
+		ve.Errors["text"] = ErrTextEmpty.Error()
+	}
+	if len(r.UserID) == 0 {
+		ve.Errors["userid"] = ErrUserIDEmpty.Error()
+	}
+	if r.Cron != "" {
+		if _, err := cron.Parse(r.Cron); err != nil {
+			ve.Errors["cron"] = err.Error()
+		}
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
@@ -5,3 +5,26 @@ import "note-service/internal/pkg/user"
 func userToUserResponse(user user.User) UserResponse {
 	return UserResponse{user.ID, user.Username}
 }
+
+func userToProfileResponse(user user.User) ProfileResponse {
+	return ProfileResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		DisplayName:   user.DisplayName,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		MFAEnabled:    user.TOTPEnabled,
+		Locale:        user.Locale,
+		TimeZone:      user.TimeZone,
+		CreatedAt:     user.CreatedAt,
+	}
+}
+
+func profileRequestToProfileUpdate(request ProfileRequest) user.ProfileUpdate {
+	return user.ProfileUpdate{
+		DisplayName: request.DisplayName,
+		Email:       request.Email,
+		Locale:      request.Locale,
+		TimeZone:    request.TimeZone,
+	}
+}
@@ -1,5 +1,7 @@
 package user
 
+import "time"
+
 type UserResponse struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
@@ -10,7 +12,80 @@ type SignUpRequest struct {
 	Password string `json:"password"`
 }
 
+// LoginRequest may limit the token to scopes, without them the token has full access
 type LoginRequest struct {
-	Username string `json:"username"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Scopes   []string `json:"scopes"`
+}
+
+type ProfileResponse struct {
+	ID            string    `json:"id"`
+	Username      string    `json:"username"`
+	DisplayName   string    `json:"displayName"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"emailVerified"`
+	MFAEnabled    bool      `json:"mfaEnabled"`
+	Locale        string    `json:"locale"`
+	TimeZone      string    `json:"timeZone"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type ProfileRequest struct {
+	DisplayName *string `json:"displayName"`
+	Email       *string `json:"email"`
+	Locale      *string `json:"locale"`
+	TimeZone    *string `json:"timeZone"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+type DeleteUserRequest struct {
+	Password string `json:"password"`
+}
+
+type ForgotPasswordRequest struct {
+	Login string `json:"login"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// MFAChallengeResponse is returned by login instead of a token when 2FA is enabled
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	Challenge   string `json:"challenge"`
+}
+
+type MFALoginRequest struct {
+	Challenge string   `json:"challenge"`
+	Code      string   `json:"code"`
+	Scopes    []string `json:"scopes"`
+}
+
+type MFAEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type DisableMFARequest struct {
 	Password string `json:"password"`
+	Code     string `json:"code"`
 }
@@ -3,31 +3,72 @@ package user
 import (
 	"errors"
 	"go.uber.org/zap"
+	"math"
 	"net/http"
 	"note-service/internal/pkg/jwt"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"note-service/internal/app"
+	"note-service/internal/pkg/audit"
 	userpkg "note-service/internal/pkg/user"
 )
 
 type userService interface {
 	SignUp(name, password string) (userpkg.User, error)
-	Login(name, password string) (userpkg.User, error)
+	Login(name, password, ip string) (userpkg.User, error)
+	GetUser(id string) (userpkg.User, error)
+	UpdateProfile(id string, update userpkg.ProfileUpdate) (userpkg.User, error)
+	ChangePassword(id, oldPassword, newPassword string) (userpkg.User, error)
+	DeleteUser(id, password string) error
+}
+
+type recoveryService interface {
+	RequestPasswordReset(login string) error
+	ResetPassword(token, password string) (userpkg.User, error)
+	SendVerification(userID string) error
+	VerifyEmail(token string) (userpkg.User, error)
+}
+
+type mfaService interface {
+	Enroll(userID string) (secret, uri string, err error)
+	Confirm(userID, code string) ([]string, error)
+	Disable(userID, password, code string) error
+	Challenge(u userpkg.User) string
+	CompleteLogin(challenge, code, ip string) (userpkg.User, error)
+}
+
+type auditLog interface {
+	Record(e audit.Event)
 }
 
 type Router struct {
-	service userService
-	logger  *zap.Logger
+	service  userService
+	recovery recoveryService
+	mfa      mfaService
+	audit    auditLog
+	logger   *zap.Logger
 }
 
-func NewRouter(service userService, logger *zap.Logger) *Router {
-	return &Router{service: service, logger: logger}
+func NewRouter(service userService, recovery recoveryService, mfa mfaService, auditLog auditLog, logger *zap.Logger) *Router {
+	return &Router{service: service, recovery: recovery, mfa: mfa, audit: auditLog, logger: logger}
 }
 
 func (r *Router) SetUpRouter(engine *gin.Engine) {
 	engine.POST("/user", r.signUp)
 	engine.POST("/user/login", r.login)
+	engine.POST("/user/login/mfa", r.completeLogin)
+	engine.GET("/user/me", app.AuthMiddleware(), r.getProfile)
+	engine.PATCH("/user/me", app.AuthMiddleware(), r.updateProfile)
+	engine.POST("/user/me/password", app.AuthMiddleware(), r.changePassword)
+	engine.DELETE("/user/me", app.AuthMiddleware(), r.deleteUser)
+	engine.POST("/user/password/forgot", r.forgotPassword)
+	engine.POST("/user/password/reset", r.resetPassword)
+	engine.POST("/user/me/email/verification", app.AuthMiddleware(), r.sendVerification)
+	engine.POST("/user/email/verify", r.verifyEmail)
+	engine.POST("/user/me/2fa", app.AuthMiddleware(), r.enrollMFA)
+	engine.POST("/user/me/2fa/confirm", app.AuthMiddleware(), r.confirmMFA)
+	engine.DELETE("/user/me/2fa", app.AuthMiddleware(), r.disableMFA)
 }
 
 func (r *Router) signUp(c *gin.Context) {
@@ -52,6 +93,7 @@ func (r *Router) signUp(c *gin.Context) {
 		}
 		return
 	}
+	r.record(c, audit.ActionSignUp, u.ID)
 	r.logger.Info("user was created", zap.Any("user", userToUserResponse(u)))
 	c.IndentedJSON(http.StatusCreated, userToUserResponse(u))
 }
@@ -69,23 +111,298 @@ func (r *Router) login(c *gin.Context) {
 		c.IndentedJSON(http.StatusBadRequest, err)
 		return
 	}
-	u, err := r.service.Login(request.Username, request.Password)
+	u, err := r.service.Login(request.Username, request.Password, c.ClientIP())
 	if err != nil {
+		r.audit.Record(app.AuditEvent(c, audit.ActionLoginFailed).
+			With("username", request.Username).
+			With("reason", err.Error()))
+		var throttleErr *userpkg.ThrottleError
 		if errors.Is(err, userpkg.ErrUserNotFound) {
 			c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+		} else if errors.Is(err, userpkg.ErrUserDisabled) {
+			c.IndentedJSON(http.StatusForbidden, app.ErrorModel{Error: err.Error()})
+		} else if errors.As(err, &throttleErr) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttleErr.RetryAfter.Seconds()))))
+			c.IndentedJSON(http.StatusTooManyRequests, app.ErrorModel{Error: err.Error()})
 		} else {
 			r.logger.Error("failed to create jwt-token", zap.Error(err))
 			c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
 		}
 		return
 	}
+	if u.TOTPEnabled {
+		r.logger.Info("user passed the password step")
+		c.IndentedJSON(http.StatusOK, MFAChallengeResponse{MFARequired: true, Challenge: r.mfa.Challenge(u)})
+		return
+	}
 
-	token, err := jwt.CreateToken(u.ID)
+	token, err := jwt.CreateToken(u.ID, request.Scopes...)
 	if err != nil {
 		r.logger.Error("failed to create jwt-token", zap.Error(err))
 		c.IndentedJSON(http.StatusInternalServerError, app.ErrorModel{Error: err.Error()})
 		return
 	}
+	r.record(c, audit.ActionLogin, u.ID)
 	r.logger.Info("user was authorized")
 	c.IndentedJSON(http.StatusOK, app.TokenModel{Token: token})
 }
+
+// completeLogin exchanges the challenge from login and a TOTP or recovery code for a token
+func (r *Router) completeLogin(c *gin.Context) {
+	var request MFALoginRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	u, err := r.mfa.CompleteLogin(request.Challenge, request.Code, c.ClientIP())
+	if err != nil {
+		r.audit.Record(app.AuditEvent(c, audit.ActionLoginFailed).
+			With("step", "mfa").
+			With("reason", err.Error()))
+		r.handleError(c, err)
+		return
+	}
+	token, err := jwt.CreateToken(u.ID, request.Scopes...)
+	if err != nil {
+		r.logger.Error("failed to create jwt-token", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+		return
+	}
+	r.record(c, audit.ActionLogin, u.ID, "mfa", "true")
+	r.logger.Info("user was authorized")
+	c.IndentedJSON(http.StatusOK, app.TokenModel{Token: token})
+}
+
+func (r *Router) getProfile(c *gin.Context) {
+	u, err := r.service.GetUser(c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, userToProfileResponse(u))
+}
+
+func (r *Router) updateProfile(c *gin.Context) {
+	var request ProfileRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	u, err := r.service.UpdateProfile(c.GetString("userId"), profileRequestToProfileUpdate(request))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	if request.Email != nil && u.Email != "" && !u.EmailVerified {
+		if err := r.recovery.SendVerification(u.ID); err != nil {
+			r.logger.Error("failed to send email verification", zap.Error(err))
+		}
+	}
+	r.logger.Info("profile was updated")
+	c.IndentedJSON(http.StatusOK, userToProfileResponse(u))
+}
+
+// changePassword signs out all sessions and returns a new token for the current one
+func (r *Router) changePassword(c *gin.Context) {
+	var request ChangePasswordRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	u, err := r.service.ChangePassword(c.GetString("userId"), request.OldPassword, request.NewPassword)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	token, err := jwt.CreateToken(u.ID)
+	if err != nil {
+		r.logger.Error("failed to create jwt-token", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+		return
+	}
+	r.record(c, audit.ActionTokensRevoked, u.ID, "reason", "password_change")
+	r.logger.Info("password was changed")
+	c.IndentedJSON(http.StatusOK, app.TokenModel{Token: token})
+}
+
+func (r *Router) deleteUser(c *gin.Context) {
+	var request DeleteUserRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	if err := r.service.DeleteUser(c.GetString("userId"), request.Password); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.record(c, audit.ActionUserDelete, c.GetString("userId"))
+	r.record(c, audit.ActionTokensRevoked, c.GetString("userId"), "reason", "account_delete")
+	r.logger.Info("user was deleted")
+	c.IndentedJSON(http.StatusOK, gin.H{"user": "user successfully deleted"})
+}
+
+// forgotPassword answers the same way whether the account exists or not
+func (r *Router) forgotPassword(c *gin.Context) {
+	var request ForgotPasswordRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	if err := r.recovery.RequestPasswordReset(request.Login); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusAccepted, gin.H{"user": "if the account has an email, the reset link was sent to it"})
+}
+
+func (r *Router) resetPassword(c *gin.Context) {
+	var request ResetPasswordRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	u, err := r.recovery.ResetPassword(request.Token, request.Password)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.record(c, audit.ActionTokensRevoked, u.ID, "reason", "password_reset")
+	r.logger.Info("password was reset")
+	c.IndentedJSON(http.StatusOK, gin.H{"user": "password successfully reset"})
+}
+
+func (r *Router) sendVerification(c *gin.Context) {
+	if err := r.recovery.SendVerification(c.GetString("userId")); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusAccepted, gin.H{"user": "verification link was sent"})
+}
+
+func (r *Router) verifyEmail(c *gin.Context) {
+	var request VerifyEmailRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	u, err := r.recovery.VerifyEmail(request.Token)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.logger.Info("email was verified")
+	c.IndentedJSON(http.StatusOK, userToProfileResponse(u))
+}
+
+// enrollMFA returns a new secret, 2FA is enabled only after confirmMFA
+func (r *Router) enrollMFA(c *gin.Context) {
+	secret, uri, err := r.mfa.Enroll(c.GetString("userId"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, MFAEnrollResponse{Secret: secret, URI: uri})
+}
+
+// confirmMFA enables 2FA, the recovery codes are shown only here
+func (r *Router) confirmMFA(c *gin.Context) {
+	var request MFACodeRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	codes, err := r.mfa.Confirm(c.GetString("userId"), request.Code)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.record(c, audit.ActionMFAEnable, c.GetString("userId"))
+	r.logger.Info("two-factor authentication was enabled")
+	c.IndentedJSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (r *Router) disableMFA(c *gin.Context) {
+	var request DisableMFARequest
+	if err := c.BindJSON(&request); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+		return
+	}
+	if err := request.Validate(); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, err)
+		return
+	}
+	if err := r.mfa.Disable(c.GetString("userId"), request.Password, request.Code); err != nil {
+		r.handleError(c, err)
+		return
+	}
+	r.record(c, audit.ActionMFADisable, c.GetString("userId"))
+	r.logger.Info("two-factor authentication was disabled")
+	c.IndentedJSON(http.StatusOK, gin.H{"user": "two-factor authentication successfully disabled"})
+}
+
+// record writes an event about the user to the audit log. Before login the user is the actor as well.
+func (r *Router) record(c *gin.Context, action, userID string, details ...string) {
+	e := app.AuditEvent(c, action).On(audit.TargetUser, userID, userID)
+	if e.ActorID == "" {
+		e.ActorID = userID
+	}
+	for i := 0; i+1 < len(details); i += 2 {
+		e = e.With(details[i], details[i+1])
+	}
+	r.audit.Record(e)
+}
+
+func (r *Router) handleError(c *gin.Context, err error) {
+	var throttleErr *userpkg.ThrottleError
+	switch {
+	case errors.As(err, &throttleErr):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttleErr.RetryAfter.Seconds()))))
+		c.IndentedJSON(http.StatusTooManyRequests, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, userpkg.ErrUserNotFound):
+		c.IndentedJSON(http.StatusNotFound, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, userpkg.ErrWrongPassword), errors.Is(err, userpkg.ErrInvalidCode),
+		errors.Is(err, userpkg.ErrUserDisabled):
+		c.IndentedJSON(http.StatusForbidden, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, userpkg.ErrInvalidToken), errors.Is(err, userpkg.ErrTokenExpired),
+		errors.Is(err, userpkg.ErrTokenUsed), errors.Is(err, userpkg.ErrNoEmail):
+		c.IndentedJSON(http.StatusBadRequest, app.ErrorModel{Error: err.Error()})
+	case errors.Is(err, userpkg.ErrMFAEnabled), errors.Is(err, userpkg.ErrMFADisabled),
+		errors.Is(err, userpkg.ErrMFANotEnrolled), errors.Is(err, userpkg.ErrUsedEmail):
+		c.IndentedJSON(http.StatusConflict, app.ErrorModel{Error: err.Error()})
+	default:
+		r.logger.Error("failed to handle user", zap.Error(err))
+		c.IndentedJSON(http.StatusInternalServerError, app.UnknownError)
+	}
+}
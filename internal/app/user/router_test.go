@@ -7,25 +7,107 @@ import (
 	"go.uber.org/zap"
 	"net/http"
 	"net/http/httptest"
+	"note-service/internal/pkg/jwt"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"note-service/internal/app"
+	"note-service/internal/pkg/audit"
 	"note-service/internal/pkg/user"
 )
 
 type userServiceMock struct {
-	SignUpFunc func(name, password string) (user.User, error)
-	LoginFunc  func(name, password string) (user.User, error)
+	SignUpFunc         func(name, password string) (user.User, error)
+	LoginFunc          func(name, password, ip string) (user.User, error)
+	GetUserFunc        func(id string) (user.User, error)
+	UpdateProfileFunc  func(id string, update user.ProfileUpdate) (user.User, error)
+	ChangePasswordFunc func(id, oldPassword, newPassword string) (user.User, error)
+	DeleteUserFunc     func(id, password string) error
 }
 
 func (u *userServiceMock) SignUp(name, password string) (user.User, error) {
 	return u.SignUpFunc(name, password)
 }
 
-func (u *userServiceMock) Login(name, password string) (user.User, error) {
-	return u.LoginFunc(name, password)
+func (u *userServiceMock) Login(name, password, ip string) (user.User, error) {
+	return u.LoginFunc(name, password, ip)
+}
+
+func (u *userServiceMock) GetUser(id string) (user.User, error) {
+	return u.GetUserFunc(id)
+}
+
+func (u *userServiceMock) UpdateProfile(id string, update user.ProfileUpdate) (user.User, error) {
+	return u.UpdateProfileFunc(id, update)
+}
+
+func (u *userServiceMock) ChangePassword(id, oldPassword, newPassword string) (user.User, error) {
+	return u.ChangePasswordFunc(id, oldPassword, newPassword)
+}
+
+func (u *userServiceMock) DeleteUser(id, password string) error {
+	return u.DeleteUserFunc(id, password)
+}
+
+type recoveryServiceMock struct {
+	RequestPasswordResetFunc func(login string) error
+	ResetPasswordFunc        func(token, password string) (user.User, error)
+	SendVerificationFunc     func(userID string) error
+	VerifyEmailFunc          func(token string) (user.User, error)
+}
+
+func (m *recoveryServiceMock) RequestPasswordReset(login string) error {
+	return m.RequestPasswordResetFunc(login)
+}
+
+func (m *recoveryServiceMock) ResetPassword(token, password string) (user.User, error) {
+	return m.ResetPasswordFunc(token, password)
+}
+
+func (m *recoveryServiceMock) SendVerification(userID string) error {
+	return m.SendVerificationFunc(userID)
+}
+
+func (m *recoveryServiceMock) VerifyEmail(token string) (user.User, error) {
+	return m.VerifyEmailFunc(token)
+}
+
+type mfaServiceMock struct {
+	EnrollFunc        func(userID string) (string, string, error)
+	ConfirmFunc       func(userID, code string) ([]string, error)
+	DisableFunc       func(userID, password, code string) error
+	ChallengeFunc     func(u user.User) string
+	CompleteLoginFunc func(challenge, code, ip string) (user.User, error)
+}
+
+func (m *mfaServiceMock) Enroll(userID string) (string, string, error) {
+	return m.EnrollFunc(userID)
+}
+
+func (m *mfaServiceMock) Confirm(userID, code string) ([]string, error) {
+	return m.ConfirmFunc(userID, code)
+}
+
+func (m *mfaServiceMock) Disable(userID, password, code string) error {
+	return m.DisableFunc(userID, password, code)
+}
+
+func (m *mfaServiceMock) Challenge(u user.User) string {
+	return m.ChallengeFunc(u)
+}
+
+func (m *mfaServiceMock) CompleteLogin(challenge, code, ip string) (user.User, error) {
+	return m.CompleteLoginFunc(challenge, code, ip)
+}
+
+type auditLogMock struct {
+	events []audit.Event
+}
+
+func (m *auditLogMock) Record(e audit.Event) {
+	m.events = append(m.events, e)
 }
 
 func TestSignUp(t *testing.T) {
@@ -86,7 +168,7 @@ func TestSignUp(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := gin.Default()
 			logger, _ := zap.NewProduction()
-			r := NewRouter(&tt.userService, logger.Named(""))
+			r := NewRouter(&tt.userService, &recoveryServiceMock{}, &mfaServiceMock{}, &auditLogMock{}, logger.Named(""))
 			r.SetUpRouter(g)
 
 			jsonValue, _ := json.Marshal(tt.Request)
@@ -123,47 +205,80 @@ func TestLogin(t *testing.T) {
 		Request       LoginRequest
 		expectedCode  int
 		expectedError *app.ErrorModel
+		expectedAudit string
 	}{
 		{
 			name: "should return request error",
 			userService: userServiceMock{
-				LoginFunc: func(name, password string) (user.User, error) {
+				LoginFunc: func(name, password, ip string) (user.User, error) {
 					return user.User{}, nil
 				},
 			},
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			name:         "should validate scopes",
+			Request:      LoginRequest{Username: "username", Password: "password123", Scopes: []string{"notes:delete"}},
+			expectedCode: http.StatusBadRequest,
+		},
 		{
 			name:    "should return errUserNotFound",
 			Request: LoginRequest{Username: "username", Password: "password123"},
 			userService: userServiceMock{
-				LoginFunc: func(name, password string) (user.User, error) {
+				LoginFunc: func(name, password, ip string) (user.User, error) {
 					return user.User{}, user.ErrUserNotFound
 				},
 			},
 			expectedCode:  http.StatusNotFound,
 			expectedError: &app.ErrorModel{Error: user.ErrUserNotFound.Error()},
+			expectedAudit: audit.ActionLoginFailed,
 		},
 		{
 			name:    "should return unknown error",
 			Request: LoginRequest{Username: "username", Password: "password123"},
 			userService: userServiceMock{
-				LoginFunc: func(name, password string) (user.User, error) {
+				LoginFunc: func(name, password, ip string) (user.User, error) {
 					return user.User{}, errors.New("something wrong")
 				},
 			},
 			expectedCode:  http.StatusInternalServerError,
 			expectedError: &app.UnknownError,
+			expectedAudit: audit.ActionLoginFailed,
+		},
+		{
+			name:    "should return ErrUserDisabled",
+			Request: LoginRequest{Username: "username", Password: "password123"},
+			userService: userServiceMock{
+				LoginFunc: func(name, password, ip string) (user.User, error) {
+					return user.User{}, user.ErrUserDisabled
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: user.ErrUserDisabled.Error()},
+			expectedAudit: audit.ActionLoginFailed,
+		},
+		{
+			name:    "should return ErrTooManyAttempts",
+			Request: LoginRequest{Username: "username", Password: "password123"},
+			userService: userServiceMock{
+				LoginFunc: func(name, password, ip string) (user.User, error) {
+					return user.User{}, &user.ThrottleError{RetryAfter: 1500 * time.Millisecond}
+				},
+			},
+			expectedCode:  http.StatusTooManyRequests,
+			expectedError: &app.ErrorModel{Error: user.ErrTooManyAttempts.Error()},
+			expectedAudit: audit.ActionLoginFailed,
 		},
 		{
 			name:    "should login user",
 			Request: LoginRequest{Username: "username", Password: "password123"},
 			userService: userServiceMock{
-				LoginFunc: func(name, password string) (user.User, error) {
+				LoginFunc: func(name, password, ip string) (user.User, error) {
 					return user.User{ID: "123-123-123", Username: "user1"}, nil
 				},
 			},
-			expectedCode: http.StatusOK,
+			expectedCode:  http.StatusOK,
+			expectedAudit: audit.ActionLogin,
 		},
 	}
 
@@ -172,15 +287,32 @@ func TestLogin(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := gin.Default()
 			logger, _ := zap.NewProduction()
-			r := NewRouter(&tt.userService, logger.Named(""))
+			auditLog := &auditLogMock{}
+			r := NewRouter(&tt.userService, &recoveryServiceMock{}, &mfaServiceMock{}, auditLog, logger.Named(""))
 			r.SetUpRouter(g)
 
 			jsonValue, _ := json.Marshal(tt.Request)
 			req, _ := http.NewRequest(http.MethodPost, "/user/login", bytes.NewBuffer(jsonValue))
+			req.Header.Set("User-Agent", "test-agent")
 			w := httptest.NewRecorder()
 			g.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedAudit == "" {
+				assert.Empty(t, auditLog.events)
+			} else if assert.Len(t, auditLog.events, 1) {
+				e := auditLog.events[0]
+				assert.Equal(t, tt.expectedAudit, e.Action)
+				assert.Equal(t, "test-agent", e.UserAgent)
+				if tt.expectedAudit == audit.ActionLoginFailed {
+					assert.Equal(t, "username", e.Details["username"])
+				} else {
+					assert.Equal(t, "123-123-123", e.ActorID)
+				}
+			}
+			if tt.expectedCode == http.StatusTooManyRequests {
+				assert.Equal(t, "2", w.Header().Get("Retry-After"))
+			}
 
 			if tt.expectedError != nil {
 				var errorModel app.ErrorModel
@@ -192,3 +324,430 @@ func TestLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestAccountRoutes(t *testing.T) {
+	zone, badZone, badEmail, email := "Europe/Moscow", "Mars/Olympus", "not an email", "user@example.com"
+	profile := user.User{ID: "123-123", Username: "username1", DisplayName: "Name", TimeZone: zone,
+		CreatedAt: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)}
+	withEmail := profile
+	withEmail.Email = email
+	verificationSent := false
+	tests := []struct {
+		name            string
+		userService     userServiceMock
+		recoveryService recoveryServiceMock
+		method          string
+		path            string
+		body            any
+		expectedCode    int
+		expectedError   *app.ErrorModel
+		expectedBody    any
+		expectedToken   bool
+	}{
+		{
+			name:   "should return profile",
+			method: http.MethodGet,
+			path:   "/user/me",
+			userService: userServiceMock{
+				GetUserFunc: func(id string) (user.User, error) {
+					return profile, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: userToProfileResponse(profile),
+		},
+		{
+			name:   "should return ErrUserNotFound",
+			method: http.MethodGet,
+			path:   "/user/me",
+			userService: userServiceMock{
+				GetUserFunc: func(id string) (user.User, error) {
+					return user.User{}, user.ErrUserNotFound
+				},
+			},
+			expectedCode:  http.StatusNotFound,
+			expectedError: &app.ErrorModel{Error: user.ErrUserNotFound.Error()},
+		},
+		{
+			name:         "should validate profile",
+			method:       http.MethodPatch,
+			path:         "/user/me",
+			body:         ProfileRequest{TimeZone: &badZone, Email: &badEmail},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ValidationErrors{Errors: map[string]string{
+				"timeZone": ErrTimeZone.Error(),
+				"email":    ErrEmail.Error(),
+			}},
+		},
+		{
+			name:   "should update profile",
+			method: http.MethodPatch,
+			path:   "/user/me",
+			body:   ProfileRequest{TimeZone: &zone},
+			userService: userServiceMock{
+				UpdateProfileFunc: func(id string, update user.ProfileUpdate) (user.User, error) {
+					if id != "123-123" || update.TimeZone == nil || *update.TimeZone != zone || update.Email != nil {
+						return user.User{}, errors.New("unexpected update")
+					}
+					return profile, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: userToProfileResponse(profile),
+		},
+		{
+			name:   "should return ErrUsedEmail",
+			method: http.MethodPatch,
+			path:   "/user/me",
+			body:   ProfileRequest{Email: &email},
+			userService: userServiceMock{
+				UpdateProfileFunc: func(id string, update user.ProfileUpdate) (user.User, error) {
+					return user.User{}, user.ErrUsedEmail
+				},
+			},
+			expectedCode:  http.StatusConflict,
+			expectedError: &app.ErrorModel{Error: user.ErrUsedEmail.Error()},
+		},
+		{
+			name:   "should return ErrWrongPassword",
+			method: http.MethodPost,
+			path:   "/user/me/password",
+			body:   ChangePasswordRequest{OldPassword: "wrong", NewPassword: "password123"},
+			userService: userServiceMock{
+				ChangePasswordFunc: func(id, oldPassword, newPassword string) (user.User, error) {
+					return user.User{}, user.ErrWrongPassword
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: user.ErrWrongPassword.Error()},
+		},
+		{
+			name:   "should change password",
+			method: http.MethodPost,
+			path:   "/user/me/password",
+			body:   ChangePasswordRequest{OldPassword: "password123", NewPassword: "password456"},
+			userService: userServiceMock{
+				ChangePasswordFunc: func(id, oldPassword, newPassword string) (user.User, error) {
+					return profile, nil
+				},
+			},
+			expectedCode:  http.StatusOK,
+			expectedToken: true,
+		},
+		{
+			name:         "should return ErrPasswordEmpty",
+			method:       http.MethodDelete,
+			path:         "/user/me",
+			body:         DeleteUserRequest{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "should delete user",
+			method: http.MethodDelete,
+			path:   "/user/me",
+			body:   DeleteUserRequest{Password: "password123"},
+			userService: userServiceMock{
+				DeleteUserFunc: func(id, password string) error {
+					if id != "123-123" || password != "password123" {
+						return errors.New("unexpected delete")
+					}
+					return nil
+				},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "should send verification after email change",
+			method: http.MethodPatch,
+			path:   "/user/me",
+			body:   ProfileRequest{Email: &email},
+			userService: userServiceMock{
+				UpdateProfileFunc: func(id string, update user.ProfileUpdate) (user.User, error) {
+					return withEmail, nil
+				},
+			},
+			recoveryService: recoveryServiceMock{
+				SendVerificationFunc: func(userID string) error {
+					verificationSent = true
+					return nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: userToProfileResponse(withEmail),
+		},
+		{
+			name:   "should not tell about unknown login",
+			method: http.MethodPost,
+			path:   "/user/password/forgot",
+			body:   ForgotPasswordRequest{Login: "nobody"},
+			recoveryService: recoveryServiceMock{
+				RequestPasswordResetFunc: func(login string) error {
+					return nil
+				},
+			},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			name:   "should return ErrTokenExpired",
+			method: http.MethodPost,
+			path:   "/user/password/reset",
+			body:   ResetPasswordRequest{Token: "token", Password: "password123"},
+			recoveryService: recoveryServiceMock{
+				ResetPasswordFunc: func(token, password string) (user.User, error) {
+					return user.User{}, user.ErrTokenExpired
+				},
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: user.ErrTokenExpired.Error()},
+		},
+		{
+			name:         "should validate new password",
+			method:       http.MethodPost,
+			path:         "/user/password/reset",
+			body:         ResetPasswordRequest{Token: "token", Password: "short"},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ValidationErrors{Errors: map[string]string{"password": ErrPasswordInvalid.Error()}},
+		},
+		{
+			name:   "should return ErrNoEmail",
+			method: http.MethodPost,
+			path:   "/user/me/email/verification",
+			recoveryService: recoveryServiceMock{
+				SendVerificationFunc: func(userID string) error {
+					return user.ErrNoEmail
+				},
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: &app.ErrorModel{Error: user.ErrNoEmail.Error()},
+		},
+		{
+			name:   "should verify email",
+			method: http.MethodPost,
+			path:   "/user/email/verify",
+			body:   VerifyEmailRequest{Token: "token"},
+			recoveryService: recoveryServiceMock{
+				VerifyEmailFunc: func(token string) (user.User, error) {
+					return withEmail, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: userToProfileResponse(withEmail),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.userService, &tt.recoveryService, &mfaServiceMock{}, &auditLogMock{}, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			var body []byte
+			if tt.body != nil {
+				body, _ = json.Marshal(tt.body)
+			}
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewReader(body))
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedBody != nil {
+				expected, err := json.Marshal(tt.expectedBody)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), w.Body.String())
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+			if tt.expectedToken {
+				var tokenModel app.TokenModel
+				err := json.Unmarshal(w.Body.Bytes(), &tokenModel)
+				assert.NoError(t, err)
+				userID, err := jwt.ParseToken(tokenModel.Token)
+				assert.NoError(t, err)
+				assert.Equal(t, "123-123", userID)
+			}
+		})
+	}
+	assert.True(t, verificationSent)
+}
+
+func TestMFARoutes(t *testing.T) {
+	enabled := user.User{ID: "123-123", Username: "username1", TOTPEnabled: true}
+	tests := []struct {
+		name          string
+		userService   userServiceMock
+		mfaService    mfaServiceMock
+		method        string
+		path          string
+		body          any
+		expectedCode  int
+		expectedError *app.ErrorModel
+		expectedBody  any
+		expectedToken bool
+	}{
+		{
+			name:   "should return challenge instead of token",
+			method: http.MethodPost,
+			path:   "/user/login",
+			body:   LoginRequest{Username: "username1", Password: "password123"},
+			userService: userServiceMock{
+				LoginFunc: func(name, password, ip string) (user.User, error) {
+					return enabled, nil
+				},
+			},
+			mfaService: mfaServiceMock{
+				ChallengeFunc: func(u user.User) string {
+					return "challenge"
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: MFAChallengeResponse{MFARequired: true, Challenge: "challenge"},
+		},
+		{
+			name:         "should validate mfa login",
+			method:       http.MethodPost,
+			path:         "/user/login/mfa",
+			body:         MFALoginRequest{Challenge: "challenge"},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ValidationErrors{Errors: map[string]string{"code": ErrCodeEmpty.Error()}},
+		},
+		{
+			name:   "should return ErrInvalidCode",
+			method: http.MethodPost,
+			path:   "/user/login/mfa",
+			body:   MFALoginRequest{Challenge: "challenge", Code: "000000"},
+			mfaService: mfaServiceMock{
+				CompleteLoginFunc: func(challenge, code, ip string) (user.User, error) {
+					return user.User{}, user.ErrInvalidCode
+				},
+			},
+			expectedCode:  http.StatusForbidden,
+			expectedError: &app.ErrorModel{Error: user.ErrInvalidCode.Error()},
+		},
+		{
+			name:   "should complete login",
+			method: http.MethodPost,
+			path:   "/user/login/mfa",
+			body:   MFALoginRequest{Challenge: "challenge", Code: "123456"},
+			mfaService: mfaServiceMock{
+				CompleteLoginFunc: func(challenge, code, ip string) (user.User, error) {
+					if challenge != "challenge" || code != "123456" {
+						return user.User{}, errors.New("unexpected login")
+					}
+					return enabled, nil
+				},
+			},
+			expectedCode:  http.StatusOK,
+			expectedToken: true,
+		},
+		{
+			name:   "should enroll",
+			method: http.MethodPost,
+			path:   "/user/me/2fa",
+			mfaService: mfaServiceMock{
+				EnrollFunc: func(userID string) (string, string, error) {
+					return "SECRET", "otpauth://totp/x", nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: MFAEnrollResponse{Secret: "SECRET", URI: "otpauth://totp/x"},
+		},
+		{
+			name:   "should return ErrMFAEnabled",
+			method: http.MethodPost,
+			path:   "/user/me/2fa",
+			mfaService: mfaServiceMock{
+				EnrollFunc: func(userID string) (string, string, error) {
+					return "", "", user.ErrMFAEnabled
+				},
+			},
+			expectedCode:  http.StatusConflict,
+			expectedError: &app.ErrorModel{Error: user.ErrMFAEnabled.Error()},
+		},
+		{
+			name:   "should confirm and return recovery codes",
+			method: http.MethodPost,
+			path:   "/user/me/2fa/confirm",
+			body:   MFACodeRequest{Code: "123456"},
+			mfaService: mfaServiceMock{
+				ConfirmFunc: func(userID, code string) ([]string, error) {
+					return []string{"abcde-fghij"}, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: RecoveryCodesResponse{RecoveryCodes: []string{"abcde-fghij"}},
+		},
+		{
+			name:         "should validate disable",
+			method:       http.MethodDelete,
+			path:         "/user/me/2fa",
+			body:         DisableMFARequest{},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: app.ValidationErrors{Errors: map[string]string{
+				"password": ErrPasswordEmpty.Error(),
+				"code":     ErrCodeEmpty.Error(),
+			}},
+		},
+		{
+			name:   "should disable",
+			method: http.MethodDelete,
+			path:   "/user/me/2fa",
+			body:   DisableMFARequest{Password: "password123", Code: "abcde-fghij"},
+			mfaService: mfaServiceMock{
+				DisableFunc: func(userID, password, code string) error {
+					if userID != "123-123" || password != "password123" || code != "abcde-fghij" {
+						return errors.New("unexpected disable")
+					}
+					return nil
+				},
+			},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g := gin.Default()
+			r := NewRouter(&tt.userService, &recoveryServiceMock{}, &tt.mfaService, &auditLogMock{}, zap.NewNop())
+			r.SetUpRouter(g)
+
+			w := httptest.NewRecorder()
+			var body []byte
+			if tt.body != nil {
+				body, _ = json.Marshal(tt.body)
+			}
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewReader(body))
+			token, _ := jwt.CreateToken("123-123")
+			req.Header.Set(app.AccessHeader, token)
+			g.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedBody != nil {
+				expected, err := json.Marshal(tt.expectedBody)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), w.Body.String())
+			}
+			if tt.expectedError != nil {
+				var errorModel app.ErrorModel
+				err := json.Unmarshal(w.Body.Bytes(), &errorModel)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedError, &errorModel)
+			}
+			if tt.expectedToken {
+				var tokenModel app.TokenModel
+				err := json.Unmarshal(w.Body.Bytes(), &tokenModel)
+				assert.NoError(t, err)
+				assert.NotEmpty(t, tokenModel.Token)
+			}
+		})
+	}
+}
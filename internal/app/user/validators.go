@@ -2,7 +2,12 @@ package user
 
 import (
 	"errors"
+	"net/mail"
 	"note-service/internal/app"
+	"note-service/internal/pkg/scope"
+	"regexp"
+	"strings"
+	"time"
 )
 
 var (
@@ -10,8 +15,23 @@ var (
 	ErrPasswordInvalid = errors.New("invalid password")
 	ErrUsernameEmpty   = errors.New("empty username")
 	ErrPasswordEmpty   = errors.New("empty password")
+	ErrProfileEmpty    = errors.New("set at least one of displayName, email, locale, timeZone")
+	ErrDisplayName     = errors.New("displayName must be at most 100 characters")
+	ErrEmail           = errors.New("invalid email")
+	ErrLocale          = errors.New("locale must be a language tag like en or ru-RU")
+	ErrTimeZone        = errors.New("timeZone must be an IANA time zone like Europe/Moscow")
+	ErrLoginEmpty      = errors.New("empty login")
+	ErrTokenEmpty      = errors.New("empty token")
+	ErrChallengeEmpty  = errors.New("empty challenge")
+	ErrCodeEmpty       = errors.New("empty code")
+	ErrScopes          = errors.New("scopes must be of " + strings.Join(scope.Names, ", "))
 )
 
+// maxDisplayName is the limit of displayName in characters
+const maxDisplayName = 100
+
+var localeTag = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
 func (r LoginRequest) Validate() error {
 	ve := app.NewValidationErrors()
 	if len(r.Username) == 0 {
@@ -20,12 +40,21 @@ func (r LoginRequest) Validate() error {
 	if len(r.Password) == 0 {
 		ve.Errors["password"] = ErrPasswordEmpty.Error()
 	}
+	validateScopes(ve, r.Scopes)
 	if len(ve.Errors) == 0 {
 		return nil
 	}
 	return ve
 }
 
+func validateScopes(ve app.ValidationErrors, scopes []string) {
+	for _, name := range scopes {
+		if !scope.Valid(name) {
+			ve.Errors["scopes"] = ErrScopes.Error()
+		}
+	}
+}
+
 func (r SignUpRequest) Validate() error {
 	ve := app.NewValidationErrors()
 	if len(r.Username) < 4 {
@@ -39,3 +68,131 @@ func (r SignUpRequest) Validate() error {
 	}
 	return ve
 }
+
+func (r ProfileRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if r.DisplayName == nil && r.Email == nil && r.Locale == nil && r.TimeZone == nil {
+		ve.Errors["profile"] = ErrProfileEmpty.Error()
+	}
+	if r.DisplayName != nil && len([]rune(*r.DisplayName)) > maxDisplayName {
+		ve.Errors["displayName"] = ErrDisplayName.Error()
+	}
+	if r.Email != nil && *r.Email != "" {
+		if address, err := mail.ParseAddress(*r.Email); err != nil || address.Address != *r.Email {
+			ve.Errors["email"] = ErrEmail.Error()
+		}
+	}
+	if r.Locale != nil && *r.Locale != "" && !localeTag.MatchString(*r.Locale) {
+		ve.Errors["locale"] = ErrLocale.Error()
+	}
+	if r.TimeZone != nil && *r.TimeZone != "" {
+		if _, err := time.LoadLocation(*r.TimeZone); err != nil {
+			ve.Errors["timeZone"] = ErrTimeZone.Error()
+		}
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r ChangePasswordRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.OldPassword) == 0 {
+		ve.Errors["oldPassword"] = ErrPasswordEmpty.Error()
+	}
+	if len(r.NewPassword) < 10 {
+		ve.Errors["newPassword"] = ErrPasswordInvalid.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r DeleteUserRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Password) == 0 {
+		ve.Errors["password"] = ErrPasswordEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r ForgotPasswordRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Login) == 0 {
+		ve.Errors["login"] = ErrLoginEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r ResetPasswordRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Token) == 0 {
+		ve.Errors["token"] = ErrTokenEmpty.Error()
+	}
+	if len(r.Password) < 10 {
+		ve.Errors["password"] = ErrPasswordInvalid.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r VerifyEmailRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Token) == 0 {
+		ve.Errors["token"] = ErrTokenEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r MFALoginRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Challenge) == 0 {
+		ve.Errors["challenge"] = ErrChallengeEmpty.Error()
+	}
+	if len(r.Code) == 0 {
+		ve.Errors["code"] = ErrCodeEmpty.Error()
+	}
+	validateScopes(ve, r.Scopes)
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r MFACodeRequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Code) == 0 {
+		ve.Errors["code"] = ErrCodeEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
+
+func (r DisableMFARequest) Validate() error {
+	ve := app.NewValidationErrors()
+	if len(r.Password) == 0 {
+		ve.Errors["password"] = ErrPasswordEmpty.Error()
+	}
+	if len(r.Code) == 0 {
+		ve.Errors["code"] = ErrCodeEmpty.Error()
+	}
+	if len(ve.Errors) == 0 {
+		return nil
+	}
+	return ve
+}
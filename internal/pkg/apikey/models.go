@@ -0,0 +1,29 @@
+package apikey
+
+import (
+	"errors"
+	"time"
+)
+
+// Prefix starts every key, so a key is easy to tell from a jwt-token and to find in leaked text
+const Prefix = "nsk_"
+
+// Key is a personal API key, only the hash of the secret is kept. A key without scopes has full access.
+type Key struct {
+	ID         string
+	UserID     string
+	Name       string
+	Hint       string
+	Hash       string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+var (
+	ErrKeyNotFound = errors.New("api key not found")
+	ErrKeyExpired  = errors.New("api key expired")
+	ErrInvalidKey  = errors.New("invalid api key")
+	ErrScope       = errors.New("unknown scope")
+)
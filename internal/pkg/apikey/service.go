@@ -0,0 +1,103 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"note-service/internal/pkg/scope"
+	"note-service/internal/pkg/user"
+	"strings"
+	"time"
+)
+
+type store interface {
+	CreateKey(key Key) (Key, error)
+	FindKeyByID(id string) (Key, error)
+	FindKeyByHash(hash string) (Key, error)
+	GetUserKeys(userID string) ([]Key, error)
+	TouchKey(id string, usedAt time.Time)
+	DeleteKey(id string) error
+}
+
+type userFinder interface {
+	FindUserByID(id string) (user.User, error)
+}
+
+type Service struct {
+	store store
+	users userFinder
+}
+
+func NewService(store store, users userFinder) *Service {
+	return &Service{store: store, users: users}
+}
+
+// CreateKey returns the key with its secret, the secret can't be read again later
+func (s *Service) CreateKey(userID, name string, scopes []string, expiresAt *time.Time) (Key, string, error) {
+	for _, name := range scopes {
+		if !scope.Valid(name) {
+			return Key{}, "", ErrScope
+		}
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return Key{}, "", err
+	}
+	secret := Prefix + base64.RawURLEncoding.EncodeToString(raw)
+	key, err := s.store.CreateKey(Key{
+		UserID:    userID,
+		Name:      name,
+		Hint:      secret[len(secret)-4:],
+		Hash:      hashSecret(secret),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return Key{}, "", err
+	}
+	return key, secret, nil
+}
+
+func (s *Service) GetUserKeys(userID string) ([]Key, error) {
+	return s.store.GetUserKeys(userID)
+}
+
+// RevokeKey deletes a key of the user, keys of other users look like missing ones
+func (s *Service) RevokeKey(id, userID string) error {
+	k, err := s.store.FindKeyByID(id)
+	if err != nil {
+		return err
+	}
+	if k.UserID != userID {
+		return ErrKeyNotFound
+	}
+	return s.store.DeleteKey(id)
+}
+
+// Authenticate finds the key by its secret and marks it used, keys of disabled users don't work
+func (s *Service) Authenticate(secret string) (Key, error) {
+	if !strings.HasPrefix(secret, Prefix) {
+		return Key{}, ErrInvalidKey
+	}
+	k, err := s.store.FindKeyByHash(hashSecret(secret))
+	if err != nil {
+		return Key{}, ErrInvalidKey
+	}
+	now := time.Now().UTC()
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(now) {
+		return Key{}, ErrKeyExpired
+	}
+	if u, err := s.users.FindUserByID(k.UserID); err != nil || u.Disabled {
+		return Key{}, ErrInvalidKey
+	}
+	s.store.TouchKey(k.ID, now)
+	k.LastUsedAt = &now
+	return k, nil
+}
+
+// hashSecret is a fast hash, secrets are random and long, so they can't be guessed from it
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+package apikey
+
+import (
+	"note-service/internal/pkg/scope"
+	"note-service/internal/pkg/user"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type userFinderMock map[string]user.User
+
+func (m userFinderMock) FindUserByID(id string) (user.User, error) {
+	if u, ok := m[id]; ok {
+		return u, nil
+	}
+	return user.User{}, user.ErrUserNotFound
+}
+
+var owners = userFinderMock{
+	"owner":    {ID: "owner"},
+	"disabled": {ID: "disabled", Disabled: true},
+}
+
+func TestCreateKey(t *testing.T) {
+	s := NewService(NewInMemoryStore(), owners)
+
+	_, _, err := s.CreateKey("owner", "ci", []string{"notes:delete"}, nil)
+	require.ErrorIs(t, err, ErrScope)
+
+	key, secret, err := s.CreateKey("owner", "ci", []string{scope.NotesRead}, nil)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret, Prefix))
+	require.True(t, strings.HasSuffix(secret, key.Hint))
+	require.NotContains(t, key.Hash, secret)
+	require.Nil(t, key.LastUsedAt)
+
+	keys, err := s.GetUserKeys("owner")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, key.ID, keys[0].ID)
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := NewService(NewInMemoryStore(), owners)
+	past := time.Now().Add(-time.Minute)
+	_, expired, err := s.CreateKey("owner", "old", nil, &past)
+	require.NoError(t, err)
+	key, secret, err := s.CreateKey("owner", "ci", nil, nil)
+	require.NoError(t, err)
+
+	_, err = s.Authenticate(expired)
+	require.ErrorIs(t, err, ErrKeyExpired)
+	_, err = s.Authenticate(Prefix + "unknown")
+	require.ErrorIs(t, err, ErrInvalidKey)
+	_, disabled, err := s.CreateKey("disabled", "ci", nil, nil)
+	require.NoError(t, err)
+	_, err = s.Authenticate(disabled)
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	got, err := s.Authenticate(secret)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, got.ID)
+	stored, err := s.store.FindKeyByID(key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.LastUsedAt)
+}
+
+func TestRevokeKey(t *testing.T) {
+	s := NewService(NewInMemoryStore(), owners)
+	key, secret, err := s.CreateKey("owner", "ci", nil, nil)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, s.RevokeKey(key.ID, "stranger"), ErrKeyNotFound)
+	require.NoError(t, s.RevokeKey(key.ID, "owner"))
+	require.ErrorIs(t, s.RevokeKey(key.ID, "owner"), ErrKeyNotFound)
+
+	_, err = s.Authenticate(secret)
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -0,0 +1,105 @@
+package apikey
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// keys map[keyId]Key, hashes map[hash]keyId
+type InMemoryStore struct {
+	sync.RWMutex
+	keys   map[string]Key
+	hashes map[string]string
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{keys: make(map[string]Key), hashes: make(map[string]string)}
+}
+
+func (store *InMemoryStore) CreateKey(key Key) (Key, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	key.ID = uuid.NewString()
+	key.CreatedAt = time.Now().UTC()
+	store.keys[key.ID] = key
+	store.hashes[key.Hash] = key.ID
+	return key, nil
+}
+
+func (store *InMemoryStore) FindKeyByID(id string) (Key, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	if k, ok := store.keys[id]; ok {
+		return k, nil
+	}
+	return Key{}, ErrKeyNotFound
+}
+
+func (store *InMemoryStore) FindKeyByHash(hash string) (Key, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	if id, ok := store.hashes[hash]; ok {
+		return store.keys[id], nil
+	}
+	return Key{}, ErrKeyNotFound
+}
+
+// GetUserKeys returns keys of the user, newest first
+func (store *InMemoryStore) GetUserKeys(userID string) ([]Key, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	res := make([]Key, 0)
+	for _, k := range store.keys {
+		if k.UserID == userID {
+			res = append(res, k)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.After(res[j].CreatedAt)
+	})
+	return res, nil
+}
+
+// TouchKey sets the last use of the key
+func (store *InMemoryStore) TouchKey(id string, usedAt time.Time) {
+	store.Lock()
+	defer store.Unlock()
+
+	if k, ok := store.keys[id]; ok {
+		k.LastUsedAt = &usedAt
+		store.keys[id] = k
+	}
+}
+
+func (store *InMemoryStore) DeleteKey(id string) error {
+	store.Lock()
+	defer store.Unlock()
+
+	k, ok := store.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	delete(store.keys, id)
+	delete(store.hashes, k.Hash)
+	return nil
+}
+
+// DeleteUserKeys removes every key of a deleted user
+func (store *InMemoryStore) DeleteUserKeys(userID string) {
+	store.Lock()
+	defer store.Unlock()
+
+	for id, k := range store.keys {
+		if k.UserID == userID {
+			delete(store.keys, id)
+			delete(store.hashes, k.Hash)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package attachment
+
+import (
+	"errors"
+	"time"
+)
+
+type Attachment struct {
+	ID          string
+	NoteID      string
+	UserID      string
+	Name        string
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+}
+
+// Key is where the attachment content lives in the blob store
+func (a Attachment) Key() string {
+	return a.UserID + "/" + a.NoteID + "/" + a.ID
+}
+
+// Limits are sizes in bytes, Quota is the total size of all attachments of one user
+type Limits struct {
+	MaxSize int64
+	Quota   int64
+}
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrTooLarge           = errors.New("attachment is too large")
+	ErrQuotaExceeded      = errors.New("attachment quota exceeded")
+)
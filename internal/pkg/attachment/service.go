@@ -0,0 +1,133 @@
+package attachment
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"note-service/internal/app"
+	"note-service/internal/pkg/blob"
+	"note-service/internal/pkg/note"
+	"path"
+)
+
+// sniffLen is how many bytes http.DetectContentType looks at
+const sniffLen = 512
+
+type store interface {
+	CreateAttachment(attachment Attachment, quota int64) (Attachment, error)
+	FindAttachmentByID(id string) (Attachment, error)
+	GetNoteAttachments(noteID string) ([]Attachment, error)
+	DeleteAttachment(id string) error
+	DeleteNoteAttachments(noteID string) ([]Attachment, error)
+}
+
+type noteFinder interface {
+	FindNoteByID(id, userID string) (note.Note, error)
+}
+
+type Service struct {
+	store  store
+	blobs  blob.Store
+	notes  noteFinder
+	limits Limits
+}
+
+func NewService(store store, blobs blob.Store, notes noteFinder, limits Limits) *Service {
+	return &Service{store: store, blobs: blobs, notes: notes, limits: limits}
+}
+
+// Upload attaches content to a note of the user. Content type is sniffed from the data,
+// whatever the client claims.
+func (s *Service) Upload(noteID, userID, name string, r io.Reader, size int64) (Attachment, error) {
+	n, err := s.notes.FindNoteByID(noteID, userID)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if n.UserID != userID {
+		return Attachment{}, app.ErrNoAccess
+	}
+	if size > s.limits.MaxSize {
+		return Attachment{}, ErrTooLarge
+	}
+
+	head := make([]byte, sniffLen)
+	read, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return Attachment{}, err
+	}
+	head = head[:read]
+
+	a, err := s.store.CreateAttachment(Attachment{
+		NoteID:      noteID,
+		UserID:      userID,
+		Name:        path.Base("/" + name),
+		ContentType: http.DetectContentType(head),
+		Size:        size,
+	}, s.limits.Quota)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if err := s.blobs.Put(a.Key(), io.MultiReader(bytes.NewReader(head), r), size); err != nil {
+		_ = s.store.DeleteAttachment(a.ID)
+		return Attachment{}, err
+	}
+	return a, nil
+}
+
+// GetNoteAttachments lists attachments of any note the user can see
+func (s *Service) GetNoteAttachments(noteID, userID string) ([]Attachment, error) {
+	if _, err := s.notes.FindNoteByID(noteID, userID); err != nil {
+		return nil, err
+	}
+	return s.store.GetNoteAttachments(noteID)
+}
+
+// Open returns attachment content if the user can see its note, the caller closes it
+func (s *Service) Open(id, userID string) (Attachment, io.ReadCloser, error) {
+	a, err := s.store.FindAttachmentByID(id)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	if _, err := s.notes.FindNoteByID(a.NoteID, userID); err != nil {
+		return Attachment{}, nil, err
+	}
+	r, err := s.blobs.Get(a.Key())
+	if errors.Is(err, blob.ErrBlobNotFound) {
+		return Attachment{}, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	return a, r, nil
+}
+
+func (s *Service) DeleteAttachment(id, userID string) error {
+	a, err := s.store.FindAttachmentByID(id)
+	if err != nil {
+		return err
+	}
+	if a.UserID != userID {
+		return app.ErrNoAccess
+	}
+	if err := s.store.DeleteAttachment(id); err != nil {
+		return err
+	}
+	return s.blobs.Delete(a.Key())
+}
+
+// DeleteNoteAttachments removes attachments of a deleted or expired note
+func (s *Service) DeleteNoteAttachments(noteID string) error {
+	removed, err := s.store.DeleteNoteAttachments(noteID)
+	if err != nil {
+		return err
+	}
+	// keep deleting the rest of blobs when one fails
+	var firstErr error
+	for _, a := range removed {
+		if err := s.blobs.Delete(a.Key()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
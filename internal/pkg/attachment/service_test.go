@@ -0,0 +1,167 @@
+package attachment
+
+import (
+	"io"
+	"note-service/internal/app"
+	"note-service/internal/pkg/blob"
+	"note-service/internal/pkg/note"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type noteFinderMock struct {
+	FindNoteByIDFunc func(id, userID string) (note.Note, error)
+}
+
+func (n *noteFinderMock) FindNoteByID(id, userID string) (note.Note, error) {
+	return n.FindNoteByIDFunc(id, userID)
+}
+
+// sharedNote belongs to owner and is shared with reader
+var sharedNote = &noteFinderMock{FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+	switch {
+	case id != "note":
+		return note.Note{}, note.ErrNoteNotFound
+	case userID != "owner" && userID != "reader":
+		return note.Note{}, app.ErrNoAccess
+	}
+	return note.Note{ID: id, UserID: "owner"}, nil
+}}
+
+func newTestService(t *testing.T, limits Limits) (*Service, *InMemoryStore, blob.Store) {
+	store := NewInMemoryStore()
+	blobs := blob.NewFS(t.TempDir())
+	return NewService(store, blobs, sharedNote, limits), store, blobs
+}
+
+func TestServiceUpload(t *testing.T) {
+	tests := []struct {
+		name          string
+		noteID        string
+		userID        string
+		content       string
+		limits        Limits
+		expectedType  string
+		expectedError error
+	}{
+		{
+			name:         "should sniff png",
+			noteID:       "note",
+			userID:       "owner",
+			content:      "\x89PNG\x0D\x0A\x1A\x0A" + strings.Repeat("0", 600),
+			limits:       Limits{MaxSize: 1000, Quota: 1000},
+			expectedType: "image/png",
+		},
+		{
+			name:         "should sniff short text",
+			noteID:       "note",
+			userID:       "owner",
+			content:      "hello",
+			limits:       Limits{MaxSize: 1000, Quota: 1000},
+			expectedType: "text/plain; charset=utf-8",
+		},
+		{
+			name:          "should return ErrNoAccess for reader",
+			noteID:        "note",
+			userID:        "reader",
+			content:       "hello",
+			limits:        Limits{MaxSize: 1000, Quota: 1000},
+			expectedError: app.ErrNoAccess,
+		},
+		{
+			name:          "should return ErrNoteNotFound",
+			noteID:        "unknown",
+			userID:        "owner",
+			content:       "hello",
+			limits:        Limits{MaxSize: 1000, Quota: 1000},
+			expectedError: note.ErrNoteNotFound,
+		},
+		{
+			name:          "should return ErrTooLarge",
+			noteID:        "note",
+			userID:        "owner",
+			content:       "hello",
+			limits:        Limits{MaxSize: 4, Quota: 1000},
+			expectedError: ErrTooLarge,
+		},
+		{
+			name:          "should return ErrQuotaExceeded",
+			noteID:        "note",
+			userID:        "owner",
+			content:       "hello",
+			limits:        Limits{MaxSize: 1000, Quota: 4},
+			expectedError: ErrQuotaExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, _ := newTestService(t, tt.limits)
+			a, err := service.Upload(tt.noteID, tt.userID, "../dir/file", strings.NewReader(tt.content), int64(len(tt.content)))
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedType, a.ContentType)
+			require.Equal(t, "file", a.Name)
+
+			_, r, err := service.Open(a.ID, tt.userID)
+			require.NoError(t, err)
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.Equal(t, tt.content, string(data))
+		})
+	}
+}
+
+func TestServiceQuota(t *testing.T) {
+	service, store, _ := newTestService(t, Limits{MaxSize: 10, Quota: 10})
+	a, err := service.Upload("note", "owner", "a", strings.NewReader("123456"), 6)
+	require.NoError(t, err)
+	_, err = service.Upload("note", "owner", "b", strings.NewReader("123456"), 6)
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+
+	require.NoError(t, service.DeleteAttachment(a.ID, "owner"))
+	require.Equal(t, int64(0), store.Usage("owner"))
+	_, err = service.Upload("note", "owner", "b", strings.NewReader("123456"), 6)
+	require.NoError(t, err)
+}
+
+func TestServiceOpen(t *testing.T) {
+	service, _, _ := newTestService(t, Limits{MaxSize: 10, Quota: 10})
+	a, err := service.Upload("note", "owner", "a", strings.NewReader("hello"), 5)
+	require.NoError(t, err)
+
+	_, r, err := service.Open(a.ID, "reader")
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	_, _, err = service.Open(a.ID, "stranger")
+	require.ErrorIs(t, err, app.ErrNoAccess)
+
+	_, _, err = service.Open("unknown", "owner")
+	require.ErrorIs(t, err, ErrAttachmentNotFound)
+
+	err = service.DeleteAttachment(a.ID, "reader")
+	require.ErrorIs(t, err, app.ErrNoAccess)
+}
+
+func TestServiceDeleteNoteAttachments(t *testing.T) {
+	service, store, blobs := newTestService(t, Limits{MaxSize: 10, Quota: 10})
+	a, err := service.Upload("note", "owner", "a", strings.NewReader("hello"), 5)
+	require.NoError(t, err)
+
+	require.NoError(t, service.DeleteNoteAttachments("note"))
+
+	attachments, err := store.GetNoteAttachments("note")
+	require.NoError(t, err)
+	require.Empty(t, attachments)
+	require.Equal(t, int64(0), store.Usage("owner"))
+	_, err = blobs.Get(a.Key())
+	require.ErrorIs(t, err, blob.ErrBlobNotFound)
+}
@@ -0,0 +1,109 @@
+package attachment
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// attachments map[attachmentId]Attachment
+// usage map[userId] total size of user attachments
+type InMemoryStore struct {
+	sync.RWMutex
+	attachments map[string]Attachment
+	usage       map[string]int64
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		attachments: make(map[string]Attachment),
+		usage:       make(map[string]int64),
+	}
+}
+
+// CreateAttachment saves attachment if it fits into the user quota
+func (store *InMemoryStore) CreateAttachment(attachment Attachment, quota int64) (Attachment, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	if store.usage[attachment.UserID]+attachment.Size > quota {
+		return Attachment{}, ErrQuotaExceeded
+	}
+	attachment.ID = uuid.NewString()
+	attachment.CreatedAt = time.Now().UTC()
+	store.attachments[attachment.ID] = attachment
+	store.usage[attachment.UserID] += attachment.Size
+	return attachment, nil
+}
+
+func (store *InMemoryStore) FindAttachmentByID(id string) (Attachment, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	if a, ok := store.attachments[id]; ok {
+		return a, nil
+	}
+	return Attachment{}, ErrAttachmentNotFound
+}
+
+// GetNoteAttachments returns attachments of the note in upload order
+func (store *InMemoryStore) GetNoteAttachments(noteID string) ([]Attachment, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	res := make([]Attachment, 0)
+	for _, a := range store.attachments {
+		if a.NoteID == noteID {
+			res = append(res, a)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+	return res, nil
+}
+
+func (store *InMemoryStore) DeleteAttachment(id string) error {
+	store.Lock()
+	defer store.Unlock()
+
+	a, ok := store.attachments[id]
+	if !ok {
+		return ErrAttachmentNotFound
+	}
+	store.remove(a)
+	return nil
+}
+
+// DeleteNoteAttachments removes all attachments of the note and returns them
+func (store *InMemoryStore) DeleteNoteAttachments(noteID string) ([]Attachment, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	var removed []Attachment
+	for _, a := range store.attachments {
+		if a.NoteID == noteID {
+			store.remove(a)
+			removed = append(removed, a)
+		}
+	}
+	return removed, nil
+}
+
+func (store *InMemoryStore) Usage(userID string) int64 {
+	store.RLock()
+	defer store.RUnlock()
+
+	return store.usage[userID]
+}
+
+// remove isn't thread-safe
+func (store *InMemoryStore) remove(a Attachment) {
+	delete(store.attachments, a.ID)
+	store.usage[a.UserID] -= a.Size
+	if store.usage[a.UserID] <= 0 {
+		delete(store.usage, a.UserID)
+	}
+}
@@ -0,0 +1,91 @@
+package audit
+
+import "time"
+
+const (
+	ActionSignUp        = "user.signup"
+	ActionLogin         = "user.login"
+	ActionLoginFailed   = "user.login_failed"
+	ActionTokensRevoked = "user.tokens_revoked"
+	ActionUserDelete    = "user.delete"
+	ActionMFAEnable     = "user.mfa_enable"
+	ActionMFADisable    = "user.mfa_disable"
+	ActionIdentityLink  = "user.identity_link"
+	ActionUserDisable   = "user.disable"
+	ActionUserEnable    = "user.enable"
+	ActionUserRole      = "user.role"
+	ActionUserUnlock    = "user.unlock"
+	ActionPasswordReset = "user.password_reset"
+
+	ActionNoteCreate   = "note.create"
+	ActionNoteUpdate   = "note.update"
+	ActionNoteDelete   = "note.delete"
+	ActionNoteShare    = "note.share"
+	ActionNoteExpire   = "note.expire"
+	ActionNoteTakeDown = "note.take_down"
+
+	TargetUser = "user"
+	TargetNote = "note"
+
+	// ActorSystem does what no user asked for, like deleting expired notes
+	ActorSystem = "system"
+)
+
+// Event is one record of the audit log, events are never changed or deleted.
+// OwnerID is the user whose data the event is about, owners can read events of their notes.
+type Event struct {
+	ID         string
+	Time       time.Time
+	Action     string
+	ActorID    string
+	IP         string
+	UserAgent  string
+	RequestID  string
+	TargetType string
+	TargetID   string
+	OwnerID    string
+	Details    map[string]string
+}
+
+// On sets the target of the event and the user who owns it
+func (e Event) On(targetType, targetID, ownerID string) Event {
+	e.TargetType, e.TargetID, e.OwnerID = targetType, targetID, ownerID
+	return e
+}
+
+// With returns the event with one more detail, details of e aren't changed
+func (e Event) With(key, value string) Event {
+	details := make(map[string]string, len(e.Details)+1)
+	for k, v := range e.Details {
+		details[k] = v
+	}
+	details[key] = value
+	e.Details = details
+	return e
+}
+
+// Filter selects events, empty fields match everything. Limit keeps only the newest events.
+type Filter struct {
+	ActorID    string
+	OwnerID    string
+	TargetType string
+	TargetID   string
+	Action     string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+func (f Filter) Match(e Event) bool {
+	switch {
+	case f.ActorID != "" && e.ActorID != f.ActorID,
+		f.OwnerID != "" && e.OwnerID != f.OwnerID,
+		f.TargetType != "" && e.TargetType != f.TargetType,
+		f.TargetID != "" && e.TargetID != f.TargetID,
+		f.Action != "" && e.Action != f.Action,
+		!f.Since.IsZero() && e.Time.Before(f.Since),
+		!f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package audit
+
+import "go.uber.org/zap"
+
+type store interface {
+	Append(e Event) (Event, error)
+	Query(f Filter) ([]Event, error)
+}
+
+// Service appends events to the store and copies them to the logger, so they outlive the store
+type Service struct {
+	store  store
+	logger *zap.Logger
+}
+
+func NewService(store store, logger *zap.Logger) *Service {
+	return &Service{store: store, logger: logger}
+}
+
+// Record never fails the action it is called for, a lost event is logged instead
+func (s *Service) Record(e Event) {
+	stored, err := s.store.Append(e)
+	if err != nil {
+		s.logger.Error("failed to record audit event", zap.String("action", e.Action), zap.Error(err))
+		return
+	}
+	e = stored
+	s.logger.Info(e.Action,
+		zap.String("id", e.ID),
+		zap.String("actor", e.ActorID),
+		zap.String("targetType", e.TargetType),
+		zap.String("target", e.TargetID),
+		zap.String("owner", e.OwnerID),
+		zap.String("ip", e.IP),
+		zap.String("requestId", e.RequestID),
+		zap.Any("details", e.Details))
+}
+
+func (s *Service) Query(f Filter) ([]Event, error) {
+	return s.store.Query(f)
+}
@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InMemoryStore keeps events in the order they were appended, there is no way to change or remove them
+type InMemoryStore struct {
+	sync.RWMutex
+	events []Event
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{}
+}
+
+func (store *InMemoryStore) Append(e Event) (Event, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	e.ID = uuid.NewString()
+	e.Time = time.Now().UTC()
+	if e.Details != nil {
+		details := make(map[string]string, len(e.Details))
+		for k, v := range e.Details {
+			details[k] = v
+		}
+		e.Details = details
+	}
+	store.events = append(store.events, e)
+	return e, nil
+}
+
+// Query returns matching events, newest first
+func (store *InMemoryStore) Query(f Filter) ([]Event, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	res := make([]Event, 0)
+	for i := len(store.events) - 1; i >= 0; i-- {
+		if f.Limit > 0 && len(res) == f.Limit {
+			break
+		}
+		if f.Match(store.events[i]) {
+			res = append(res, store.events[i])
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	store := NewInMemoryStore()
+	start := time.Now().UTC()
+	_, _ = store.Append(Event{Action: ActionSignUp, ActorID: "u1", TargetType: TargetUser, TargetID: "u1", OwnerID: "u1"})
+	_, _ = store.Append(Event{Action: ActionNoteCreate, ActorID: "u1", TargetType: TargetNote, TargetID: "n1", OwnerID: "u1"})
+	_, _ = store.Append(Event{Action: ActionNoteUpdate, ActorID: "u1", TargetType: TargetNote, TargetID: "n1", OwnerID: "u1"})
+	_, _ = store.Append(Event{Action: ActionNoteTakeDown, ActorID: "admin", TargetType: TargetNote, TargetID: "n1", OwnerID: "u1"})
+	_, _ = store.Append(Event{Action: ActionNoteCreate, ActorID: "u2", TargetType: TargetNote, TargetID: "n2", OwnerID: "u2"})
+
+	actions := func(events []Event) []string {
+		res := make([]string, 0, len(events))
+		for _, e := range events {
+			res = append(res, e.Action)
+		}
+		return res
+	}
+
+	t.Run("newest first", func(t *testing.T) {
+		events, err := store.Query(Filter{TargetID: "n1"})
+		require.NoError(t, err)
+		require.Equal(t, []string{ActionNoteTakeDown, ActionNoteUpdate, ActionNoteCreate}, actions(events))
+		require.NotEmpty(t, events[0].ID)
+		require.False(t, events[0].Time.Before(start))
+	})
+	t.Run("filters", func(t *testing.T) {
+		events, err := store.Query(Filter{OwnerID: "u1", ActorID: "admin"})
+		require.NoError(t, err)
+		require.Equal(t, []string{ActionNoteTakeDown}, actions(events))
+
+		events, err = store.Query(Filter{OwnerID: "u1", TargetType: TargetNote})
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+
+		events, err = store.Query(Filter{Action: ActionNoteCreate})
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+
+		events, err = store.Query(Filter{Until: start})
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+	t.Run("limit keeps newest", func(t *testing.T) {
+		events, err := store.Query(Filter{OwnerID: "u1", Limit: 2})
+		require.NoError(t, err)
+		require.Equal(t, []string{ActionNoteTakeDown, ActionNoteUpdate}, actions(events))
+	})
+	t.Run("details are copied", func(t *testing.T) {
+		details := map[string]string{"reason": "spam"}
+		e, err := store.Append(Event{Action: ActionNoteTakeDown, Details: details})
+		require.NoError(t, err)
+		details["reason"] = "changed"
+		require.Equal(t, "spam", e.Details["reason"])
+	})
+}
@@ -0,0 +1,15 @@
+package blob
+
+import (
+	"errors"
+	"io"
+)
+
+// Store keeps opaque blobs by key. Keys are slash separated, e.g. userId/noteId/attachmentId.
+type Store interface {
+	Put(key string, r io.Reader, size int64) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+var ErrBlobNotFound = errors.New("blob not found")
@@ -0,0 +1,31 @@
+package blob
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	store := NewFS(t.TempDir())
+
+	err := store.Put("user/note/1", strings.NewReader("hello"), 5)
+	require.NoError(t, err)
+
+	r, err := store.Get("user/note/1")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete("user/note/1"))
+	_, err = store.Get("user/note/1")
+	require.ErrorIs(t, err, ErrBlobNotFound)
+	require.NoError(t, store.Delete("user/note/1"))
+
+	require.Error(t, store.Put("../escape", strings.NewReader("hello"), 5))
+	require.Error(t, store.Put("user/note/2", strings.NewReader("hello"), 4))
+}
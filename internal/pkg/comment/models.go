@@ -0,0 +1,22 @@
+package comment
+
+import (
+	"errors"
+	"time"
+)
+
+// Comment is a top level comment on a note or a reply when ParentID is set
+type Comment struct {
+	ID        string
+	NoteID    string
+	UserID    string
+	ParentID  string
+	Text      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrParentNotFound  = errors.New("parent comment not found on this note")
+)
@@ -0,0 +1,74 @@
+package comment
+
+import (
+	"note-service/internal/app"
+	"note-service/internal/pkg/note"
+)
+
+type store interface {
+	CreateComment(comment Comment) (Comment, error)
+	FindCommentByID(id string) (Comment, error)
+	GetNoteComments(noteID string) ([]Comment, error)
+	UpdateComment(comment Comment) (Comment, error)
+	DeleteComment(id string) error
+}
+
+type noteFinder interface {
+	FindNoteByID(id, userID string) (note.Note, error)
+}
+
+// Service lets everyone who can see a note discuss it
+type Service struct {
+	store store
+	notes noteFinder
+}
+
+func NewService(store store, notes noteFinder) *Service {
+	return &Service{store: store, notes: notes}
+}
+
+func (s *Service) CreateComment(comment Comment) (Comment, error) {
+	if _, err := s.notes.FindNoteByID(comment.NoteID, comment.UserID); err != nil {
+		return Comment{}, err
+	}
+	return s.store.CreateComment(comment)
+}
+
+func (s *Service) GetNoteComments(noteID, userID string) ([]Comment, error) {
+	if _, err := s.notes.FindNoteByID(noteID, userID); err != nil {
+		return nil, err
+	}
+	return s.store.GetNoteComments(noteID)
+}
+
+// UpdateComment changes text of a comment, only its author can do it while the note is still visible to them
+func (s *Service) UpdateComment(id, userID, text string) (Comment, error) {
+	c, err := s.store.FindCommentByID(id)
+	if err != nil {
+		return Comment{}, err
+	}
+	if _, err := s.notes.FindNoteByID(c.NoteID, userID); err != nil {
+		return Comment{}, err
+	}
+	if c.UserID != userID {
+		return Comment{}, app.ErrNoAccess
+	}
+	c.Text = text
+	return s.store.UpdateComment(c)
+}
+
+// DeleteComment removes a comment with its replies, the author or the note owner can do it
+func (s *Service) DeleteComment(id, userID string) error {
+	c, err := s.store.FindCommentByID(id)
+	if err != nil {
+		return err
+	}
+	n, err := s.notes.FindNoteByID(c.NoteID, userID)
+	if err != nil {
+		return err
+	}
+	if c.UserID != userID && n.UserID != userID {
+		return app.ErrNoAccess
+	}
+	return s.store.DeleteComment(id)
+}
@@ -0,0 +1,133 @@
+package comment
+
+import (
+	"github.com/stretchr/testify/require"
+	"note-service/internal/app"
+	"note-service/internal/pkg/note"
+	"testing"
+)
+
+type noteFinderMock struct {
+	FindNoteByIDFunc func(id, userID string) (note.Note, error)
+}
+
+func (n *noteFinderMock) FindNoteByID(id, userID string) (note.Note, error) {
+	return n.FindNoteByIDFunc(id, userID)
+}
+
+// sharedNote belongs to owner and is shared with reader and writer
+var sharedNote = &noteFinderMock{FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+	switch {
+	case id != "note":
+		return note.Note{}, note.ErrNoteNotFound
+	case userID != "owner" && userID != "reader" && userID != "writer":
+		return note.Note{}, app.ErrNoAccess
+	}
+	return note.Note{ID: id, UserID: "owner"}, nil
+}}
+
+func TestServiceCreateComment(t *testing.T) {
+	tests := []struct {
+		name          string
+		comment       Comment
+		expectedError error
+	}{
+		{
+			name:          "should return ErrNoAccess",
+			comment:       Comment{NoteID: "note", UserID: "stranger", Text: "hi"},
+			expectedError: app.ErrNoAccess,
+		},
+		{
+			name:          "should return ErrNoteNotFound",
+			comment:       Comment{NoteID: "unknown", UserID: "reader", Text: "hi"},
+			expectedError: note.ErrNoteNotFound,
+		},
+		{
+			name:          "should return ErrParentNotFound",
+			comment:       Comment{NoteID: "note", UserID: "reader", ParentID: "unknown", Text: "hi"},
+			expectedError: ErrParentNotFound,
+		},
+		{
+			name:    "should create comment",
+			comment: Comment{NoteID: "note", UserID: "reader", Text: "hi"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(NewInMemoryStore(), sharedNote)
+			c, err := service.CreateComment(tt.comment)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, c.ID)
+			require.Equal(t, tt.comment.Text, c.Text)
+		})
+	}
+}
+
+func TestServiceThread(t *testing.T) {
+	store := NewInMemoryStore()
+	service := NewService(store, sharedNote)
+	root, err := service.CreateComment(Comment{NoteID: "note", UserID: "reader", Text: "question"})
+	require.NoError(t, err)
+	reply, err := service.CreateComment(Comment{NoteID: "note", UserID: "writer", ParentID: root.ID, Text: "answer"})
+	require.NoError(t, err)
+	_, err = service.CreateComment(Comment{NoteID: "note", UserID: "reader", ParentID: reply.ID, Text: "thanks"})
+	require.NoError(t, err)
+	other, err := service.CreateComment(Comment{NoteID: "note", UserID: "writer", Text: "other"})
+	require.NoError(t, err)
+
+	t.Run("only author should edit", func(t *testing.T) {
+		_, err := service.UpdateComment(reply.ID, "owner", "edited")
+		require.ErrorIs(t, err, app.ErrNoAccess)
+		c, err := service.UpdateComment(reply.ID, "writer", "edited")
+		require.NoError(t, err)
+		require.Equal(t, "edited", c.Text)
+		require.False(t, c.UpdatedAt.IsZero())
+	})
+
+	t.Run("others should not delete", func(t *testing.T) {
+		err := service.DeleteComment(other.ID, "reader")
+		require.ErrorIs(t, err, app.ErrNoAccess)
+	})
+
+	t.Run("owner should delete thread", func(t *testing.T) {
+		require.NoError(t, service.DeleteComment(root.ID, "owner"))
+		comments, err := service.GetNoteComments("note", "reader")
+		require.NoError(t, err)
+		require.Equal(t, []Comment{other}, comments)
+	})
+
+	t.Run("author should delete comment", func(t *testing.T) {
+		require.NoError(t, service.DeleteComment(other.ID, "writer"))
+		_, err := service.GetNoteComments("note", "stranger")
+		require.ErrorIs(t, err, app.ErrNoAccess)
+	})
+
+	t.Run("should delete comments with the note", func(t *testing.T) {
+		_, err := service.CreateComment(Comment{NoteID: "note", UserID: "writer", Text: "again"})
+		require.NoError(t, err)
+		require.NoError(t, store.DeleteNoteComments("note"))
+		comments, err := store.GetNoteComments("note")
+		require.NoError(t, err)
+		require.Empty(t, comments)
+	})
+
+	t.Run("should delete comments of deleted user with replies", func(t *testing.T) {
+		question, err := service.CreateComment(Comment{NoteID: "note", UserID: "reader", Text: "question"})
+		require.NoError(t, err)
+		_, err = service.CreateComment(Comment{NoteID: "note", UserID: "writer", ParentID: question.ID, Text: "answer"})
+		require.NoError(t, err)
+		kept, err := service.CreateComment(Comment{NoteID: "note", UserID: "writer", Text: "other"})
+		require.NoError(t, err)
+
+		store.DeleteUserComments("reader")
+		comments, err := store.GetNoteComments("note")
+		require.NoError(t, err)
+		require.Equal(t, []Comment{kept}, comments)
+	})
+}
@@ -0,0 +1,130 @@
+package comment
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// comments map[commentId]Comment
+type InMemoryStore struct {
+	sync.RWMutex
+	comments map[string]Comment
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{comments: make(map[string]Comment)}
+}
+
+// CreateComment saves comment, a reply must have its parent on the same note
+func (store *InMemoryStore) CreateComment(comment Comment) (Comment, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	if comment.ParentID != "" {
+		parent, ok := store.comments[comment.ParentID]
+		if !ok || parent.NoteID != comment.NoteID {
+			return Comment{}, ErrParentNotFound
+		}
+	}
+	comment.ID = uuid.NewString()
+	comment.CreatedAt = time.Now().UTC()
+	store.comments[comment.ID] = comment
+	return comment, nil
+}
+
+func (store *InMemoryStore) FindCommentByID(id string) (Comment, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	if c, ok := store.comments[id]; ok {
+		return c, nil
+	}
+	return Comment{}, ErrCommentNotFound
+}
+
+// GetNoteComments returns comments of the note, oldest first
+func (store *InMemoryStore) GetNoteComments(noteID string) ([]Comment, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	res := make([]Comment, 0)
+	for _, c := range store.comments {
+		if c.NoteID == noteID {
+			res = append(res, c)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+	return res, nil
+}
+
+func (store *InMemoryStore) UpdateComment(comment Comment) (Comment, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	if _, ok := store.comments[comment.ID]; !ok {
+		return Comment{}, ErrCommentNotFound
+	}
+	comment.UpdatedAt = time.Now().UTC()
+	store.comments[comment.ID] = comment
+	return comment, nil
+}
+
+// DeleteComment removes the comment with all replies to it
+func (store *InMemoryStore) DeleteComment(id string) error {
+	store.Lock()
+	defer store.Unlock()
+
+	if _, ok := store.comments[id]; !ok {
+		return ErrCommentNotFound
+	}
+	store.deleteWithReplies(map[string]bool{id: true})
+	return nil
+}
+
+// DeleteUserComments removes comments the user left on any note with all replies to them
+func (store *InMemoryStore) DeleteUserComments(userID string) {
+	store.Lock()
+	defer store.Unlock()
+
+	removed := make(map[string]bool)
+	for id, c := range store.comments {
+		if c.UserID == userID {
+			removed[id] = true
+		}
+	}
+	store.deleteWithReplies(removed)
+}
+
+// deleteWithReplies isn't thread-safe
+func (store *InMemoryStore) deleteWithReplies(removed map[string]bool) {
+	// replies are found level by level until no comment hangs on removed ones
+	for found := true; found; {
+		found = false
+		for _, c := range store.comments {
+			if removed[c.ParentID] && !removed[c.ID] {
+				removed[c.ID] = true
+				found = true
+			}
+		}
+	}
+	for cid := range removed {
+		delete(store.comments, cid)
+	}
+}
+
+func (store *InMemoryStore) DeleteNoteComments(noteID string) error {
+	store.Lock()
+	defer store.Unlock()
+
+	for id, c := range store.comments {
+		if c.NoteID == noteID {
+			delete(store.comments, id)
+		}
+	}
+	return nil
+}
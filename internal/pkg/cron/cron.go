@@ -0,0 +1,153 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+// Schedule is a parsed five-field cron expression: minute, hour, day of month, month and day of week.
+// Every field is a bitset of allowed values.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+type bounds struct {
+	min, max int
+}
+
+var (
+	minuteBounds = bounds{0, 59}
+	hourBounds   = bounds{0, 23}
+	domBounds    = bounds{1, 31}
+	monthBounds  = bounds{1, 12}
+	dowBounds    = bounds{0, 7}
+)
+
+var macros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// Parse supports *, lists, ranges, steps and @hourly/@daily/@weekly/@monthly/@yearly
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if m, ok := macros[expr]; ok {
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidExpression, len(fields))
+	}
+
+	var s Schedule
+	var err error
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.dom, err = parseField(fields[2], domBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return Schedule{}, err
+	}
+	// 7 is another name for sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return s, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		lo, hi, step := b.min, b.max, 1
+		rangePart := part
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("%w: bad step in %q", ErrInvalidExpression, part)
+			}
+			step = s
+			rangePart = part[:i]
+		}
+
+		if rangePart != "*" {
+			var err error
+			if i := strings.Index(rangePart, "-"); i >= 0 {
+				lo, err = strconv.Atoi(rangePart[:i])
+				if err == nil {
+					hi, err = strconv.Atoi(rangePart[i+1:])
+				}
+			} else {
+				lo, err = strconv.Atoi(rangePart)
+				hi = lo
+				if step > 1 {
+					hi = b.max
+				}
+			}
+			if err != nil || lo < b.min || hi > b.max || lo > hi {
+				return 0, fmt.Errorf("%w: bad range in %q", ErrInvalidExpression, part)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first moment after t which matches the schedule, in t location.
+// Zero time is returned if nothing matches during the next five years.
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron rules: when both day fields are restricted, either of them is enough
+func (s Schedule) dayMatches(t time.Time) bool {
+	domOk := s.dom&(1<<uint(t.Day())) != 0
+	dowOk := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domOk && dowOk
+	}
+	return domOk || dowOk
+}
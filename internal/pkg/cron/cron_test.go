@@ -0,0 +1,61 @@
+package cron
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		valid bool
+	}{
+		{name: "should parse stars", expr: "* * * * *", valid: true},
+		{name: "should parse lists, ranges and steps", expr: "0,30 9-17 */2 1-6/2 1-5", valid: true},
+		{name: "should parse macro", expr: "@weekly", valid: true},
+		{name: "should fail on field count", expr: "* * * *"},
+		{name: "should fail on out of range value", expr: "60 * * * *"},
+		{name: "should fail on bad step", expr: "*/0 * * * *"},
+		{name: "should fail on garbage", expr: "a b c d e"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrInvalidExpression)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2022, time.September, 20, 10, 15, 30, 0, time.UTC) // tuesday
+	tests := []struct {
+		name     string
+		expr     string
+		expected time.Time
+	}{
+		{name: "every minute", expr: "* * * * *", expected: time.Date(2022, time.September, 20, 10, 16, 0, 0, time.UTC)},
+		{name: "daily stand-up", expr: "0 10 * * 1-5", expected: time.Date(2022, time.September, 21, 10, 0, 0, 0, time.UTC)},
+		{name: "friday retro", expr: "30 16 * * 5", expected: time.Date(2022, time.September, 23, 16, 30, 0, 0, time.UTC)},
+		{name: "first of month", expr: "@monthly", expected: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", expr: "0 0 * * 7", expected: time.Date(2022, time.September, 25, 0, 0, 0, 0, time.UTC)},
+		{name: "day of month or weekday", expr: "0 0 28 * 4", expected: time.Date(2022, time.September, 22, 0, 0, 0, 0, time.UTC)},
+		{name: "never", expr: "0 0 30 2 *", expected: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, s.Next(from))
+		})
+	}
+}
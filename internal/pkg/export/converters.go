@@ -0,0 +1,30 @@
+package export
+
+import "note-service/internal/pkg/note"
+
+func noteToDocument(n note.Note) Document {
+	doc := Document{
+		ID:          n.ID,
+		UserID:      n.UserID,
+		Subject:     n.Subject,
+		ContentType: n.ContentType,
+		Kind:        n.Kind,
+		Text:        n.Text,
+		TTL:         n.TTL,
+		IsPublic:    n.IsPublic,
+		PublishAt:   n.PublishAt,
+		DueAt:       n.DueAt,
+		CreatedAt:   n.CreatedAt,
+	}
+	if n.PublicUsers != nil {
+		doc.PublicUsers = *n.PublicUsers
+	}
+	if !n.UpdatedAt.IsZero() {
+		updatedAt := n.UpdatedAt
+		doc.UpdatedAt = &updatedAt
+	}
+	for _, item := range n.Items {
+		doc.Items = append(doc.Items, Item{Text: item.Text, Checked: item.Checked})
+	}
+	return doc
+}
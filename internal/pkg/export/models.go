@@ -0,0 +1,63 @@
+package export
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Document is a note as it is written to an export, import reads the same fields back
+type Document struct {
+	ID          string     `json:"id" yaml:"id"`
+	UserID      string     `json:"userId" yaml:"userId"`
+	Subject     string     `json:"subject" yaml:"subject"`
+	ContentType string     `json:"contentType" yaml:"contentType"`
+	Kind        string     `json:"kind" yaml:"kind"`
+	Text        string     `json:"text" yaml:"-"`
+	Items       []Item     `json:"items,omitempty" yaml:"-"`
+	TTL         *int64     `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	IsPublic    bool       `json:"isPublic" yaml:"isPublic"`
+	PublicUsers []string   `json:"publicUsers,omitempty" yaml:"publicUsers,omitempty"`
+	PublishAt   *int64     `json:"publishAt,omitempty" yaml:"publishAt,omitempty"`
+	DueAt       *int64     `json:"dueAt,omitempty" yaml:"dueAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt" yaml:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty" yaml:"updatedAt,omitempty"`
+	Attachments []string   `json:"attachments,omitempty" yaml:"attachments,omitempty"`
+}
+
+type Item struct {
+	Text    string `json:"text"`
+	Checked bool   `json:"checked"`
+}
+
+// Job is an export running in background, Done of Total notes are written so far
+type Job struct {
+	ID         string
+	UserID     string
+	Format     string
+	Shared     bool
+	Status     string
+	Total      int
+	Done       int
+	Error      string
+	Path       string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+var (
+	ErrFormat      = errors.New("format must be json, markdown or html")
+	ErrJobNotFound = errors.New("export job not found")
+	ErrJobNotDone  = errors.New("export job is not done yet")
+	ErrJobActive   = errors.New("another export job of the user is not finished yet")
+)
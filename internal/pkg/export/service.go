@@ -0,0 +1,265 @@
+package export
+
+import (
+	"io"
+	"note-service/internal/app"
+	"note-service/internal/pkg/attachment"
+	"note-service/internal/pkg/note"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type store interface {
+	CreateJob(job Job) (Job, error)
+	FindJobByID(id string) (Job, error)
+	UpdateJob(job Job) (Job, error)
+	DeleteJob(id string) error
+	DeleteUserJobs(userID string) []Job
+	DeleteFinishedBefore(t time.Time) []Job
+}
+
+const (
+	// sweepInterval is how often Run looks for jobs past retention
+	sweepInterval = time.Minute
+	// maxRunningJobs is how many archives are written at once, other jobs wait pending
+	maxRunningJobs = 4
+)
+
+type noteSource interface {
+	GetNotes(userID string, opts note.ListOptions) ([]note.Note, error)
+}
+
+type attachmentSource interface {
+	GetNoteAttachments(noteID, userID string) ([]attachment.Attachment, error)
+	Open(id, userID string) (attachment.Attachment, io.ReadCloser, error)
+}
+
+type Service struct {
+	store       store
+	notes       noteSource
+	attachments attachmentSource
+	dir         string
+	retention   time.Duration
+	logger      *zap.Logger
+	done        chan struct{}
+	stop        sync.Once
+	slots       chan struct{}
+}
+
+// NewService keeps finished job archives in dir until the job is deleted or for retention after it finishes
+func NewService(store store, notes noteSource, attachments attachmentSource, dir string, retention time.Duration, logger *zap.Logger) *Service {
+	return &Service{
+		store:       store,
+		notes:       notes,
+		attachments: attachments,
+		dir:         dir,
+		retention:   retention,
+		logger:      logger,
+		done:        make(chan struct{}),
+		slots:       make(chan struct{}, maxRunningJobs),
+	}
+}
+
+// Run deletes jobs past retention with their archives until Stop
+func (s *Service) Run() error {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return nil
+		case now := <-ticker.C:
+			s.DeleteExpired(now.UTC())
+		}
+	}
+}
+
+func (s *Service) Stop() {
+	s.stop.Do(func() { close(s.done) })
+}
+
+// DeleteExpired drops jobs finished more than retention before now with their archives
+func (s *Service) DeleteExpired(now time.Time) {
+	s.removeArchives(s.store.DeleteFinishedBefore(now.Add(-s.retention)))
+}
+
+func ValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatMarkdown || format == FormatHTML
+}
+
+// Export writes a zip of all user notes, archived ones included, and of shared with the user notes if asked
+func (s *Service) Export(w io.Writer, userID, format string, shared bool) error {
+	if !ValidFormat(format) {
+		return ErrFormat
+	}
+	notes, err := s.notes.GetNotes(userID, note.ListOptions{Sort: "created-at", Archived: true, Shared: shared})
+	if err != nil {
+		return err
+	}
+	return s.write(w, notes, userID, format, func(int) {})
+}
+
+func (s *Service) write(w io.Writer, notes []note.Note, userID, format string, progress func(done int)) error {
+	a := newArchive(w, format)
+	for i, n := range notes {
+		attachments, err := s.attachments.GetNoteAttachments(n.ID, userID)
+		if err != nil {
+			return err
+		}
+		doc := noteToDocument(n)
+		content := make(map[string]func() (io.ReadCloser, error), len(attachments))
+		for _, at := range attachments {
+			id, name := at.ID, uniqueAttachmentName(content, at.Name)
+			doc.Attachments = append(doc.Attachments, name)
+			content[name] = func() (io.ReadCloser, error) {
+				_, r, err := s.attachments.Open(id, userID)
+				return r, err
+			}
+		}
+		if err := a.writeNote(doc, content); err != nil {
+			return err
+		}
+		progress(i + 1)
+	}
+	return a.Close()
+}
+
+func uniqueAttachmentName(taken map[string]func() (io.ReadCloser, error), name string) string {
+	ext := filepath.Ext(name)
+	res := name
+	for i := 2; taken[res] != nil; i++ {
+		res = name[:len(name)-len(ext)] + "-" + strconv.Itoa(i) + ext
+	}
+	return res
+}
+
+// StartJob runs export in background, the archive is downloaded with OpenResult when the job is done.
+// A user has one unfinished job at a time.
+func (s *Service) StartJob(userID, format string, shared bool) (Job, error) {
+	if !ValidFormat(format) {
+		return Job{}, ErrFormat
+	}
+	job, err := s.store.CreateJob(Job{UserID: userID, Format: format, Shared: shared})
+	if err != nil {
+		return Job{}, err
+	}
+	go s.run(job)
+	return job, nil
+}
+
+func (s *Service) run(job Job) {
+	s.slots <- struct{}{}
+	path, err := s.runJob(job)
+	<-s.slots
+
+	job, findErr := s.store.FindJobByID(job.ID)
+	if findErr != nil {
+		// the job was deleted while running, nobody will download the archive
+		os.Remove(path)
+		return
+	}
+	now := time.Now().UTC()
+	job.FinishedAt = &now
+	if err != nil {
+		s.logger.Error("export job failed", zap.String("jobID", job.ID), zap.Error(err))
+		os.Remove(path)
+		job.Status = JobFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = JobDone
+		job.Path = path
+	}
+	if _, err := s.store.UpdateJob(job); err != nil && job.Path != "" {
+		// the job was deleted after the check above, nobody will download the archive
+		os.Remove(path)
+	}
+}
+
+// runJob writes the archive into a file in the service dir and returns its path
+func (s *Service) runJob(job Job) (string, error) {
+	notes, err := s.notes.GetNotes(job.UserID, note.ListOptions{Sort: "created-at", Archived: true, Shared: job.Shared})
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(s.dir, "export-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	job.Status = JobRunning
+	job.Total = len(notes)
+	if _, err := s.store.UpdateJob(job); err != nil {
+		return f.Name(), err
+	}
+	return f.Name(), s.write(f, notes, job.UserID, job.Format, func(done int) {
+		job.Done = done
+		s.store.UpdateJob(job)
+	})
+}
+
+func (s *Service) GetJob(id, userID string) (Job, error) {
+	job, err := s.store.FindJobByID(id)
+	if err != nil {
+		return Job{}, err
+	}
+	if job.UserID != userID {
+		return Job{}, app.ErrNoAccess
+	}
+	return job, nil
+}
+
+func (s *Service) OpenResult(id, userID string) (Job, io.ReadCloser, error) {
+	job, err := s.GetJob(id, userID)
+	if err != nil {
+		return Job{}, nil, err
+	}
+	if job.Status != JobDone {
+		return Job{}, nil, ErrJobNotDone
+	}
+	f, err := os.Open(job.Path)
+	if err != nil {
+		return Job{}, nil, err
+	}
+	return job, f, nil
+}
+
+// DeleteJob forgets the job and removes its archive, a running job finishes but its result is dropped
+func (s *Service) DeleteJob(id, userID string) error {
+	job, err := s.GetJob(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteJob(id); err != nil {
+		return err
+	}
+	if job.Path != "" {
+		return os.Remove(job.Path)
+	}
+	return nil
+}
+
+// DeleteUserJobs drops every job of the user with its archive, running jobs drop their result when they finish
+func (s *Service) DeleteUserJobs(userID string) {
+	s.removeArchives(s.store.DeleteUserJobs(userID))
+}
+
+func (s *Service) removeArchives(jobs []Job) {
+	for _, job := range jobs {
+		if job.Path == "" {
+			continue
+		}
+		if err := os.Remove(job.Path); err != nil {
+			s.logger.Error("failed to remove export archive", zap.String("jobID", job.ID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"note-service/internal/app"
+	"note-service/internal/pkg/attachment"
+	"note-service/internal/pkg/note"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type noteSourceMock struct {
+	GetNotesFunc func(userID string, opts note.ListOptions) ([]note.Note, error)
+}
+
+func (n *noteSourceMock) GetNotes(userID string, opts note.ListOptions) ([]note.Note, error) {
+	return n.GetNotesFunc(userID, opts)
+}
+
+type attachmentSourceMock struct {
+	attachments map[string][]attachment.Attachment
+	content     map[string]string
+}
+
+func (a *attachmentSourceMock) GetNoteAttachments(noteID, userID string) ([]attachment.Attachment, error) {
+	return a.attachments[noteID], nil
+}
+
+func (a *attachmentSourceMock) Open(id, userID string) (attachment.Attachment, io.ReadCloser, error) {
+	return attachment.Attachment{ID: id}, io.NopCloser(strings.NewReader(a.content[id])), nil
+}
+
+var (
+	created = time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	ttl     = int64(1700000000)
+
+	ownNote = note.Note{
+		ID:          "11111111-aaaa",
+		UserID:      "owner",
+		Subject:     "Shopping list",
+		Text:        "**milk**",
+		ContentType: note.ContentTypeMarkdown,
+		Kind:        note.KindText,
+		TTL:         &ttl,
+		IsPublic:    true,
+		PublicUsers: &[]string{"reader"},
+		CreatedAt:   created,
+	}
+	checklist = note.Note{
+		ID:        "22222222-bbbb",
+		UserID:    "owner",
+		Subject:   "Todo",
+		Kind:      note.KindChecklist,
+		Items:     []note.ChecklistItem{{Text: "first", Checked: true}, {Text: "second"}},
+		CreatedAt: created,
+	}
+	sharedWithOwner = note.Note{
+		ID:        "33333333-cccc",
+		UserID:    "friend",
+		Subject:   "Shared",
+		Text:      "<b>hi</b>",
+		Kind:      note.KindText,
+		IsPublic:  true,
+		CreatedAt: created,
+	}
+)
+
+func newTestService(t *testing.T) *Service {
+	notes := &noteSourceMock{GetNotesFunc: func(userID string, opts note.ListOptions) ([]note.Note, error) {
+		if !opts.Archived {
+			t.Error("archived notes should be exported")
+		}
+		if opts.Shared {
+			return []note.Note{ownNote, checklist, sharedWithOwner}, nil
+		}
+		return []note.Note{ownNote, checklist}, nil
+	}}
+	attachments := &attachmentSourceMock{
+		attachments: map[string][]attachment.Attachment{
+			ownNote.ID: {{ID: "a1", Name: "photo.png"}, {ID: "a2", Name: "photo.png"}},
+		},
+		content: map[string]string{"a1": "first photo", "a2": "second photo"},
+	}
+	return NewService(NewInMemoryStore(), notes, attachments, t.TempDir(), time.Hour, zap.NewNop())
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestServiceExport(t *testing.T) {
+	service := newTestService(t)
+
+	t.Run("should write markdown with front matter and attachments", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, service.Export(&buf, "owner", FormatMarkdown, false))
+		files := readZip(t, buf.Bytes())
+		require.Len(t, files, 4)
+
+		md := files["notes/shopping-list-11111111.md"]
+		require.True(t, strings.HasPrefix(md, "---\nid: 11111111-aaaa\n"), md)
+		require.Contains(t, md, "subject: Shopping list\n")
+		require.Contains(t, md, "ttl: 1700000000\n")
+		require.Contains(t, md, "createdAt: 2022-10-01T12:00:00Z\n")
+		require.Contains(t, md, "publicUsers:\n  - reader\n")
+		require.Contains(t, md, "attachments:\n  - photo.png\n  - photo-2.png\n")
+		require.True(t, strings.HasSuffix(md, "---\n**milk**"), md)
+
+		require.True(t, strings.HasSuffix(files["notes/todo-22222222.md"], "---\n- [x] first\n- [ ] second\n"))
+		require.Equal(t, "first photo", files["attachments/shopping-list-11111111/photo.png"])
+		require.Equal(t, "second photo", files["attachments/shopping-list-11111111/photo-2.png"])
+	})
+
+	t.Run("should render html and include shared notes", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, service.Export(&buf, "owner", FormatHTML, true))
+		files := readZip(t, buf.Bytes())
+
+		require.Contains(t, files["notes/shopping-list-11111111.html"], "<strong>milk</strong>")
+		require.Contains(t, files["notes/shared-33333333.html"], "&lt;b&gt;hi&lt;/b&gt;")
+		require.Contains(t, files["notes/todo-22222222.html"], `<input checked="" disabled="" type="checkbox"`)
+		require.True(t, strings.HasPrefix(files["notes/shared-33333333.html"], "<!DOCTYPE html>\n"))
+		require.Contains(t, files["notes/shopping-list-11111111.html"], "<dt>publicUsers</dt><dd>reader</dd>")
+	})
+
+	t.Run("should escape note fields in html", func(t *testing.T) {
+		data, err := encodeDocument(Document{
+			ID:          "1",
+			Subject:     "<script>alert(1)</script>",
+			Kind:        note.KindText,
+			Text:        "<script>alert(2)</script>",
+			PublicUsers: []string{`"><script>alert(3)</script>`},
+			CreatedAt:   created,
+		}, FormatHTML)
+		require.NoError(t, err)
+		page := string(data)
+		require.NotContains(t, page, "<script>")
+		require.Contains(t, page, "<title>&lt;script&gt;alert(1)&lt;/script&gt;</title>")
+		require.Contains(t, page, "<h1>&lt;script&gt;alert(1)&lt;/script&gt;</h1>")
+		require.Contains(t, page, "&#34;&gt;&lt;script&gt;alert(3)&lt;/script&gt;")
+	})
+
+	t.Run("should write json documents", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, service.Export(&buf, "owner", FormatJSON, false))
+		files := readZip(t, buf.Bytes())
+
+		require.Contains(t, files["notes/todo-22222222.json"], `"items": [`)
+		require.Contains(t, files["notes/shopping-list-11111111.json"], `"text": "**milk**"`)
+	})
+
+	t.Run("should return ErrFormat", func(t *testing.T) {
+		require.ErrorIs(t, service.Export(io.Discard, "owner", "pdf", false), ErrFormat)
+	})
+}
+
+func TestServiceJob(t *testing.T) {
+	service := newTestService(t)
+
+	job, err := service.StartJob("owner", FormatJSON, true)
+	require.NoError(t, err)
+	require.Equal(t, JobPending, job.Status)
+
+	require.Eventually(t, func() bool {
+		job, err = service.GetJob(job.ID, "owner")
+		return err == nil && job.Status == JobDone
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 3, job.Total)
+	require.Equal(t, 3, job.Done)
+
+	_, err = service.GetJob(job.ID, "stranger")
+	require.ErrorIs(t, err, app.ErrNoAccess)
+
+	_, content, err := service.OpenResult(job.ID, "owner")
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	content.Close()
+	require.Contains(t, readZip(t, data), "notes/shared-33333333.json")
+
+	require.NoError(t, service.DeleteJob(job.ID, "owner"))
+	require.NoFileExists(t, job.Path)
+	_, err = service.GetJob(job.ID, "owner")
+	require.ErrorIs(t, err, ErrJobNotFound)
+}
+
+// deletingStore deletes the job right before it is saved as done, like DeleteJob coming at the last moment
+type deletingStore struct {
+	*InMemoryStore
+}
+
+func (s deletingStore) UpdateJob(job Job) (Job, error) {
+	if job.Status == JobDone {
+		s.InMemoryStore.DeleteJob(job.ID)
+	}
+	return s.InMemoryStore.UpdateJob(job)
+}
+
+func TestServiceJobLimits(t *testing.T) {
+	t.Run("should keep one unfinished job per user", func(t *testing.T) {
+		service := newTestService(t)
+		job, err := service.StartJob("owner", FormatJSON, false)
+		require.NoError(t, err)
+		_, err = service.StartJob("owner", FormatJSON, false)
+		require.ErrorIs(t, err, ErrJobActive)
+
+		require.Eventually(t, func() bool {
+			job, err = service.GetJob(job.ID, "owner")
+			return err == nil && job.Status == JobDone
+		}, time.Second, 10*time.Millisecond)
+		_, err = service.StartJob("owner", FormatJSON, false)
+		require.NoError(t, err)
+	})
+
+	t.Run("should remove archive of job deleted while it was saved", func(t *testing.T) {
+		base := newTestService(t)
+		store := deletingStore{NewInMemoryStore()}
+		service := NewService(store, base.notes, base.attachments, base.dir, time.Hour, zap.NewNop())
+		job, err := service.StartJob("owner", FormatJSON, false)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			_, err := store.FindJobByID(job.ID)
+			entries, _ := os.ReadDir(base.dir)
+			return errors.Is(err, ErrJobNotFound) && len(entries) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestServiceDeleteExpired(t *testing.T) {
+	service := newTestService(t)
+	job, err := service.StartJob("owner", FormatJSON, false)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		job, err = service.GetJob(job.ID, "owner")
+		return err == nil && job.Status == JobDone
+	}, time.Second, 10*time.Millisecond)
+
+	service.DeleteExpired(job.FinishedAt.Add(time.Hour))
+	require.FileExists(t, job.Path, "the job is kept for the whole retention")
+
+	service.DeleteExpired(job.FinishedAt.Add(time.Hour + time.Second))
+	require.NoFileExists(t, job.Path)
+	_, err = service.GetJob(job.ID, "owner")
+	require.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestServiceDeleteUserJobs(t *testing.T) {
+	service := newTestService(t)
+	job, err := service.StartJob("owner", FormatJSON, false)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		job, err = service.GetJob(job.ID, "owner")
+		return err == nil && job.Status == JobDone
+	}, time.Second, 10*time.Millisecond)
+	require.FileExists(t, job.Path)
+
+	service.DeleteUserJobs("owner")
+	require.NoFileExists(t, job.Path)
+	_, err = service.GetJob(job.ID, "owner")
+	require.ErrorIs(t, err, ErrJobNotFound)
+}
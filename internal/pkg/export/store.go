@@ -0,0 +1,97 @@
+package export
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// jobs map[jobId]Job
+type InMemoryStore struct {
+	sync.RWMutex
+	jobs map[string]Job
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{jobs: make(map[string]Job)}
+}
+
+// CreateJob returns ErrJobActive while the user has a pending or running job
+func (store *InMemoryStore) CreateJob(job Job) (Job, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	for _, other := range store.jobs {
+		if other.UserID == job.UserID && (other.Status == JobPending || other.Status == JobRunning) {
+			return Job{}, ErrJobActive
+		}
+	}
+	job.ID = uuid.NewString()
+	job.Status = JobPending
+	job.CreatedAt = time.Now().UTC()
+	store.jobs[job.ID] = job
+	return job, nil
+}
+
+func (store *InMemoryStore) FindJobByID(id string) (Job, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	if job, ok := store.jobs[id]; ok {
+		return job, nil
+	}
+	return Job{}, ErrJobNotFound
+}
+
+func (store *InMemoryStore) UpdateJob(job Job) (Job, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	if _, ok := store.jobs[job.ID]; !ok {
+		return Job{}, ErrJobNotFound
+	}
+	store.jobs[job.ID] = job
+	return job, nil
+}
+
+func (store *InMemoryStore) DeleteJob(id string) error {
+	store.Lock()
+	defer store.Unlock()
+
+	if _, ok := store.jobs[id]; !ok {
+		return ErrJobNotFound
+	}
+	delete(store.jobs, id)
+	return nil
+}
+
+// DeleteUserJobs removes every job of the user and returns them
+func (store *InMemoryStore) DeleteUserJobs(userID string) []Job {
+	store.Lock()
+	defer store.Unlock()
+
+	var removed []Job
+	for id, job := range store.jobs {
+		if job.UserID == userID {
+			delete(store.jobs, id)
+			removed = append(removed, job)
+		}
+	}
+	return removed
+}
+
+// DeleteFinishedBefore removes jobs finished before t and returns them, running jobs stay
+func (store *InMemoryStore) DeleteFinishedBefore(t time.Time) []Job {
+	store.Lock()
+	defer store.Unlock()
+
+	var removed []Job
+	for id, job := range store.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(t) {
+			delete(store.jobs, id)
+			removed = append(removed, job)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,223 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"note-service/internal/pkg/markdown"
+	"note-service/internal/pkg/note"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// archive writes notes into a zip, one file per note under notes/ and attachments under attachments/<note file>/
+type archive struct {
+	zw     *zip.Writer
+	format string
+	names  map[string]bool
+}
+
+func newArchive(w io.Writer, format string) *archive {
+	return &archive{zw: zip.NewWriter(w), format: format, names: make(map[string]bool)}
+}
+
+func (a *archive) writeNote(doc Document, attachments map[string]func() (io.ReadCloser, error)) error {
+	base := a.uniqueName("notes/"+baseName(doc), "")
+	data, err := encodeDocument(doc, a.format)
+	if err != nil {
+		return err
+	}
+	if err := a.writeFile(base+extension(a.format), doc.modTime(), bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	for _, name := range doc.Attachments {
+		content, err := attachments[name]()
+		if err != nil {
+			return err
+		}
+		err = a.writeFile("attachments/"+strings.TrimPrefix(base, "notes/")+"/"+name, doc.modTime(), content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *archive) writeFile(name string, modified time.Time, content io.Reader) error {
+	w, err := a.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, content)
+	return err
+}
+
+func (a *archive) uniqueName(name, suffix string) string {
+	res := name + suffix
+	for i := 2; a.names[res]; i++ {
+		res = fmt.Sprintf("%s-%d%s", name, i, suffix)
+	}
+	a.names[res] = true
+	return res
+}
+
+func (a *archive) Close() error {
+	return a.zw.Close()
+}
+
+// modTime is the modification time of the note files
+func (d Document) modTime() time.Time {
+	if d.UpdatedAt != nil {
+		return *d.UpdatedAt
+	}
+	return d.CreatedAt
+}
+
+func extension(format string) string {
+	switch format {
+	case FormatMarkdown:
+		return ".md"
+	case FormatHTML:
+		return ".html"
+	default:
+		return ".json"
+	}
+}
+
+// encodeDocument writes json as is, markdown gets YAML front matter before the body
+// and html is a whole page with escaped metadata
+func encodeDocument(doc Document, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(doc, "", "  ")
+	case FormatHTML:
+		return encodeHTML(doc)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	buf.WriteString("---\n")
+
+	buf.WriteString(documentBody(doc))
+	return buf.Bytes(), nil
+}
+
+func documentBody(doc Document) string {
+	if doc.Kind == note.KindChecklist {
+		return checklistMarkdown(doc.Items)
+	}
+	return doc.Text
+}
+
+// encodeHTML escapes every field taken from the note, the body is rendered by the sanitizing renderer
+func encodeHTML(doc Document) ([]byte, error) {
+	body := documentBody(doc)
+	if doc.ContentType == note.ContentTypeMarkdown || doc.Kind == note.KindChecklist {
+		rendered, err := markdown.Render(body)
+		if err != nil {
+			return nil, err
+		}
+		body = rendered
+	} else {
+		body = markdown.RenderPlain(body)
+	}
+
+	title := html.EscapeString(doc.Subject)
+	var buf bytes.Buffer
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	buf.WriteString("<title>" + title + "</title>\n</head>\n<body>\n<h1>" + title + "</h1>\n<dl>\n")
+	for _, field := range documentMetadata(doc) {
+		buf.WriteString("<dt>" + field[0] + "</dt><dd>" + html.EscapeString(field[1]) + "</dd>\n")
+	}
+	buf.WriteString("</dl>\n")
+	buf.WriteString(body)
+	buf.WriteString("\n</body>\n</html>\n")
+	return buf.Bytes(), nil
+}
+
+// documentMetadata lists the front matter fields of doc which are set, the subject goes to the title
+func documentMetadata(doc Document) [][2]string {
+	unix := func(t int64) string {
+		return time.Unix(t, 0).UTC().Format(time.RFC3339)
+	}
+	res := [][2]string{
+		{"id", doc.ID},
+		{"userId", doc.UserID},
+		{"contentType", doc.ContentType},
+		{"kind", doc.Kind},
+		{"createdAt", doc.CreatedAt.Format(time.RFC3339)},
+	}
+	if doc.UpdatedAt != nil {
+		res = append(res, [2]string{"updatedAt", doc.UpdatedAt.Format(time.RFC3339)})
+	}
+	if doc.TTL != nil {
+		res = append(res, [2]string{"ttl", unix(*doc.TTL)})
+	}
+	res = append(res, [2]string{"isPublic", strconv.FormatBool(doc.IsPublic)})
+	if len(doc.PublicUsers) > 0 {
+		res = append(res, [2]string{"publicUsers", strings.Join(doc.PublicUsers, ", ")})
+	}
+	if doc.PublishAt != nil {
+		res = append(res, [2]string{"publishAt", unix(*doc.PublishAt)})
+	}
+	if doc.DueAt != nil {
+		res = append(res, [2]string{"dueAt", unix(*doc.DueAt)})
+	}
+	if len(doc.Attachments) > 0 {
+		res = append(res, [2]string{"attachments", strings.Join(doc.Attachments, ", ")})
+	}
+	return res
+}
+
+func checklistMarkdown(items []Item) string {
+	var b strings.Builder
+	for _, item := range items {
+		mark := " "
+		if item.Checked {
+			mark = "x"
+		}
+		b.WriteString("- [" + mark + "] " + item.Text + "\n")
+	}
+	return b.String()
+}
+
+// baseName is a readable file name from the subject with the id start to keep it unique
+func baseName(doc Document) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(doc.Subject) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 40 {
+			break
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		slug = "note"
+	}
+	id := doc.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return slug + "-" + id
+}
@@ -0,0 +1,16 @@
+package idempotency
+
+import "time"
+
+// Record is a request seen with an idempotency key, Response stays nil while the request is handled
+type Record struct {
+	Fingerprint string
+	Response    *Response
+	ExpiresAt   time.Time
+}
+
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
@@ -0,0 +1,72 @@
+package idempotency
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired records are dropped
+const sweepInterval = time.Minute
+
+type recordKey struct {
+	userID string
+	key    string
+}
+
+type InMemoryStore struct {
+	sync.Mutex
+	records   map[recordKey]Record
+	lastSweep time.Time
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{records: make(map[recordKey]Record), lastSweep: time.Now()}
+}
+
+// Reserve returns the record of the key if it is known and not expired,
+// otherwise it saves a new record without response and reports true
+func (store *InMemoryStore) Reserve(userID, key, fingerprint string, window time.Duration) (Record, bool) {
+	store.Lock()
+	defer store.Unlock()
+
+	now := time.Now()
+	store.sweep(now)
+	k := recordKey{userID: userID, key: key}
+	if r, ok := store.records[k]; ok && now.Before(r.ExpiresAt) {
+		return r, false
+	}
+	r := Record{Fingerprint: fingerprint, ExpiresAt: now.Add(window)}
+	store.records[k] = r
+	return r, true
+}
+
+func (store *InMemoryStore) Complete(userID, key string, response Response) {
+	store.Lock()
+	defer store.Unlock()
+
+	k := recordKey{userID: userID, key: key}
+	if r, ok := store.records[k]; ok {
+		r.Response = &response
+		store.records[k] = r
+	}
+}
+
+// Release forgets the key so the request can be retried with it
+func (store *InMemoryStore) Release(userID, key string) {
+	store.Lock()
+	defer store.Unlock()
+
+	delete(store.records, recordKey{userID: userID, key: key})
+}
+
+func (store *InMemoryStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < sweepInterval {
+		return
+	}
+	store.lastSweep = now
+	for k, r := range store.records {
+		if !now.Before(r.ExpiresAt) {
+			delete(store.records, k)
+		}
+	}
+}
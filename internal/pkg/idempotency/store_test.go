@@ -0,0 +1,37 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	store := NewInMemoryStore()
+
+	_, reserved := store.Reserve("user", "key", "a", time.Hour)
+	require.True(t, reserved)
+
+	r, reserved := store.Reserve("user", "key", "b", time.Hour)
+	require.False(t, reserved)
+	require.Equal(t, "a", r.Fingerprint)
+	require.Nil(t, r.Response)
+
+	_, reserved = store.Reserve("other", "key", "a", time.Hour)
+	require.True(t, reserved, "keys are kept per user")
+
+	store.Complete("user", "key", Response{Status: 201, Body: []byte("{}")})
+	r, _ = store.Reserve("user", "key", "a", time.Hour)
+	require.Equal(t, 201, r.Response.Status)
+
+	store.Release("user", "key")
+	_, reserved = store.Reserve("user", "key", "c", time.Hour)
+	require.True(t, reserved)
+
+	_, reserved = store.Reserve("user", "expiring", "a", time.Nanosecond)
+	require.True(t, reserved)
+	time.Sleep(time.Millisecond)
+	_, reserved = store.Reserve("user", "expiring", "b", time.Hour)
+	require.True(t, reserved, "expired key can be used again")
+}
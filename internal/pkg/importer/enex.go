@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"note-service/internal/pkg/export"
+	"note-service/internal/pkg/note"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Data     string `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+// enexSource reads Evernote export note by note, resources become attachments
+type enexSource struct {
+	f     *os.File
+	dec   *xml.Decoder
+	count int
+}
+
+func openENEX(p string) (*enexSource, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	return &enexSource{f: f, dec: xml.NewDecoder(f)}, nil
+}
+
+func (s *enexSource) Next() (item, error) {
+	for {
+		token, err := s.dec.Token()
+		if err != nil {
+			return item{}, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		if s.count == maxEntries {
+			return item{}, ErrTooManyEntries
+		}
+		begin := s.dec.InputOffset()
+		var n enexNote
+		if err := s.dec.DecodeElement(&n, &start); err != nil {
+			return item{}, err
+		}
+		s.count++
+		if s.dec.InputOffset()-begin > maxItemSize {
+			return item{Name: enexName(n, s.count), Err: ErrItemTooLarge}, nil
+		}
+		return enexItem(n, s.count), nil
+	}
+}
+
+func (s *enexSource) Close() error {
+	return s.f.Close()
+}
+
+func enexItem(n enexNote, number int) item {
+	res := item{Name: enexName(n, number)}
+	text, err := enmlToText(n.Content)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Doc = export.Document{Subject: n.Title, Text: text, ContentType: note.ContentTypePlain, Kind: note.KindText}
+
+	for i, r := range n.Resources {
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(r.Data), ""))
+		if err != nil {
+			res.Err = errors.New("resource " + strconv.Itoa(i+1) + " is not valid base64")
+			return res
+		}
+		res.Attachments = append(res.Attachments, file{
+			Name: resourceName(r, i),
+			Size: int64(len(data)),
+			Open: func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(data)), nil
+			},
+		})
+	}
+	return res
+}
+
+func enexName(n enexNote, number int) string {
+	if n.Title == "" {
+		return "note " + strconv.Itoa(number)
+	}
+	return n.Title
+}
+
+func resourceName(r enexResource, i int) string {
+	if r.FileName != "" {
+		return r.FileName
+	}
+	name := "attachment-" + strconv.Itoa(i+1)
+	if exts, _ := mime.ExtensionsByType(r.Mime); len(exts) > 0 {
+		name += exts[0]
+	}
+	return name
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// enmlToText keeps the text of note content putting blocks on separate lines, to-dos become [ ] and [x]
+func enmlToText(content string) (string, error) {
+	dec := xml.NewDecoder(strings.NewReader(content))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	var b strings.Builder
+	newLine := func() {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "br":
+				b.WriteByte('\n')
+			case "div", "p", "li", "tr", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote", "pre":
+				newLine()
+			case "en-todo":
+				mark := "[ ] "
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "checked" && attr.Value == "true" {
+						mark = "[x] "
+					}
+				}
+				b.WriteString(mark)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "div", "p", "li", "tr", "h1", "h2", "h3", "h4", "h5", "h6", "blockquote", "pre":
+				newLine()
+			}
+		}
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(b.String(), "\n\n")), nil
+}
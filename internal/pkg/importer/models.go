@@ -0,0 +1,63 @@
+package importer
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+
+	ItemCreated = "created"
+	ItemSkipped = "skipped"
+	ItemFailed  = "failed"
+
+	// MaxFileSize is the largest file to import
+	MaxFileSize = 100 << 20
+	// maxEntries is the most files in a zip or notes in an ENEX file
+	maxEntries = 10000
+	// maxItemSize is the largest note file in a zip, in ENEX it counts the note with its resources
+	maxItemSize = 10 << 20
+)
+
+// Job imports the uploaded file kept at Path, Next is the index of the item to import after resume
+type Job struct {
+	ID         string
+	UserID     string
+	Status     string
+	Path       string
+	Next       int
+	Created    int
+	Skipped    int
+	Failed     int
+	Items      []ItemResult
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// ItemResult is what happened to one note of the file, Name is the file in archive or the title in ENEX
+type ItemResult struct {
+	Index  int
+	Name   string
+	Status string
+	NoteID string
+	Error  string
+}
+
+var (
+	ErrFormat          = errors.New("file must be a zip of json or markdown notes or an enex export")
+	ErrJobNotFound     = errors.New("import job not found")
+	ErrJobNotResumable = errors.New("only failed import job can be resumed")
+	ErrDuplicate       = errors.New("same note already exists")
+	ErrExpired         = errors.New("note ttl has passed")
+	ErrInvalidNote     = errors.New("note has unknown kind or content type")
+	ErrPublishAt       = errors.New("publishAt must be before ttl")
+	ErrItems           = errors.New("items are allowed only in checklist with non-empty text")
+	ErrTooLarge        = errors.New("file must be at most 100 MB")
+	ErrTooManyEntries  = errors.New("file must have at most 10000 notes and attachments")
+	ErrItemTooLarge    = errors.New("note must be at most 10 MB")
+)
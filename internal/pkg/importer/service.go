@@ -0,0 +1,338 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"note-service/internal/app"
+	"note-service/internal/pkg/attachment"
+	"note-service/internal/pkg/export"
+	"note-service/internal/pkg/note"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type store interface {
+	CreateJob(job Job) (Job, error)
+	FindJobByID(id string) (Job, error)
+	UpdateJob(job Job) (Job, error)
+	TransitionJob(id, from, to string) (Job, error)
+	DeleteJob(id string) error
+	DeleteUserJobs(userID string) []Job
+}
+
+type noteService interface {
+	CreateNote(note note.Note) (note.Note, error)
+	GetNotes(userID string, opts note.ListOptions) ([]note.Note, error)
+}
+
+type uploader interface {
+	Upload(noteID, userID, name string, r io.Reader, size int64) (attachment.Attachment, error)
+}
+
+type Service struct {
+	store       store
+	notes       noteService
+	attachments uploader
+	dir         string
+	logger      *zap.Logger
+
+	mu          sync.Mutex
+	createHooks []func(n note.Note, jobID string)
+}
+
+// NewService keeps uploaded files in dir until their job is done or deleted
+func NewService(store store, notes noteService, attachments uploader, dir string, logger *zap.Logger) *Service {
+	return &Service{store: store, notes: notes, attachments: attachments, dir: dir, logger: logger}
+}
+
+// OnCreate registers hook which is called with every note created by an import job
+func (s *Service) OnCreate(hook func(n note.Note, jobID string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.createHooks = append(s.createHooks, hook)
+}
+
+// StartJob saves the file and imports it in background, files over MaxFileSize are rejected
+func (s *Service) StartJob(userID string, r io.Reader) (Job, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return Job{}, err
+	}
+	f, err := os.CreateTemp(s.dir, "import-*")
+	if err != nil {
+		return Job{}, err
+	}
+	written, err := io.Copy(f, io.LimitReader(r, MaxFileSize+1))
+	f.Close()
+	if err == nil && written > MaxFileSize {
+		err = ErrTooLarge
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return Job{}, err
+	}
+
+	src, err := openSource(f.Name())
+	if err != nil {
+		os.Remove(f.Name())
+		return Job{}, err
+	}
+	src.Close()
+
+	job, err := s.store.CreateJob(Job{UserID: userID, Path: f.Name(), Items: []ItemResult{}})
+	if err != nil {
+		os.Remove(f.Name())
+		return Job{}, err
+	}
+	go s.run(job)
+	return job, nil
+}
+
+// ResumeJob continues a failed job from the first item it hasn't imported
+func (s *Service) ResumeJob(id, userID string) (Job, error) {
+	if _, err := s.GetJob(id, userID); err != nil {
+		return Job{}, err
+	}
+	job, err := s.store.TransitionJob(id, JobFailed, JobPending)
+	if err != nil {
+		return Job{}, err
+	}
+	go s.run(job)
+	return job, nil
+}
+
+func (s *Service) run(job Job) {
+	job.Status = JobRunning
+	if _, err := s.store.UpdateJob(job); err != nil {
+		return
+	}
+
+	err := s.importItems(&job)
+	if _, findErr := s.store.FindJobByID(job.ID); findErr != nil {
+		// the job was deleted while running
+		os.Remove(job.Path)
+		return
+	}
+	now := time.Now().UTC()
+	job.FinishedAt = &now
+	if err != nil {
+		s.logger.Error("import job failed", zap.String("jobID", job.ID), zap.Int("item", job.Next), zap.Error(err))
+		job.Status = JobFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = JobDone
+		os.Remove(job.Path)
+		job.Path = ""
+	}
+	s.store.UpdateJob(job)
+}
+
+// importItems skips items imported before resume and saves the job after every item,
+// it stops with error only when the failure isn't the item's fault
+func (s *Service) importItems(job *Job) error {
+	src, err := openSource(job.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	existing, err := s.notes.GetNotes(job.UserID, note.ListOptions{Archived: true})
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, n := range existing {
+		seen[fingerprint(n)] = true
+	}
+
+	for i := 0; ; i++ {
+		it, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if i < job.Next {
+			continue
+		}
+
+		result, err := s.importItem(it, job, seen)
+		if err != nil {
+			return err
+		}
+		result.Index = i
+		job.Items = append(job.Items, result)
+		switch result.Status {
+		case ItemCreated:
+			job.Created++
+		case ItemSkipped:
+			job.Skipped++
+		case ItemFailed:
+			job.Failed++
+		}
+		job.Next = i + 1
+		if _, err := s.store.UpdateJob(*job); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Service) importItem(it item, job *Job, seen map[string]bool) (ItemResult, error) {
+	result := ItemResult{Name: it.Name}
+	failed := func(err error) (ItemResult, error) {
+		result.Status = ItemFailed
+		result.Error = err.Error()
+		return result, nil
+	}
+	if it.Err != nil {
+		return failed(it.Err)
+	}
+	n, err := documentToNote(it.Doc, job.UserID, time.Now())
+	if err != nil {
+		return failed(err)
+	}
+	key := fingerprint(n)
+	if seen[key] {
+		result.Status = ItemSkipped
+		result.Error = ErrDuplicate.Error()
+		return result, nil
+	}
+
+	n, err = s.notes.CreateNote(n)
+	if err != nil {
+		return ItemResult{}, err
+	}
+	s.mu.Lock()
+	hooks := s.createHooks
+	s.mu.Unlock()
+	for _, hook := range hooks {
+		hook(n, job.ID)
+	}
+	seen[key] = true
+	result.NoteID = n.ID
+	result.Status = ItemCreated
+
+	for _, f := range it.Attachments {
+		if err := s.upload(n.ID, job.UserID, f); err != nil {
+			if errors.Is(err, attachment.ErrTooLarge) || errors.Is(err, attachment.ErrQuotaExceeded) {
+				// the note is there, so the item is reported as created with the attachment error
+				result.Error = f.Name + ": " + err.Error()
+				continue
+			}
+			return ItemResult{}, err
+		}
+	}
+	return result, nil
+}
+
+func (s *Service) upload(noteID, userID string, f file) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = s.attachments.Upload(noteID, userID, f.Name, r, f.Size)
+	return err
+}
+
+// documentToNote takes the content and sharing of the document, the note belongs to the importing user
+func documentToNote(doc export.Document, userID string, now time.Time) (note.Note, error) {
+	n := note.Note{
+		UserID:      userID,
+		Subject:     doc.Subject,
+		Text:        doc.Text,
+		ContentType: doc.ContentType,
+		Kind:        doc.Kind,
+		TTL:         doc.TTL,
+		IsPublic:    doc.IsPublic,
+		PublishAt:   doc.PublishAt,
+		DueAt:       doc.DueAt,
+	}
+	if len(doc.PublicUsers) > 0 {
+		publicUsers := doc.PublicUsers
+		n.PublicUsers = &publicUsers
+	}
+	for _, i := range doc.Items {
+		if i.Text == "" {
+			return note.Note{}, ErrItems
+		}
+		n.Items = append(n.Items, note.ChecklistItem{Text: i.Text, Checked: i.Checked})
+	}
+
+	// the same checks as the note router makes for a new note
+	switch {
+	case n.ContentType != "" && n.ContentType != note.ContentTypePlain && n.ContentType != note.ContentTypeMarkdown,
+		n.Kind != "" && n.Kind != note.KindText && n.Kind != note.KindChecklist:
+		return note.Note{}, ErrInvalidNote
+	case n.Kind != note.KindChecklist && n.Text == "":
+		return note.Note{}, note.ErrEmptyNote
+	case n.Kind != note.KindChecklist && len(n.Items) > 0:
+		return note.Note{}, ErrItems
+	case n.PublishAt != nil && n.TTL != nil && *n.PublishAt >= *n.TTL:
+		return note.Note{}, ErrPublishAt
+	case n.TTL != nil && *n.TTL <= now.Unix():
+		return note.Note{}, ErrExpired
+	}
+	return n, nil
+}
+
+// fingerprint is equal for notes with the same content, it finds duplicates of already imported notes
+func fingerprint(n note.Note) string {
+	h := sha256.New()
+	kind := n.Kind
+	if kind == "" {
+		kind = note.KindText
+	}
+	for _, s := range []string{kind, n.Subject, n.Text} {
+		h.Write([]byte(strconv.Quote(s)))
+	}
+	for _, i := range n.Items {
+		h.Write([]byte(strconv.Quote(i.Text) + strconv.FormatBool(i.Checked)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *Service) GetJob(id, userID string) (Job, error) {
+	job, err := s.store.FindJobByID(id)
+	if err != nil {
+		return Job{}, err
+	}
+	if job.UserID != userID {
+		return Job{}, app.ErrNoAccess
+	}
+	return job, nil
+}
+
+// DeleteJob forgets the job, the notes it has imported stay
+func (s *Service) DeleteJob(id, userID string) error {
+	job, err := s.GetJob(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteJob(id); err != nil {
+		return err
+	}
+	if job.Status != JobRunning && job.Path != "" {
+		return os.Remove(job.Path)
+	}
+	return nil
+}
+
+// DeleteUserJobs drops every job of the user with its uploaded file, running jobs remove the file when they stop
+func (s *Service) DeleteUserJobs(userID string) {
+	for _, job := range s.store.DeleteUserJobs(userID) {
+		if job.Status == JobRunning || job.Path == "" {
+			continue
+		}
+		if err := os.Remove(job.Path); err != nil {
+			s.logger.Error("failed to remove import file", zap.String("jobID", job.ID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"note-service/internal/app"
+	"note-service/internal/pkg/attachment"
+	"note-service/internal/pkg/note"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type noteServiceMock struct {
+	sync.Mutex
+	notes     []note.Note
+	failAfter int
+}
+
+func (m *noteServiceMock) CreateNote(n note.Note) (note.Note, error) {
+	m.Lock()
+	defer m.Unlock()
+	if m.failAfter > 0 && len(m.notes) == m.failAfter {
+		m.failAfter = 0
+		return note.Note{}, errors.New("store is unavailable")
+	}
+	n.ID = strconv.Itoa(len(m.notes) + 1)
+	m.notes = append(m.notes, n)
+	return n, nil
+}
+
+func (m *noteServiceMock) GetNotes(userID string, opts note.ListOptions) ([]note.Note, error) {
+	m.Lock()
+	defer m.Unlock()
+	return append([]note.Note(nil), m.notes...), nil
+}
+
+type uploaderMock struct {
+	sync.Mutex
+	uploaded map[string]string
+}
+
+func (m *uploaderMock) Upload(noteID, userID, name string, r io.Reader, size int64) (attachment.Attachment, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return attachment.Attachment{}, err
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.uploaded[noteID+"/"+name] = string(data)
+	return attachment.Attachment{ID: name, NoteID: noteID}, nil
+}
+
+func newTestService(t *testing.T, notes *noteServiceMock) (*Service, *uploaderMock) {
+	uploads := &uploaderMock{uploaded: make(map[string]string)}
+	return NewService(NewInMemoryStore(), notes, uploads, t.TempDir(), zap.NewNop()), uploads
+}
+
+func zipFile(t *testing.T, files map[string]string, order ...string) io.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range order {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, files[name])
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return &buf
+}
+
+func waitJob(t *testing.T, service *Service, id, status string) Job {
+	var job Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = service.GetJob(id, "owner")
+		return err == nil && job.Status == status
+	}, time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestServiceImportZip(t *testing.T) {
+	notes := &noteServiceMock{notes: []note.Note{{ID: "old", UserID: "owner", Subject: "Existing", Text: "same", Kind: note.KindText}}}
+	service, uploads := newTestService(t, notes)
+	files := map[string]string{
+		"notes/shopping-11111111.md": "---\nid: 11111111\nsubject: Shopping\ncontentType: markdown\nkind: text\n" +
+			"isPublic: true\npublicUsers:\n  - reader\nttl: 4102444800\n---\n**milk**",
+		"attachments/shopping-11111111/photo.png": "photo",
+		"notes/todo-22222222.md":                  "---\nsubject: Todo\nkind: checklist\n---\n- [x] first\n- [ ] second\n",
+		"notes/existing-33333333.json":            `{"subject": "Existing", "text": "same", "kind": "text"}`,
+		"notes/broken-44444444.json":              `{"subject": `,
+		"notes/expired-55555555.json":             `{"subject": "Expired", "text": "old", "ttl": 1}`,
+		"Plain.md":                                "no front matter",
+		"notes/again-66666666.md":                 "---\nsubject: Shopping\ncontentType: markdown\n---\n**milk**",
+	}
+	var hooked []string
+	service.OnCreate(func(n note.Note, jobID string) {
+		hooked = append(hooked, jobID+"/"+n.ID)
+	})
+	job, err := service.StartJob("owner", zipFile(t, files, "notes/shopping-11111111.md", "attachments/shopping-11111111/photo.png",
+		"notes/todo-22222222.md", "notes/existing-33333333.json", "notes/broken-44444444.json", "notes/expired-55555555.json",
+		"Plain.md", "notes/again-66666666.md"))
+	require.NoError(t, err)
+
+	job = waitJob(t, service, job.ID, JobDone)
+	require.Equal(t, 3, job.Created)
+	require.Equal(t, 2, job.Skipped)
+	require.Equal(t, 2, job.Failed)
+	require.Len(t, job.Items, 7)
+	require.Equal(t, ItemFailed, job.Items[3].Status)
+	require.Equal(t, ErrExpired.Error(), job.Items[4].Error)
+	require.Equal(t, ErrDuplicate.Error(), job.Items[6].Error)
+	require.NoFileExists(t, job.Path)
+
+	shopping := notes.notes[1]
+	require.Equal(t, "owner", shopping.UserID)
+	require.Equal(t, "**milk**", shopping.Text)
+	require.Equal(t, note.ContentTypeMarkdown, shopping.ContentType)
+	require.Equal(t, []string{"reader"}, *shopping.PublicUsers)
+	require.Equal(t, "photo", uploads.uploaded[shopping.ID+"/photo.png"])
+
+	todo := notes.notes[2]
+	require.Equal(t, []note.ChecklistItem{{Text: "first", Checked: true}, {Text: "second"}}, todo.Items)
+	require.Equal(t, "Plain", notes.notes[3].Subject)
+	require.Equal(t, []string{job.ID + "/" + shopping.ID, job.ID + "/" + todo.ID, job.ID + "/" + notes.notes[3].ID}, hooked)
+}
+
+func TestServiceImportENEX(t *testing.T) {
+	notes := &noteServiceMock{}
+	service, uploads := newTestService(t, notes)
+	enex := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20221001T120000Z" application="Evernote">
+  <note>
+    <title>Trip</title>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><div>Pack&nbsp;bags</div><div><en-todo checked="true"/>tickets</div><div><en-todo/>hotel<br/></div><en-media type="image/png" hash="1"/></en-note>]]></content>
+    <created>20221001T120000Z</created>
+    <resource>
+      <data encoding="base64">cGhvdG8=
+      </data>
+      <mime>image/png</mime>
+    </resource>
+  </note>
+  <note>
+    <title>Empty</title>
+    <content><![CDATA[<en-note></en-note>]]></content>
+  </note>
+</en-export>`
+	job, err := service.StartJob("owner", strings.NewReader(enex))
+	require.NoError(t, err)
+
+	job = waitJob(t, service, job.ID, JobDone)
+	require.Equal(t, 1, job.Created)
+	require.Equal(t, 1, job.Failed)
+	require.Equal(t, "Empty", job.Items[1].Name)
+	require.Equal(t, "Pack bags\n[x] tickets\n[ ] hotel", notes.notes[0].Text)
+	require.Equal(t, "photo", uploads.uploaded["1/attachment-1.png"])
+}
+
+func TestServiceResumeJob(t *testing.T) {
+	notes := &noteServiceMock{failAfter: 1}
+	service, _ := newTestService(t, notes)
+	files := map[string]string{"a.md": "first", "b.md": "second", "c.md": "third"}
+	job, err := service.StartJob("owner", zipFile(t, files, "a.md", "b.md", "c.md"))
+	require.NoError(t, err)
+
+	job = waitJob(t, service, job.ID, JobFailed)
+	require.Equal(t, 1, job.Next)
+	require.Equal(t, "store is unavailable", job.Error)
+
+	_, err = service.ResumeJob(job.ID, "stranger")
+	require.ErrorIs(t, err, app.ErrNoAccess)
+
+	_, err = service.ResumeJob(job.ID, "owner")
+	require.NoError(t, err)
+	job = waitJob(t, service, job.ID, JobDone)
+	require.Equal(t, 3, job.Created)
+	require.Len(t, notes.notes, 3)
+
+	_, err = service.ResumeJob(job.ID, "owner")
+	require.ErrorIs(t, err, ErrJobNotResumable)
+
+	require.NoError(t, service.DeleteJob(job.ID, "owner"))
+	_, err = service.GetJob(job.ID, "owner")
+	require.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestServiceDeleteUserJobs(t *testing.T) {
+	service, _ := newTestService(t, &noteServiceMock{failAfter: 1})
+	files := map[string]string{"a.md": "first", "b.md": "second"}
+	job, err := service.StartJob("owner", zipFile(t, files, "a.md", "b.md"))
+	require.NoError(t, err)
+	job = waitJob(t, service, job.ID, JobFailed)
+	require.FileExists(t, job.Path)
+
+	service.DeleteUserJobs("owner")
+	require.NoFileExists(t, job.Path)
+	_, err = service.GetJob(job.ID, "owner")
+	require.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestServiceResumeJobOnce(t *testing.T) {
+	notes := &noteServiceMock{failAfter: 1}
+	service, _ := newTestService(t, notes)
+	files := map[string]string{"a.md": "first", "b.md": "second", "c.md": "third"}
+	job, err := service.StartJob("owner", zipFile(t, files, "a.md", "b.md", "c.md"))
+	require.NoError(t, err)
+	job = waitJob(t, service, job.ID, JobFailed)
+
+	var wg sync.WaitGroup
+	results := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.ResumeJob(job.ID, "owner")
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+	resumed := 0
+	for err := range results {
+		if err == nil {
+			resumed++
+		} else {
+			require.ErrorIs(t, err, ErrJobNotResumable)
+		}
+	}
+	require.Equal(t, 1, resumed)
+
+	job = waitJob(t, service, job.ID, JobDone)
+	require.Equal(t, 3, job.Created)
+	notes.Lock()
+	defer notes.Unlock()
+	require.Len(t, notes.notes, 3, "no note is imported twice")
+}
+
+func TestServiceStartJobFormat(t *testing.T) {
+	service, _ := newTestService(t, &noteServiceMock{})
+	_, err := service.StartJob("owner", strings.NewReader("just text"))
+	require.ErrorIs(t, err, ErrFormat)
+}
+
+func TestServiceImportLimits(t *testing.T) {
+	notes := &noteServiceMock{}
+	service, _ := newTestService(t, notes)
+	files := map[string]string{
+		"big.md":      strings.Repeat("a", maxItemSize+1),
+		"items.json":  `{"subject": "Items", "text": "text", "kind": "text", "items": [{"text": "first"}]}`,
+		"empty.json":  `{"subject": "Empty item", "kind": "checklist", "items": [{"text": ""}]}`,
+		"window.json": `{"subject": "Window", "text": "text", "publishAt": 4102444800, "ttl": 4102444800}`,
+		"fine.md":     "fine",
+	}
+	job, err := service.StartJob("owner", zipFile(t, files, "big.md", "items.json", "empty.json", "window.json", "fine.md"))
+	require.NoError(t, err)
+
+	job = waitJob(t, service, job.ID, JobDone)
+	require.Equal(t, 1, job.Created)
+	require.Equal(t, ErrItemTooLarge.Error(), job.Items[0].Error)
+	require.Equal(t, ErrItems.Error(), job.Items[1].Error)
+	require.Equal(t, ErrItems.Error(), job.Items[2].Error)
+	require.Equal(t, ErrPublishAt.Error(), job.Items[3].Error)
+
+	order := make([]string, maxEntries+1)
+	for i := range order {
+		order[i] = strconv.Itoa(i) + ".md"
+	}
+	_, err = service.StartJob("owner", zipFile(t, map[string]string{}, order...))
+	require.ErrorIs(t, err, ErrTooManyEntries)
+}
@@ -0,0 +1,187 @@
+package importer
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"note-service/internal/pkg/export"
+	"note-service/internal/pkg/note"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// item is one note read from the file, Err is set when only this note can't be read
+type item struct {
+	Name        string
+	Doc         export.Document
+	Attachments []file
+	Err         error
+}
+
+type file struct {
+	Name string
+	Size int64
+	Open func() (io.ReadCloser, error)
+}
+
+// source reads notes of the file in the same order every time, so a job resumes by skipping items.
+// Next returns io.EOF after the last item.
+type source interface {
+	Next() (item, error)
+	Close() error
+}
+
+// openSource detects the format by the file content
+func openSource(p string) (source, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	head, _ := bufio.NewReader(f).Peek(512)
+	f.Close()
+
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return openZip(p)
+	case bytes.Contains(head, []byte("<en-export")):
+		return openENEX(p)
+	}
+	return nil, ErrFormat
+}
+
+// zipSource reads json and markdown files of our export or any zip of markdown files.
+// Attachments are taken from attachments/<note file name without extension>/.
+type zipSource struct {
+	zr          *zip.ReadCloser
+	notes       []*zip.File
+	attachments map[string][]*zip.File
+	next        int
+}
+
+func openZip(p string) (*zipSource, error) {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, ErrFormat
+	}
+	if len(zr.File) > maxEntries {
+		zr.Close()
+		return nil, ErrTooManyEntries
+	}
+	s := &zipSource{zr: zr, attachments: make(map[string][]*zip.File)}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if dir := path.Dir(f.Name); strings.HasPrefix(dir, "attachments/") {
+			dir = strings.TrimPrefix(dir, "attachments/")
+			s.attachments[dir] = append(s.attachments[dir], f)
+			continue
+		}
+		switch strings.ToLower(path.Ext(f.Name)) {
+		case ".json", ".md", ".markdown":
+			s.notes = append(s.notes, f)
+		}
+	}
+	return s, nil
+}
+
+func (s *zipSource) Next() (item, error) {
+	if s.next == len(s.notes) {
+		return item{}, io.EOF
+	}
+	f := s.notes[s.next]
+	s.next++
+
+	res := item{Name: f.Name}
+	data, err := readZipFile(f)
+	if err != nil {
+		res.Err = err
+		return res, nil
+	}
+	if strings.ToLower(path.Ext(f.Name)) == ".json" {
+		err = json.Unmarshal(data, &res.Doc)
+	} else {
+		res.Doc, err = parseMarkdown(f.Name, data)
+	}
+	if err != nil {
+		res.Err = err
+		return res, nil
+	}
+
+	base := strings.TrimSuffix(path.Base(f.Name), path.Ext(f.Name))
+	for _, a := range s.attachments[base] {
+		a := a
+		res.Attachments = append(res.Attachments, file{Name: path.Base(a.Name), Size: int64(a.UncompressedSize64), Open: a.Open})
+	}
+	return res, nil
+}
+
+func (s *zipSource) Close() error {
+	return s.zr.Close()
+}
+
+// readZipFile doesn't trust the size in the archive header, a file is read only up to maxItemSize
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxItemSize {
+		return nil, ErrItemTooLarge
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, maxItemSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxItemSize {
+		return nil, ErrItemTooLarge
+	}
+	return data, nil
+}
+
+// parseMarkdown reads YAML front matter if the file has it, otherwise the file name is the subject
+func parseMarkdown(name string, data []byte) (export.Document, error) {
+	doc := export.Document{ContentType: note.ContentTypeMarkdown}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if strings.HasPrefix(text, "---\n") {
+		rest := strings.TrimPrefix(text, "---\n")
+		end := strings.Index(rest, "\n---\n")
+		if end < 0 {
+			return export.Document{}, errors.New("front matter is not closed")
+		}
+		if err := yaml.Unmarshal([]byte(rest[:end+1]), &doc); err != nil {
+			return export.Document{}, err
+		}
+		text = rest[end+len("\n---\n"):]
+	} else {
+		doc.Subject = strings.TrimSuffix(path.Base(name), path.Ext(name))
+	}
+
+	if doc.Kind == note.KindChecklist {
+		doc.Items = parseChecklist(text)
+	} else {
+		doc.Text = text
+	}
+	return doc, nil
+}
+
+// parseChecklist reads a markdown task list written by export, other lines are ignored
+func parseChecklist(text string) []export.Item {
+	var items []export.Item
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"- [ ] ", "- [x] ", "- [X] "} {
+			if strings.HasPrefix(line, prefix) {
+				items = append(items, export.Item{Text: strings.TrimPrefix(line, prefix), Checked: prefix != "- [ ] "})
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// jobs map[jobId]Job
+type InMemoryStore struct {
+	sync.RWMutex
+	jobs map[string]Job
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{jobs: make(map[string]Job)}
+}
+
+func (store *InMemoryStore) CreateJob(job Job) (Job, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	job.ID = uuid.NewString()
+	job.Status = JobPending
+	job.CreatedAt = time.Now().UTC()
+	store.jobs[job.ID] = job
+	return job, nil
+}
+
+func (store *InMemoryStore) FindJobByID(id string) (Job, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	if job, ok := store.jobs[id]; ok {
+		return job, nil
+	}
+	return Job{}, ErrJobNotFound
+}
+
+func (store *InMemoryStore) UpdateJob(job Job) (Job, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	if _, ok := store.jobs[job.ID]; !ok {
+		return Job{}, ErrJobNotFound
+	}
+	store.jobs[job.ID] = job
+	return job, nil
+}
+
+// TransitionJob moves the job from status from to status to and clears the result of the last run,
+// it returns ErrJobNotResumable when the job isn't in status from, so only one caller wins
+func (store *InMemoryStore) TransitionJob(id, from, to string) (Job, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	job, ok := store.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	if job.Status != from {
+		return Job{}, ErrJobNotResumable
+	}
+	job.Status = to
+	job.Error = ""
+	job.FinishedAt = nil
+	store.jobs[id] = job
+	return job, nil
+}
+
+func (store *InMemoryStore) DeleteJob(id string) error {
+	store.Lock()
+	defer store.Unlock()
+
+	if _, ok := store.jobs[id]; !ok {
+		return ErrJobNotFound
+	}
+	delete(store.jobs, id)
+	return nil
+}
+
+// DeleteUserJobs removes every job of the user and returns them
+func (store *InMemoryStore) DeleteUserJobs(userID string) []Job {
+	store.Lock()
+	defer store.Unlock()
+
+	var removed []Job
+	for id, job := range store.jobs {
+		if job.UserID == userID {
+			delete(store.jobs, id)
+			removed = append(removed, job)
+		}
+	}
+	return removed
+}
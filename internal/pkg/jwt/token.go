@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -10,14 +11,40 @@ import (
 var mySigningKey = []byte("BlaBlaBla123")
 var ErrJwtParse = errors.New("jwt parse error")
 
+// UserClaims limit the token to Scopes, a token without scopes has full access
 type UserClaims struct {
-	UserID string `json:"userId"`
+	UserID     string   `json:"userId"`
+	Generation int      `json:"gen"`
+	Scopes     []string `json:"scopes,omitempty"`
 	jwt.StandardClaims
 }
 
-func CreateToken(userid string) (string, error) {
+// generations counts RevokeTokens calls per user, only tokens of the current generation are valid
+var generations = struct {
+	sync.RWMutex
+	m map[string]int
+}{m: make(map[string]int)}
+
+// RevokeTokens invalidates every token issued to the user so far
+func RevokeTokens(userID string) {
+	generations.Lock()
+	defer generations.Unlock()
+
+	generations.m[userID]++
+}
+
+func generation(userID string) int {
+	generations.RLock()
+	defer generations.RUnlock()
+
+	return generations.m[userID]
+}
+
+func CreateToken(userid string, scopes ...string) (string, error) {
 	claims := UserClaims{
 		userid,
+		generation(userid),
+		scopes,
 		jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(10 * time.Minute).Unix(),
 			Issuer:    "test",
@@ -28,15 +55,23 @@ func CreateToken(userid string) (string, error) {
 }
 
 func ParseToken(tokenString string) (string, error) {
+	claims, err := ParseClaims(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
+}
+
+func ParseClaims(tokenString string) (UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return mySigningKey, nil
 	})
 	if err != nil {
-		return "", ErrJwtParse
+		return UserClaims{}, ErrJwtParse
 	}
 
-	if claims, ok := token.Claims.(*UserClaims); ok && token.Valid {
-		return claims.UserID, nil
+	if claims, ok := token.Claims.(*UserClaims); ok && token.Valid && claims.Generation == generation(claims.UserID) {
+		return *claims, nil
 	}
-	return "", ErrJwtParse
+	return UserClaims{}, ErrJwtParse
 }
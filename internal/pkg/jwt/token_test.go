@@ -0,0 +1,43 @@
+package jwt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeTokens(t *testing.T) {
+	old, err := CreateToken("revoked-user")
+	require.NoError(t, err)
+	other, err := CreateToken("other-user")
+	require.NoError(t, err)
+
+	RevokeTokens("revoked-user")
+
+	_, err = ParseToken(old)
+	require.ErrorIs(t, err, ErrJwtParse)
+	userID, err := ParseToken(other)
+	require.NoError(t, err)
+	require.Equal(t, "other-user", userID)
+
+	fresh, err := CreateToken("revoked-user")
+	require.NoError(t, err)
+	userID, err = ParseToken(fresh)
+	require.NoError(t, err)
+	require.Equal(t, "revoked-user", userID)
+}
+
+func TestTokenScopes(t *testing.T) {
+	token, err := CreateToken("scoped-user", "notes:read")
+	require.NoError(t, err)
+	claims, err := ParseClaims(token)
+	require.NoError(t, err)
+	require.Equal(t, "scoped-user", claims.UserID)
+	require.Equal(t, []string{"notes:read"}, claims.Scopes)
+
+	token, err = CreateToken("scoped-user")
+	require.NoError(t, err)
+	claims, err = ParseClaims(token)
+	require.NoError(t, err)
+	require.Empty(t, claims.Scopes)
+}
@@ -0,0 +1,20 @@
+package mail
+
+import "go.uber.org/zap"
+
+// Log writes messages to the log instead of sending them, it is meant for development
+type Log struct {
+	logger *zap.Logger
+}
+
+func NewLog(logger *zap.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (l *Log) Send(msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	l.logger.Info("mail", zap.String("to", msg.To), zap.String("subject", msg.Subject), zap.String("body", msg.Body))
+	return nil
+}
@@ -0,0 +1,26 @@
+package mail
+
+import (
+	"errors"
+	"strings"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends plain text messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+var ErrHeader = errors.New("recipient and subject must be a single line")
+
+func (m Message) validate() error {
+	if m.To == "" || strings.ContainsAny(m.To+m.Subject, "\r\n") {
+		return ErrHeader
+	}
+	return nil
+}
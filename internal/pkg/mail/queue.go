@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+var ErrQueueFull = errors.New("mail queue is full, message was dropped")
+
+// Queue sends messages through next in its own goroutine, so the caller doesn't wait for SMTP
+// and the response time doesn't depend on whether a message was sent.
+// When size messages are waiting, new ones are dropped with ErrQueueFull.
+type Queue struct {
+	next     Mailer
+	messages chan Message
+	logger   *zap.Logger
+	done     chan struct{}
+	stop     sync.Once
+}
+
+func NewQueue(next Mailer, size int, logger *zap.Logger) *Queue {
+	return &Queue{next: next, messages: make(chan Message, size), logger: logger, done: make(chan struct{})}
+}
+
+// Send checks the headers and queues msg, a full queue drops it with a log line
+func (q *Queue) Send(msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	select {
+	case q.messages <- msg:
+		return nil
+	default:
+		q.logger.Error("failed to queue mail", zap.String("subject", msg.Subject), zap.Error(ErrQueueFull))
+		return ErrQueueFull
+	}
+}
+
+// Run sends messages until Stop, messages still waiting then are dropped
+func (q *Queue) Run() error {
+	for {
+		select {
+		case <-q.done:
+			return nil
+		case msg := <-q.messages:
+			if err := q.next.Send(msg); err != nil {
+				q.logger.Error("failed to send mail", zap.String("subject", msg.Subject), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (q *Queue) Stop() {
+	q.stop.Do(func() { close(q.done) })
+}
@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type blockingMailer struct {
+	release chan struct{}
+	got     chan Message
+}
+
+func (m *blockingMailer) Send(msg Message) error {
+	<-m.release
+	m.got <- msg
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	next := &blockingMailer{release: make(chan struct{}), got: make(chan Message, 10)}
+	q := NewQueue(next, 1, zap.NewNop())
+	go q.Run()
+	defer q.Stop()
+
+	require.ErrorIs(t, q.Send(Message{To: "a@example.com\r\nBcc: b@example.com"}), ErrHeader)
+	require.NoError(t, q.Send(Message{To: "1@example.com"}))
+	require.Eventually(t, func() bool { return len(q.messages) == 0 }, time.Second, time.Millisecond)
+	// the first message is being sent, one more fits the queue
+	require.NoError(t, q.Send(Message{To: "2@example.com"}))
+	require.ErrorIs(t, q.Send(Message{To: "3@example.com"}), ErrQueueFull)
+
+	close(next.release)
+	require.Equal(t, "1@example.com", (<-next.got).To)
+	require.Equal(t, "2@example.com", (<-next.got).To)
+	q.Stop()
+}
@@ -0,0 +1,69 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SMTPConfig struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+type SMTP struct {
+	config SMTPConfig
+}
+
+func NewSMTP(config SMTPConfig) *SMTP {
+	return &SMTP{config: config}
+}
+
+// Send uses STARTTLS when the server offers it, PLAIN auth is sent only over TLS or to localhost
+func (s *SMTP) Send(msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		host, _, err := net.SplitHostPort(s.config.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, host)
+	}
+	data, err := s.format(msg)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.config.Addr, auth, s.config.From, []string{msg.To}, data)
+}
+
+func (s *SMTP) format(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@note-service>\r\n", uuid.NewString())
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,105 @@
+package mail
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type received struct {
+	from string
+	to   []string
+	auth string
+	data string
+}
+
+// fakeSMTP accepts one session and passes what it got to the channel
+func fakeSMTP(t *testing.T) (string, <-chan received) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	res := make(chan received, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		c := textproto.NewConn(conn)
+		var r received
+		c.PrintfLine("220 localhost ESMTP fake")
+		for {
+			line, err := c.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO":
+				c.PrintfLine("250-localhost")
+				c.PrintfLine("250 AUTH PLAIN")
+			case "AUTH":
+				r.auth = line
+				c.PrintfLine("235 2.7.0 Authentication successful")
+			case "MAIL":
+				r.from = line
+				c.PrintfLine("250 OK")
+			case "RCPT":
+				r.to = append(r.to, line)
+				c.PrintfLine("250 OK")
+			case "DATA":
+				c.PrintfLine("354 go ahead")
+				data, err := c.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				r.data = string(data)
+				c.PrintfLine("250 OK")
+			case "QUIT":
+				c.PrintfLine("221 bye")
+				res <- r
+				return
+			default:
+				c.PrintfLine("502 unknown command")
+			}
+		}
+	}()
+	return l.Addr().String(), res
+}
+
+func TestSMTPSend(t *testing.T) {
+	addr, res := fakeSMTP(t)
+	mailer := NewSMTP(SMTPConfig{Addr: addr, Username: "user", Password: "secret", From: "notes@example.com"})
+
+	err := mailer.Send(Message{To: "alice@example.com", Subject: "Сброс пароля", Body: "Ваш код: 42"})
+	require.NoError(t, err)
+
+	r := <-res
+	require.Equal(t, "MAIL FROM:<notes@example.com>", r.from)
+	require.Equal(t, []string{"RCPT TO:<alice@example.com>"}, r.to)
+	require.True(t, strings.HasPrefix(r.auth, "AUTH PLAIN "), r.auth)
+
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(r.data)))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, "Сброс пароля", subject)
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	require.Equal(t, "Ваш код: 42", strings.TrimRight(string(body), "\r\n"))
+}
+
+func TestSendRejectsHeaderInjection(t *testing.T) {
+	mailer := NewSMTP(SMTPConfig{Addr: "127.0.0.1:1", From: "notes@example.com"})
+	err := mailer.Send(Message{To: "alice@example.com\r\nBcc: eve@example.com", Subject: "hi"})
+	require.ErrorIs(t, err, ErrHeader)
+}
@@ -0,0 +1,38 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	converter = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	policy    = newPolicy()
+)
+
+// newPolicy allows user generated content plus disabled checkboxes of task lists
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("type").Matching(bluemonday.Paragraph).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
+
+// Render converts CommonMark with GFM tables, task lists, strikethrough and autolinks
+// into html which is safe to embed: scripts, event handlers and javascript links are removed
+func Render(text string) (string, error) {
+	var buf bytes.Buffer
+	if err := converter.Convert([]byte(text), &buf); err != nil {
+		return "", err
+	}
+	return policy.Sanitize(buf.String()), nil
+}
+
+// RenderPlain escapes plain text and keeps its line breaks
+func RenderPlain(text string) string {
+	return "<pre>" + html.EscapeString(text) + "</pre>"
+}
@@ -0,0 +1,54 @@
+package markdown
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:     "should render commonmark",
+			text:     "# Title\n\n**bold** and [link](https://example.com)",
+			contains: []string{"<h1>Title</h1>", "<strong>bold</strong>", `href="https://example.com"`},
+		},
+		{
+			name:     "should render gfm table",
+			text:     "| a | b |\n|---|---|\n| 1 | 2 |",
+			contains: []string{"<table>", "<th>a</th>", "<td>2</td>"},
+		},
+		{
+			name:     "should render task list",
+			text:     "- [x] done\n- [ ] todo",
+			contains: []string{`<input checked="" disabled="" type="checkbox"`, `<input disabled="" type="checkbox"`},
+		},
+		{
+			name:        "should strip scripts and event handlers",
+			text:        "<script>alert(1)</script>\n\n<img src=\"x.png\" onerror=\"alert(1)\">\n\n[x](javascript:alert(1))",
+			notContains: []string{"<script", "onerror", "javascript:"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := Render(tt.text)
+			require.NoError(t, err)
+			for _, s := range tt.contains {
+				require.Contains(t, actual, s)
+			}
+			for _, s := range tt.notContains {
+				require.NotContains(t, actual, s)
+			}
+		})
+	}
+}
+
+func TestRenderPlain(t *testing.T) {
+	require.Equal(t, "<pre>a &lt;b&gt;\nc</pre>", RenderPlain("a <b>\nc"))
+}
@@ -0,0 +1,83 @@
+package note
+
+import (
+	"errors"
+	"note-service/internal/app"
+)
+
+// Batch runs operations of the user and returns a result for each of them.
+// Without atomic every operation is applied on its own and may fail alone.
+// With atomic the store applies all operations or none, then the failed operation gets its error
+// and the rest get ErrBatchAborted.
+func (s *Service) Batch(userID string, ops []BatchOp, atomic bool) []BatchResult {
+	results := make([]BatchResult, len(ops))
+	prepare := func(op BatchOp, current Note) (Note, error) {
+		return prepareBatchOp(userID, op, current)
+	}
+
+	if !atomic {
+		for i, op := range ops {
+			notes, err := s.store.ApplyBatch([]BatchOp{op}, prepare)
+			var batchErr *BatchError
+			if errors.As(err, &batchErr) {
+				err = batchErr.Err
+			}
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			results[i].Note = notes[0]
+			s.forgetBatchOp(op)
+		}
+		return results
+	}
+
+	notes, err := s.store.ApplyBatch(ops, prepare)
+	if err != nil {
+		var batchErr *BatchError
+		for i := range results {
+			results[i].Err = ErrBatchAborted
+		}
+		if errors.As(err, &batchErr) {
+			results[batchErr.Index].Err = batchErr.Err
+		}
+		return results
+	}
+	for i, op := range ops {
+		results[i].Note = notes[i]
+		s.forgetBatchOp(op)
+	}
+	return results
+}
+
+// prepareBatchOp applies the same rules as single note methods
+func prepareBatchOp(userID string, op BatchOp, current Note) (Note, error) {
+	note := op.Note
+	note.UserID = userID
+	switch op.Type {
+	case OpCreate:
+		return prepareCreate(note), nil
+	case OpUpdate:
+		return prepareUpdate(current, note)
+	case OpDelete:
+		if current.UserID != userID {
+			return Note{}, app.ErrNoAccess
+		}
+		return current, nil
+	case OpShare:
+		if current.UserID != userID {
+			return Note{}, app.ErrNoAccess
+		}
+		current.IsPublic = note.IsPublic
+		current.PublicUsers = note.PublicUsers
+		return current, nil
+	default:
+		return Note{}, ErrUnknownOp
+	}
+}
+
+func (s *Service) forgetBatchOp(op BatchOp) {
+	if op.Type != OpCreate {
+		s.rendered.delete(op.Note.ID)
+	}
+}
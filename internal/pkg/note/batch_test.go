@@ -0,0 +1,87 @@
+package note
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"note-service/internal/app"
+	"testing"
+)
+
+func TestServiceBatch(t *testing.T) {
+	newService := func(t *testing.T) (*Service, *InMemoryStore, Note, Note) {
+		store := NewInMemoryStore(zap.NewNop())
+		service := NewService(store)
+		own, err := service.CreateNote(Note{UserID: "123-123", Text: "own"})
+		require.NoError(t, err)
+		other, err := service.CreateNote(Note{UserID: "321-321", Text: "other"})
+		require.NoError(t, err)
+		return service, store, own, other
+	}
+	users := []string{"321-321"}
+
+	t.Run("should apply every operation on its own", func(t *testing.T) {
+		service, store, own, other := newService(t)
+		results := service.Batch("123-123", []BatchOp{
+			{Type: OpCreate, Note: Note{Text: "new"}},
+			{Type: OpDelete, Note: Note{ID: other.ID}},
+			{Type: OpShare, Note: Note{ID: own.ID, PublicUsers: &users}},
+			{Type: "archive", Note: Note{ID: own.ID}},
+		}, false)
+
+		require.NoError(t, results[0].Err)
+		require.Equal(t, "new", results[0].Note.Text)
+		require.Equal(t, "123-123", results[0].Note.UserID)
+		require.Equal(t, KindText, results[0].Note.Kind)
+		require.ErrorIs(t, results[1].Err, app.ErrNoAccess)
+		require.NoError(t, results[2].Err)
+		require.Equal(t, &users, results[2].Note.PublicUsers)
+		require.ErrorIs(t, results[3].Err, ErrUnknownOp)
+
+		notes, err := store.GetNotes("123-123", "")
+		require.NoError(t, err)
+		require.Len(t, notes, 2)
+	})
+
+	t.Run("should apply all operations or none", func(t *testing.T) {
+		service, store, own, other := newService(t)
+		results := service.Batch("123-123", []BatchOp{
+			{Type: OpCreate, Note: Note{Text: "new"}},
+			{Type: OpUpdate, Note: Note{ID: own.ID, Text: "changed"}},
+			{Type: OpDelete, Note: Note{ID: other.ID}},
+		}, true)
+
+		require.ErrorIs(t, results[0].Err, ErrBatchAborted)
+		require.ErrorIs(t, results[1].Err, ErrBatchAborted)
+		require.ErrorIs(t, results[2].Err, app.ErrNoAccess)
+		notes, err := store.GetNotes("123-123", "")
+		require.NoError(t, err)
+		require.Equal(t, []Note{own}, notes)
+
+		results = service.Batch("123-123", []BatchOp{
+			{Type: OpCreate, Note: Note{Text: "new"}},
+			{Type: OpUpdate, Note: Note{ID: own.ID, Text: "changed"}},
+			{Type: OpDelete, Note: Note{ID: own.ID}},
+		}, true)
+		for _, r := range results {
+			require.NoError(t, r.Err)
+		}
+		require.Equal(t, "changed", results[1].Note.Text)
+		notes, err = store.GetNotes("123-123", "")
+		require.NoError(t, err)
+		require.Len(t, notes, 1)
+		require.Equal(t, "new", notes[0].Text)
+	})
+
+	t.Run("should see notes deleted earlier in the batch", func(t *testing.T) {
+		service, _, own, _ := newService(t)
+		results := service.Batch("123-123", []BatchOp{
+			{Type: OpDelete, Note: Note{ID: own.ID}},
+			{Type: OpUpdate, Note: Note{ID: own.ID, Text: "changed"}},
+		}, true)
+		require.ErrorIs(t, results[1].Err, ErrNoteNotFound)
+
+		var batchErr *BatchError
+		require.False(t, errors.As(results[1].Err, &batchErr))
+	})
+}
@@ -0,0 +1,89 @@
+package note
+
+import (
+	"note-service/internal/app"
+
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+// AddItem appends an unchecked item to the checklist
+func (s *Service) AddItem(noteID, userID, text string) (Note, error) {
+	return s.updateChecklist(noteID, userID, func(items []ChecklistItem) ([]ChecklistItem, error) {
+		return append(items, ChecklistItem{ID: uuid.NewString(), Text: text}), nil
+	})
+}
+
+func (s *Service) ToggleItem(noteID, itemID, userID string) (Note, error) {
+	return s.updateChecklist(noteID, userID, func(items []ChecklistItem) ([]ChecklistItem, error) {
+		i := findItem(items, itemID)
+		if i < 0 {
+			return nil, ErrItemNotFound
+		}
+		items[i].Checked = !items[i].Checked
+		return items, nil
+	})
+}
+
+// ReorderItems puts items in the order of itemIDs, which must contain every item exactly once
+func (s *Service) ReorderItems(noteID, userID string, itemIDs []string) (Note, error) {
+	return s.updateChecklist(noteID, userID, func(items []ChecklistItem) ([]ChecklistItem, error) {
+		if len(itemIDs) != len(items) {
+			return nil, ErrItemOrder
+		}
+		res := make([]ChecklistItem, 0, len(items))
+		for _, id := range itemIDs {
+			i := findItem(items, id)
+			if i < 0 || findItem(res, id) >= 0 {
+				return nil, ErrItemOrder
+			}
+			res = append(res, items[i])
+		}
+		return res, nil
+	})
+}
+
+func (s *Service) DeleteItem(noteID, itemID, userID string) (Note, error) {
+	return s.updateChecklist(noteID, userID, func(items []ChecklistItem) ([]ChecklistItem, error) {
+		i := findItem(items, itemID)
+		if i < 0 {
+			return nil, ErrItemNotFound
+		}
+		return slices.Delete(items, i, i+1), nil
+	})
+}
+
+// updateChecklist lets only the owner change items
+func (s *Service) updateChecklist(noteID, userID string, update func(items []ChecklistItem) ([]ChecklistItem, error)) (Note, error) {
+	n, err := s.store.FindNoteByID(noteID)
+	if err != nil {
+		return Note{}, err
+	}
+	if n.UserID != userID {
+		return Note{}, app.ErrNoAccess
+	}
+	return s.store.UpdateChecklist(noteID, update)
+}
+
+func findItem(items []ChecklistItem, id string) int {
+	return slices.IndexFunc(items, func(item ChecklistItem) bool {
+		return item.ID == id
+	})
+}
+
+// newItems numbers positions in order and keeps the id of an item sent with the id of one of current items,
+// other items get new ids
+func newItems(items, current []ChecklistItem) []ChecklistItem {
+	if items == nil {
+		return nil
+	}
+	res := make([]ChecklistItem, len(items))
+	for i, item := range items {
+		if item.ID == "" || findItem(current, item.ID) < 0 || findItem(res[:i], item.ID) >= 0 {
+			item.ID = uuid.NewString()
+		}
+		item.Position = i
+		res[i] = item
+	}
+	return res
+}
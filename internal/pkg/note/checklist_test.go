@@ -0,0 +1,136 @@
+package note
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"note-service/internal/app"
+	"testing"
+)
+
+func TestChecklistItems(t *testing.T) {
+	newChecklist := func(t *testing.T) (*Service, Note) {
+		service := NewService(NewInMemoryStore(zap.NewNop()))
+		n, err := service.CreateNote(Note{
+			UserID: "123-123",
+			Kind:   KindChecklist,
+			Items:  []ChecklistItem{{Text: "milk"}, {Text: "bread"}},
+		})
+		require.NoError(t, err)
+		return service, n
+	}
+
+	t.Run("should give items ids and positions on create", func(t *testing.T) {
+		_, n := newChecklist(t)
+		require.Len(t, n.Items, 2)
+		require.NotEmpty(t, n.Items[0].ID)
+		require.Equal(t, 1, n.Items[1].Position)
+	})
+
+	t.Run("should add and toggle item", func(t *testing.T) {
+		service, n := newChecklist(t)
+		n, err := service.AddItem(n.ID, "123-123", "eggs")
+		require.NoError(t, err)
+		require.Len(t, n.Items, 3)
+		require.Equal(t, "eggs", n.Items[2].Text)
+		require.Equal(t, 2, n.Items[2].Position)
+
+		n, err = service.ToggleItem(n.ID, n.Items[2].ID, "123-123")
+		require.NoError(t, err)
+		done, total := n.Progress()
+		require.Equal(t, 1, done)
+		require.Equal(t, 3, total)
+	})
+
+	t.Run("should reorder items", func(t *testing.T) {
+		service, n := newChecklist(t)
+		first, second := n.Items[0], n.Items[1]
+		n, err := service.ReorderItems(n.ID, "123-123", []string{second.ID, first.ID})
+		require.NoError(t, err)
+		require.Equal(t, second.ID, n.Items[0].ID)
+		require.Equal(t, 0, n.Items[0].Position)
+		require.Equal(t, first.ID, n.Items[1].ID)
+		require.Equal(t, 1, n.Items[1].Position)
+	})
+
+	t.Run("should return ErrItemOrder", func(t *testing.T) {
+		service, n := newChecklist(t)
+		_, err := service.ReorderItems(n.ID, "123-123", []string{n.Items[0].ID, n.Items[0].ID})
+		require.ErrorIs(t, err, ErrItemOrder)
+		_, err = service.ReorderItems(n.ID, "123-123", []string{n.Items[0].ID})
+		require.ErrorIs(t, err, ErrItemOrder)
+	})
+
+	t.Run("should delete item", func(t *testing.T) {
+		service, n := newChecklist(t)
+		n, err := service.DeleteItem(n.ID, n.Items[0].ID, "123-123")
+		require.NoError(t, err)
+		require.Len(t, n.Items, 1)
+		require.Equal(t, "bread", n.Items[0].Text)
+		require.Equal(t, 0, n.Items[0].Position)
+
+		_, err = service.DeleteItem(n.ID, "unknown", "123-123")
+		require.ErrorIs(t, err, ErrItemNotFound)
+	})
+
+	t.Run("should keep ids of items sent in update", func(t *testing.T) {
+		service, n := newChecklist(t)
+		milk, bread := n.Items[0], n.Items[1]
+		updated, err := service.UpdateNote(Note{
+			ID:     n.ID,
+			UserID: "123-123",
+			Items: []ChecklistItem{
+				{ID: bread.ID, Text: "bread", Checked: true},
+				{ID: "made-up", Text: "eggs"},
+				{ID: bread.ID, Text: "more bread"},
+				{Text: "milk"},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, updated.Items, 4)
+		require.Equal(t, bread.ID, updated.Items[0].ID)
+		require.True(t, updated.Items[0].Checked)
+		for _, item := range updated.Items[1:] {
+			require.NotContains(t, []string{"made-up", bread.ID, milk.ID, ""}, item.ID)
+		}
+		require.Equal(t, 3, updated.Items[3].Position)
+	})
+
+	t.Run("should drop items when the note becomes text", func(t *testing.T) {
+		service, n := newChecklist(t)
+		updated, err := service.UpdateNote(Note{ID: n.ID, UserID: "123-123", Kind: KindText, Text: "milk and bread"})
+		require.NoError(t, err)
+		require.Equal(t, KindText, updated.Kind)
+		require.Nil(t, updated.Items)
+		stored, err := service.FindNoteByID(n.ID, "123-123")
+		require.NoError(t, err)
+		require.Nil(t, stored.Items)
+	})
+
+	t.Run("should return ErrNoAccess", func(t *testing.T) {
+		service, n := newChecklist(t)
+		_, err := service.AddItem(n.ID, "321-321", "eggs")
+		require.ErrorIs(t, err, app.ErrNoAccess)
+	})
+
+	t.Run("should return ErrNotChecklist", func(t *testing.T) {
+		service := NewService(NewInMemoryStore(zap.NewNop()))
+		n, err := service.CreateNote(Note{UserID: "123-123", Text: "123"})
+		require.NoError(t, err)
+		_, err = service.AddItem(n.ID, "123-123", "eggs")
+		require.ErrorIs(t, err, ErrNotChecklist)
+	})
+}
+
+func TestGetNotesByCompletion(t *testing.T) {
+	store := NewInMemoryStore(zap.NewNop())
+	text, err := store.CreateNote(Note{UserID: "123-123", Text: "123"})
+	require.NoError(t, err)
+	done, err := store.CreateNote(Note{UserID: "123-123", Kind: KindChecklist, Items: []ChecklistItem{{Checked: true}}})
+	require.NoError(t, err)
+	half, err := store.CreateNote(Note{UserID: "123-123", Kind: KindChecklist, Items: []ChecklistItem{{Checked: true}, {}}})
+	require.NoError(t, err)
+
+	actual, err := store.GetNotes("123-123", "completion")
+	require.NoError(t, err)
+	require.Equal(t, []Note{half, done, text}, actual)
+}
@@ -2,29 +2,207 @@ package note
 
 import (
 	"go.uber.org/zap"
+	"note-service/internal/pkg/notify"
+	"note-service/internal/pkg/schedule"
+	"sync"
 	"time"
 )
 
 type expStore interface {
-	ExpireNotes() error
+	ExpireBatch(now time.Time, limit int) ([]Expiration, error)
+	WarnBatch(now time.Time, limit int) ([]Expiration, error)
+	PublishBatch(now time.Time, limit int) ([]Note, error)
+	NextDeadline() (time.Time, bool)
+	ScheduleChanged() <-chan struct{}
 }
 
+// ExpMetrics shows how late notes are deleted compared to their ttl
+type ExpMetrics struct {
+	Expired uint64
+	Batches uint64
+	LastLag time.Duration
+	MaxLag  time.Duration
+	AvgLag  time.Duration
+}
+
+// ExpService sleeps until the earliest ttl and deletes due notes in batches of batchSize.
+// Owners who opted in are notified before the ttl and when the note is deleted.
+// The same loop publishes notes whose publishAt has come.
 type ExpService struct {
-	store  expStore
-	ticker *time.Ticker
-	logger *zap.Logger
+	store     expStore
+	batchSize int
+	notifier  notify.Notifier
+	logger    *zap.Logger
+	done      chan struct{}
+	stop      sync.Once
+
+	mu          sync.Mutex
+	metrics     ExpMetrics
+	totalLag    time.Duration
+	expireHooks []func(Expiration)
+}
+
+func NewExpService(store expStore, batchSize int, notifier notify.Notifier, logger *zap.Logger) *ExpService {
+	return &ExpService{
+		store:     store,
+		batchSize: batchSize,
+		notifier:  notifier,
+		logger:    logger,
+		done:      make(chan struct{}),
+	}
 }
 
-func NewExpService(store expStore, expirationRunInterval time.Duration, logger *zap.Logger) *ExpService {
-	ticker := time.NewTicker(expirationRunInterval)
-	return &ExpService{store: store, ticker: ticker, logger: logger}
+// OnExpire registers hook which is called with every note deleted because of its ttl
+func (service *ExpService) OnExpire(hook func(Expiration)) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.expireHooks = append(service.expireHooks, hook)
 }
 
 func (service *ExpService) Run() error {
-	for range service.ticker.C {
-		if err := service.store.ExpireNotes(); err != nil {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-service.done:
+			return nil
+		case <-service.store.ScheduleChanged():
+		case <-timer.C:
+			service.publish()
+			service.warn()
+			service.expire()
+		}
+
+		if next, ok := service.store.NextDeadline(); ok {
+			schedule.ResetTimer(timer, time.Until(next))
+		} else {
+			schedule.StopTimer(timer)
+		}
+	}
+}
+
+// Stop ends Run, it may be called more than once
+func (service *ExpService) Stop() {
+	service.stop.Do(func() { close(service.done) })
+}
+
+func (service *ExpService) Metrics() ExpMetrics {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	return service.metrics
+}
+
+func (service *ExpService) expire() {
+	for {
+		now := time.Now().UTC()
+		expired, err := service.store.ExpireBatch(now, service.batchSize)
+		if err != nil {
 			service.logger.Error("failed to expire notes", zap.Error(err))
+			return
+		}
+		if len(expired) == 0 {
+			return
+		}
+		hooks := service.record(now, expired)
+		for _, e := range expired {
+			for _, hook := range hooks {
+				hook(e)
+			}
+			if e.Notify {
+				service.notify(expirationEvent(notify.EventNoteExpired, e))
+			}
+		}
+		if len(expired) < service.batchSize {
+			return
 		}
 	}
-	return nil
+}
+
+func (service *ExpService) warn() {
+	for {
+		warnings, err := service.store.WarnBatch(time.Now().UTC(), service.batchSize)
+		if err != nil {
+			service.logger.Error("failed to get expiration warnings", zap.Error(err))
+			return
+		}
+		for _, w := range warnings {
+			service.notify(expirationEvent(notify.EventNoteExpiring, w))
+		}
+		if len(warnings) < service.batchSize {
+			return
+		}
+	}
+}
+
+func (service *ExpService) publish() {
+	for {
+		published, err := service.store.PublishBatch(time.Now().UTC(), service.batchSize)
+		if err != nil {
+			service.logger.Error("failed to publish notes", zap.Error(err))
+			return
+		}
+		for _, n := range published {
+			service.notify(notify.Event{
+				Type:    notify.EventNotePublished,
+				UserID:  n.UserID,
+				NoteID:  n.ID,
+				Subject: n.Subject,
+				At:      time.Unix(*n.PublishAt, 0).UTC(),
+			})
+		}
+		if len(published) < service.batchSize {
+			return
+		}
+	}
+}
+
+func (service *ExpService) notify(event notify.Event) {
+	if service.notifier == nil {
+		return
+	}
+	if err := service.notifier.Notify(event); err != nil {
+		service.logger.Error("failed to notify", zap.String("event", event.Type), zap.String("noteID", event.NoteID), zap.Error(err))
+	}
+}
+
+func expirationEvent(eventType string, e Expiration) notify.Event {
+	return notify.Event{
+		Type:    eventType,
+		UserID:  e.UserID,
+		NoteID:  e.NoteID,
+		Subject: e.Subject,
+		At:      e.Deadline,
+		Lead:    e.Lead,
+	}
+}
+
+// record updates metrics and returns expire hooks to call
+func (service *ExpService) record(now time.Time, expired []Expiration) []func(Expiration) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	var batchLag time.Duration
+	for _, e := range expired {
+		lag := now.Sub(e.Deadline)
+		service.totalLag += lag
+		if lag > batchLag {
+			batchLag = lag
+		}
+	}
+	m := &service.metrics
+	m.Expired += uint64(len(expired))
+	m.Batches++
+	m.LastLag = batchLag
+	if batchLag > m.MaxLag {
+		m.MaxLag = batchLag
+	}
+	m.AvgLag = service.totalLag / time.Duration(m.Expired)
+
+	service.logger.Info("notes were expired",
+		zap.Int("count", len(expired)),
+		zap.Duration("lag", batchLag),
+		zap.Duration("maxLag", m.MaxLag))
+	return service.expireHooks
 }
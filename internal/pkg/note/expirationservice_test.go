@@ -0,0 +1,153 @@
+package note
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"note-service/internal/pkg/notify"
+	"testing"
+	"time"
+)
+
+func TestExpServiceRun(t *testing.T) {
+	t.Run("should delete note at its ttl", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		service := NewExpService(store, 10, nil, zap.NewNop())
+		expired := make(chan Expiration, 1)
+		service.OnExpire(func(e Expiration) { expired <- e })
+		go service.Run()
+		defer service.Stop()
+
+		ttl := time.Now().UTC().Unix() + 1
+		note, err := store.CreateNote(Note{Text: "123", UserID: "123-123", TTL: &ttl})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			_, err := store.FindNoteByID(note.ID)
+			return err != nil
+		}, 3*time.Second, 50*time.Millisecond)
+		e := <-expired
+		require.Equal(t, note.ID, e.NoteID)
+		require.Equal(t, "123-123", e.UserID)
+
+		metrics := service.Metrics()
+		require.Equal(t, uint64(1), metrics.Expired)
+		require.Less(t, metrics.MaxLag, time.Second)
+	})
+
+	t.Run("should wake up when ttl is moved earlier", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		service := NewExpService(store, 10, nil, zap.NewNop())
+		go service.Run()
+		defer service.Stop()
+
+		ttl := time.Now().UTC().Unix() + 3600
+		note, err := store.CreateNote(Note{Text: "123", UserID: "123-123", TTL: &ttl})
+		require.NoError(t, err)
+
+		ttl = time.Now().UTC().Unix()
+		note.TTL = &ttl
+		_, err = store.UpdateNote(note)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			_, err := store.FindNoteByID(note.ID)
+			return err != nil
+		}, 2*time.Second, 50*time.Millisecond)
+	})
+	t.Run("should stop more than once", func(t *testing.T) {
+		service := NewExpService(NewInMemoryStore(zap.NewNop()), 10, nil, zap.NewNop())
+		stopped := make(chan error)
+		go func() { stopped <- service.Run() }()
+		service.Stop()
+		service.Stop()
+		require.NoError(t, <-stopped)
+	})
+}
+
+func TestExpServiceExpire(t *testing.T) {
+	t.Run("should expire all due notes in batches", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		service := NewExpService(store, 2, nil, zap.NewNop())
+		ttl := time.Now().UTC().Unix() - 1
+		for i := 0; i < 5; i++ {
+			_, err := store.CreateNote(Note{Text: "123", UserID: "123-123", TTL: &ttl})
+			require.NoError(t, err)
+		}
+
+		service.expire()
+
+		notes, _ := store.GetNotes("123-123", "")
+		require.Empty(t, notes)
+		require.Equal(t, uint64(5), service.Metrics().Expired)
+		require.Equal(t, uint64(3), service.Metrics().Batches)
+	})
+}
+
+func TestExpServiceNotify(t *testing.T) {
+	t.Run("should notify before and at ttl", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		store.SetExpirationLeadTimes(24*time.Hour, time.Second)
+		bus := notify.NewBus()
+		events := make(chan notify.Event, 10)
+		bus.Subscribe(func(e notify.Event) { events <- e })
+		service := NewExpService(store, 10, bus, zap.NewNop())
+		go service.Run()
+		defer service.Stop()
+
+		ttl := time.Now().UTC().Unix() + 2
+		note, err := store.CreateNote(Note{Text: "123", Subject: "sub", UserID: "123-123", TTL: &ttl, NotifyExpiration: true})
+		require.NoError(t, err)
+
+		warning := <-events
+		require.Equal(t, notify.EventNoteExpiring, warning.Type)
+		require.Equal(t, note.ID, warning.NoteID)
+		require.Equal(t, "sub", warning.Subject)
+		require.Equal(t, time.Second, warning.Lead)
+
+		expired := <-events
+		require.Equal(t, notify.EventNoteExpired, expired.Type)
+		require.Equal(t, note.ID, expired.NoteID)
+		require.Empty(t, events)
+	})
+
+	t.Run("should not notify notes without opt-in", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		store.SetExpirationLeadTimes(time.Hour)
+		bus := notify.NewBus()
+		calls := 0
+		bus.Subscribe(func(e notify.Event) { calls++ })
+		service := NewExpService(store, 10, bus, zap.NewNop())
+
+		ttl := time.Now().UTC().Unix() - 1
+		_, err := store.CreateNote(Note{Text: "123", UserID: "123-123", TTL: &ttl})
+		require.NoError(t, err)
+
+		service.warn()
+		service.expire()
+		require.Equal(t, 0, calls)
+	})
+}
+
+func TestExpServicePublish(t *testing.T) {
+	t.Run("should publish note and send event", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		bus := notify.NewBus()
+		events := make(chan notify.Event, 10)
+		bus.Subscribe(func(e notify.Event) { events <- e })
+		service := NewExpService(store, 10, bus, zap.NewNop())
+		go service.Run()
+		defer service.Stop()
+
+		publishAt := time.Now().UTC().Unix() + 1
+		ttl := publishAt + 3600
+		note, err := store.CreateNote(Note{Text: "123", UserID: "123-123", IsPublic: true, PublishAt: &publishAt, TTL: &ttl})
+		require.NoError(t, err)
+
+		event := <-events
+		require.Equal(t, notify.EventNotePublished, event.Type)
+		require.Equal(t, note.ID, event.NoteID)
+		actual, err := store.FindNoteByID(note.ID)
+		require.NoError(t, err)
+		require.True(t, actual.Published)
+	})
+}
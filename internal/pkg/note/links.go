@@ -0,0 +1,65 @@
+package note
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var linkPattern = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// parseLinks returns distinct [[targets]] of text in order of appearance
+func parseLinks(text string) []string {
+	var targets []string
+	seen := make(map[string]struct{})
+	for _, m := range linkPattern.FindAllStringSubmatch(text, -1) {
+		target := strings.TrimSpace(m[1])
+		key := strings.ToLower(target)
+		if _, ok := seen[key]; ok || target == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// GetLinks returns links of a note the user can see. Links to notes hidden from the user are broken
+// like links to missing notes, so the answer doesn't tell whether a hidden note exists.
+func (s *Service) GetLinks(id, userID string) ([]Link, error) {
+	if _, err := s.FindNoteByID(id, userID); err != nil {
+		return nil, err
+	}
+	links, err := s.store.GetLinks(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	res := make([]Link, 0, len(links))
+	for _, l := range links {
+		if !l.Broken && !canRead(l.Note, userID, now) {
+			l = Link{Target: l.Target, Broken: true}
+		}
+		res = append(res, l)
+	}
+	return res, nil
+}
+
+// GetBacklinks returns notes the user can see which link to a note the user can see
+func (s *Service) GetBacklinks(id, userID string) ([]Note, error) {
+	if _, err := s.FindNoteByID(id, userID); err != nil {
+		return nil, err
+	}
+	notes, err := s.store.GetBacklinks(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	res := make([]Note, 0, len(notes))
+	for _, n := range notes {
+		if canRead(n, userID, now) {
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,88 @@
+package note
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"note-service/internal/app"
+	"testing"
+)
+
+func TestParseLinks(t *testing.T) {
+	targets := parseLinks("see [[Plan]] and [[ 123-123 ]], again [[plan]], not [[]] or [[a\nb]]")
+	require.Equal(t, []string{"Plan", "123-123"}, targets)
+	require.Nil(t, parseLinks("no links"))
+}
+
+func TestStoreLinks(t *testing.T) {
+	store := NewInMemoryStore(zap.NewNop())
+	plan, err := store.CreateNote(Note{UserID: "123-123", Subject: "Plan", Text: "plan"})
+	require.NoError(t, err)
+	other, err := store.CreateNote(Note{UserID: "321-321", Subject: "Plan", Text: "someone else's plan"})
+	require.NoError(t, err)
+	source, err := store.CreateNote(Note{UserID: "123-123", Text: "[[plan]], [[" + other.ID + "]], [[Missing]]"})
+	require.NoError(t, err)
+
+	links, err := store.GetLinks(source.ID)
+	require.NoError(t, err)
+	require.Equal(t, []Link{
+		{Target: "plan", Note: plan},
+		{Target: other.ID, Note: other},
+		{Target: "Missing", Broken: true},
+	}, links)
+
+	backlinks, err := store.GetBacklinks(plan.ID)
+	require.NoError(t, err)
+	require.Equal(t, []Note{source}, backlinks)
+
+	// subject links resolve among the owner's notes only
+	backlinks, err = store.GetBacklinks(other.ID)
+	require.NoError(t, err)
+	require.Equal(t, []Note{source}, backlinks)
+	backlinks, err = store.GetBacklinks(source.ID)
+	require.NoError(t, err)
+	require.Empty(t, backlinks)
+
+	t.Run("should report links to deleted notes as broken", func(t *testing.T) {
+		require.NoError(t, store.DeleteNote(plan.ID))
+		links, err := store.GetLinks(source.ID)
+		require.NoError(t, err)
+		require.True(t, links[0].Broken)
+		require.False(t, links[1].Broken)
+	})
+
+	t.Run("should drop links removed by update", func(t *testing.T) {
+		source.Text = "nothing"
+		_, err := store.UpdateNote(source)
+		require.NoError(t, err)
+		links, err := store.GetLinks(source.ID)
+		require.NoError(t, err)
+		require.Empty(t, links)
+		backlinks, err := store.GetBacklinks(other.ID)
+		require.NoError(t, err)
+		require.Empty(t, backlinks)
+	})
+}
+
+func TestServiceLinks(t *testing.T) {
+	service := NewService(NewInMemoryStore(zap.NewNop()))
+	users := []string{"321-321"}
+	public, err := service.CreateNote(Note{UserID: "123-123", Subject: "Public", Text: "[[Private]]", PublicUsers: &users})
+	require.NoError(t, err)
+	private, err := service.CreateNote(Note{UserID: "123-123", Subject: "Private", Text: "[[Public]] [[Gone]]"})
+	require.NoError(t, err)
+
+	links, err := service.GetLinks(public.ID, "321-321")
+	require.NoError(t, err)
+	require.Equal(t, []Link{{Target: "Private", Broken: true}}, links, "hidden notes look like missing ones")
+
+	links, err = service.GetLinks(public.ID, "123-123")
+	require.NoError(t, err)
+	require.Equal(t, []Link{{Target: "Private", Note: private}}, links)
+
+	backlinks, err := service.GetBacklinks(public.ID, "321-321")
+	require.NoError(t, err)
+	require.Empty(t, backlinks)
+
+	_, err = service.GetBacklinks(private.ID, "321-321")
+	require.ErrorIs(t, err, app.ErrNoAccess)
+}
@@ -2,22 +2,158 @@ package note
 
 import (
 	"errors"
+	"fmt"
 	"time"
 )
 
+const (
+	ContentTypePlain    = "plain"
+	ContentTypeMarkdown = "markdown"
+
+	KindText      = "text"
+	KindChecklist = "checklist"
+
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+	OpShare  = "share"
+)
+
 type Note struct {
-	ID          string
-	UserID      string
-	Subject     string
-	Text        string
-	TTL         *int64
-	IsPublic    bool
-	PublicUsers *[]string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID               string
+	UserID           string
+	Subject          string
+	Text             string
+	ContentType      string
+	Kind             string
+	Items            []ChecklistItem
+	TTL              *int64
+	IsPublic         bool
+	PublicUsers      *[]string
+	NotifyExpiration bool
+	PublishAt        *int64
+	Published        bool
+	DueAt            *int64
+	Marks            Marks
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// Marks are flags of one viewer on a note, they aren't shared with the owner or other viewers.
+// Store keeps them apart from notes, Note.Marks is filled for the user who reads the note.
+type Marks struct {
+	Pinned   bool
+	Starred  bool
+	Archived bool
+}
+
+// MarksUpdate changes only flags which are set
+type MarksUpdate struct {
+	Pinned   *bool
+	Starred  *bool
+	Archived *bool
+}
+
+func (u MarksUpdate) Apply(m Marks) Marks {
+	if u.Pinned != nil {
+		m.Pinned = *u.Pinned
+	}
+	if u.Starred != nil {
+		m.Starred = *u.Starred
+	}
+	if u.Archived != nil {
+		m.Archived = *u.Archived
+	}
+	return m
+}
+
+// ListOptions tune GetNotes. Archived notes and notes shared with the user are left out unless asked for.
+type ListOptions struct {
+	Sort     string
+	Archived bool
+	Shared   bool
+}
+
+type ChecklistItem struct {
+	ID       string
+	Text     string
+	Checked  bool
+	Position int
+}
+
+// Progress returns how many checklist items are checked and how many there are
+func (n Note) Progress() (done, total int) {
+	for _, item := range n.Items {
+		if item.Checked {
+			done++
+		}
+	}
+	return done, len(n.Items)
+}
+
+// IsPublishedAt reports whether note sharing is already in effect at t
+func (n Note) IsPublishedAt(t time.Time) bool {
+	return n.PublishAt == nil || t.Unix() >= *n.PublishAt
+}
+
+// Link is a [[target]] reference in note text, target is a note id or a subject.
+// Broken links don't resolve to any note, Note is empty for them.
+type Link struct {
+	Target string
+	Note   Note
+	Broken bool
+}
+
+// BatchOp is one operation of a batch. Note is the new note for create,
+// for other operations it carries ID of the target and, for share, new IsPublic and PublicUsers.
+type BatchOp struct {
+	Type string
+	Note Note
+}
+
+type BatchResult struct {
+	Note Note
+	Err  error
+}
+
+// BatchError tells which operation stopped an atomic batch
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Stats counts notes of all users, Public are notes visible to everyone
+type Stats struct {
+	Notes  int
+	Public int
+	Shared int
+}
+
+// Expiration describes a note deleted because its ttl has passed,
+// or a warning about it if Lead isn't zero
+type Expiration struct {
+	NoteID   string
+	UserID   string
+	Subject  string
+	Deadline time.Time
+	Lead     time.Duration
+	Notify   bool
 }
 
 var (
 	ErrEmptyNote    = errors.New("empty note text")
 	ErrNoteNotFound = errors.New("note not found")
+	ErrNotChecklist = errors.New("note is not a checklist")
+	ErrItemNotFound = errors.New("checklist item not found")
+	ErrItemOrder    = errors.New("item order must list every item once")
+	ErrUnknownOp    = errors.New("operation must be create, update, delete or share")
+	ErrBatchAborted = errors.New("not applied because another operation failed")
 )
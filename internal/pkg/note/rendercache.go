@@ -0,0 +1,47 @@
+package note
+
+import (
+	"sync"
+	"time"
+)
+
+// renderCache keeps rendered html keyed by note id.
+// An entry is valid only for the note version it was rendered from.
+type renderCache struct {
+	sync.RWMutex
+	items map[string]renderedNote
+}
+
+type renderedNote struct {
+	updatedAt time.Time
+	html      string
+}
+
+func newRenderCache() *renderCache {
+	return &renderCache{items: make(map[string]renderedNote)}
+}
+
+func (c *renderCache) get(note Note) (string, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	item, ok := c.items[note.ID]
+	if !ok || !item.updatedAt.Equal(note.UpdatedAt) {
+		return "", false
+	}
+	return item.html, true
+}
+
+func (c *renderCache) set(note Note, html string) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.items[note.ID] = renderedNote{updatedAt: note.UpdatedAt, html: html}
+}
+
+func (c *renderCache) delete(noteID string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.items, noteID)
+}
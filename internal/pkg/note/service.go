@@ -2,26 +2,50 @@ package note
 
 import (
 	"note-service/internal/app"
+	"note-service/internal/pkg/markdown"
+	"sort"
+	"time"
 )
 
 type store interface {
 	CreateNote(note Note) (Note, error)
 	FindNoteByID(id string) (Note, error)
 	GetNotes(userID, param string) ([]Note, error)
+	GetSharedNotes(userID, param string) ([]Note, error)
 	UpdateNote(note Note) (Note, error)
 	DeleteNote(id string) error
+	UpdateChecklist(id string, update func(items []ChecklistItem) ([]ChecklistItem, error)) (Note, error)
+	GetLinks(id string) ([]Link, error)
+	GetBacklinks(id string) ([]Note, error)
+	GetMarks(noteID, userID string) Marks
+	UpdateMarks(userID string, noteIDs []string, update func(Marks) Marks) error
+	ApplyBatch(ops []BatchOp, prepare func(op BatchOp, current Note) (Note, error)) ([]Note, error)
+	Stats() Stats
 }
 
 type Service struct {
-	store store
+	store    store
+	rendered *renderCache
 }
 
 func NewService(store store) *Service {
-	return &Service{store: store}
+	return &Service{store: store, rendered: newRenderCache()}
 }
 
 func (s *Service) CreateNote(note Note) (Note, error) {
-	return s.store.CreateNote(note)
+	return s.store.CreateNote(prepareCreate(note))
+}
+
+// prepareCreate fills defaults of a new note
+func prepareCreate(note Note) Note {
+	if note.ContentType == "" {
+		note.ContentType = ContentTypePlain
+	}
+	if note.Kind == "" {
+		note.Kind = KindText
+	}
+	note.Items = newItems(note.Items, nil)
+	return note
 }
 
 func (s *Service) FindNoteByID(id, userID string) (Note, error) {
@@ -30,21 +54,88 @@ func (s *Service) FindNoteByID(id, userID string) (Note, error) {
 		return Note{}, err
 	}
 
-	if userID == note.UserID || (note.IsPublic && note.PublicUsers == nil) {
-		return note, nil
+	if !canRead(note, userID, time.Now().UTC()) {
+		return Note{}, app.ErrNoAccess
 	}
+	note.Marks = s.store.GetMarks(id, userID)
+	return note, nil
+}
 
-	pu := note.PublicUsers
-	for _, u := range *pu {
+// canRead reports whether user can see note. Owner always can,
+// others get access through IsPublic or PublicUsers only after publishAt.
+func canRead(note Note, userID string, now time.Time) bool {
+	if userID == note.UserID {
+		return true
+	}
+	if !note.IsPublishedAt(now) {
+		return false
+	}
+	if note.IsPublic && note.PublicUsers == nil {
+		return true
+	}
+	if note.PublicUsers == nil {
+		return false
+	}
+	for _, u := range *note.PublicUsers {
 		if u == userID {
-			return note, nil
+			return true
+		}
+	}
+	return false
+}
+
+// GetNotes returns notes of the user sorted by opts.Sort with pinned ones first
+func (s *Service) GetNotes(userID string, opts ListOptions) ([]Note, error) {
+	notes, err := s.store.GetNotes(userID, opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Shared {
+		shared, err := s.store.GetSharedNotes(userID, opts.Sort)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().UTC()
+		for _, n := range shared {
+			if canRead(n, userID, now) {
+				notes = append(notes, n)
+			}
+		}
+		sortNotes(notes, opts.Sort)
+	}
+
+	res := make([]Note, 0, len(notes))
+	for _, n := range notes {
+		n.Marks = s.store.GetMarks(n.ID, userID)
+		if !n.Marks.Archived || opts.Archived {
+			res = append(res, n)
 		}
 	}
-	return Note{}, app.ErrNoAccess
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Marks.Pinned && !res[j].Marks.Pinned
+	})
+	return res, nil
 }
 
-func (s *Service) GetNotes(id, param string) ([]Note, error) {
-	return s.store.GetNotes(id, param)
+// SetMarks changes flags of the user on notes the user can see, nothing changes if any of them is hidden
+func (s *Service) SetMarks(noteIDs []string, userID string, update MarksUpdate) ([]Note, error) {
+	for _, id := range noteIDs {
+		if _, err := s.FindNoteByID(id, userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.store.UpdateMarks(userID, noteIDs, update.Apply); err != nil {
+		return nil, err
+	}
+	res := make([]Note, 0, len(noteIDs))
+	for _, id := range noteIDs {
+		n, err := s.FindNoteByID(id, userID)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+	return res, nil
 }
 
 func (s *Service) UpdateNote(note Note) (Note, error) {
@@ -52,12 +143,36 @@ func (s *Service) UpdateNote(note Note) (Note, error) {
 	if err != nil {
 		return Note{}, err
 	}
+	note, err = prepareUpdate(n, note)
+	if err != nil {
+		return Note{}, err
+	}
+	s.rendered.delete(note.ID)
+	return s.store.UpdateNote(note)
+}
 
-	if n.UserID != note.UserID {
+// prepareUpdate lets only the owner replace current note, fields left empty in note are kept.
+// Items are dropped when the note stops being a checklist.
+func prepareUpdate(current, note Note) (Note, error) {
+	if current.UserID != note.UserID {
 		return Note{}, app.ErrNoAccess
 	}
-	note.CreatedAt = n.CreatedAt
-	return s.store.UpdateNote(note)
+	note.CreatedAt = current.CreatedAt
+	if note.ContentType == "" {
+		note.ContentType = current.ContentType
+	}
+	if note.Kind == "" {
+		note.Kind = current.Kind
+	}
+	switch {
+	case note.Kind != KindChecklist:
+		note.Items = nil
+	case note.Items == nil:
+		note.Items = current.Items
+	default:
+		note.Items = newItems(note.Items, current.Items)
+	}
+	return note, nil
 }
 
 func (s *Service) DeleteNote(id, userID string) error {
@@ -68,5 +183,45 @@ func (s *Service) DeleteNote(id, userID string) error {
 	if n.UserID != userID {
 		return app.ErrNoAccess
 	}
+	s.rendered.delete(id)
 	return s.store.DeleteNote(id)
 }
+
+// TakeDown deletes a note of any user, it is done by admins to remove abusive notes
+func (s *Service) TakeDown(id string) (Note, error) {
+	n, err := s.store.FindNoteByID(id)
+	if err != nil {
+		return Note{}, err
+	}
+	s.rendered.delete(id)
+	return n, s.store.DeleteNote(id)
+}
+
+func (s *Service) Stats() Stats {
+	return s.store.Stats()
+}
+
+// RenderNote returns note with its text rendered to sanitized html, markdown notes are cached
+func (s *Service) RenderNote(id, userID string) (Note, string, error) {
+	note, err := s.FindNoteByID(id, userID)
+	if err != nil {
+		return Note{}, "", err
+	}
+	if note.ContentType != ContentTypeMarkdown {
+		return note, markdown.RenderPlain(note.Text), nil
+	}
+	if html, ok := s.rendered.get(note); ok {
+		return note, html, nil
+	}
+	html, err := markdown.Render(note.Text)
+	if err != nil {
+		return Note{}, "", err
+	}
+	s.rendered.set(note, html)
+	return note, html, nil
+}
+
+// ForgetRendered drops cached html of a note removed from the store
+func (s *Service) ForgetRendered(note Note) {
+	s.rendered.delete(note.ID)
+}
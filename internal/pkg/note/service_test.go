@@ -3,8 +3,10 @@ package note
 import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"note-service/internal/app"
 	"testing"
+	"time"
 )
 
 type noteStoreMock struct {
@@ -13,6 +15,15 @@ type noteStoreMock struct {
 	GetNotesFunc     func(userID, param string) ([]Note, error)
 	UpdateNoteFunc   func(note Note) (Note, error)
 	DeleteNoteFunc   func(id string) error
+
+	UpdateChecklistFunc func(id string, update func(items []ChecklistItem) ([]ChecklistItem, error)) (Note, error)
+	GetLinksFunc        func(id string) ([]Link, error)
+	GetBacklinksFunc    func(id string) ([]Note, error)
+	GetSharedNotesFunc  func(userID, param string) ([]Note, error)
+	GetMarksFunc        func(noteID, userID string) Marks
+	UpdateMarksFunc     func(userID string, noteIDs []string, update func(Marks) Marks) error
+	ApplyBatchFunc      func(ops []BatchOp, prepare func(op BatchOp, current Note) (Note, error)) ([]Note, error)
+	StatsFunc           func() Stats
 }
 
 func (s *noteStoreMock) CreateNote(note Note) (Note, error) {
@@ -35,6 +46,42 @@ func (s *noteStoreMock) DeleteNote(id string) error {
 	return s.DeleteNoteFunc(id)
 }
 
+func (s *noteStoreMock) UpdateChecklist(id string, update func(items []ChecklistItem) ([]ChecklistItem, error)) (Note, error) {
+	return s.UpdateChecklistFunc(id, update)
+}
+
+func (s *noteStoreMock) GetLinks(id string) ([]Link, error) {
+	return s.GetLinksFunc(id)
+}
+
+func (s *noteStoreMock) GetBacklinks(id string) ([]Note, error) {
+	return s.GetBacklinksFunc(id)
+}
+
+func (s *noteStoreMock) GetSharedNotes(userID, param string) ([]Note, error) {
+	return s.GetSharedNotesFunc(userID, param)
+}
+
+// GetMarks returns no marks unless GetMarksFunc is set, reads of every note ask for them
+func (s *noteStoreMock) GetMarks(noteID, userID string) Marks {
+	if s.GetMarksFunc == nil {
+		return Marks{}
+	}
+	return s.GetMarksFunc(noteID, userID)
+}
+
+func (s *noteStoreMock) UpdateMarks(userID string, noteIDs []string, update func(Marks) Marks) error {
+	return s.UpdateMarksFunc(userID, noteIDs, update)
+}
+
+func (s *noteStoreMock) ApplyBatch(ops []BatchOp, prepare func(op BatchOp, current Note) (Note, error)) ([]Note, error) {
+	return s.ApplyBatchFunc(ops, prepare)
+}
+
+func (s *noteStoreMock) Stats() Stats {
+	return s.StatsFunc()
+}
+
 func TestServiceGetNotes(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -61,7 +108,7 @@ func TestServiceGetNotes(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			s := NewService(&tt.noteStore)
-			n, err := s.GetNotes(tt.id, tt.param)
+			n, err := s.GetNotes(tt.id, ListOptions{Sort: tt.param})
 			if len(tt.expectedNotes) != 0 {
 				require.Equal(t, n, tt.expectedNotes)
 			}
@@ -108,6 +155,8 @@ func TestServiceCreateNote(t *testing.T) {
 }
 
 func TestFindNoteByID(t *testing.T) {
+	future := time.Now().UTC().Unix() + 3600
+	past := time.Now().UTC().Unix() - 3600
 	tests := []struct {
 		name          string
 		noteStore     noteStoreMock
@@ -172,6 +221,54 @@ func TestFindNoteByID(t *testing.T) {
 			},
 			expectedError: app.ErrNoAccess,
 		},
+		{
+			name:   "should return ErrNoAccess, not published yet",
+			id:     uuid.NewString(),
+			userID: "123-123-123",
+			noteStore: noteStoreMock{
+				FindNoteByIDFunc: func(id string) (Note, error) {
+					return Note{
+						ID:          "123-123-123",
+						Text:        "123",
+						PublicUsers: &[]string{"123-123-123"},
+						PublishAt:   &future}, nil
+				},
+			},
+			expectedError: app.ErrNoAccess,
+		},
+		{
+			name:   "should return not published note to owner",
+			id:     uuid.NewString(),
+			userID: "User1",
+			noteStore: noteStoreMock{
+				FindNoteByIDFunc: func(id string) (Note, error) {
+					return Note{ID: "123-123-123", UserID: "User1", Text: "123", PublishAt: &future}, nil
+				},
+			},
+			expectedNote: Note{ID: "123-123-123", UserID: "User1", Text: "123", PublishAt: &future},
+		},
+		{
+			name:   "should return published note",
+			id:     uuid.NewString(),
+			userID: uuid.NewString(),
+			noteStore: noteStoreMock{
+				FindNoteByIDFunc: func(id string) (Note, error) {
+					return Note{ID: "123-123-123", Text: "123", IsPublic: true, PublishAt: &past}, nil
+				},
+			},
+			expectedNote: Note{ID: "123-123-123", Text: "123", IsPublic: true, PublishAt: &past},
+		},
+		{
+			name:   "should return ErrNoAccess, private note",
+			id:     uuid.NewString(),
+			userID: uuid.NewString(),
+			noteStore: noteStoreMock{
+				FindNoteByIDFunc: func(id string) (Note, error) {
+					return Note{ID: "123-123-123", Text: "123"}, nil
+				},
+			},
+			expectedError: app.ErrNoAccess,
+		},
 	}
 
 	for _, tt := range tests {
@@ -302,3 +399,107 @@ func TestUpdateNote(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderNote(t *testing.T) {
+	t.Run("should cache markdown until note is updated", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		s := NewService(store)
+		n, err := s.CreateNote(Note{UserID: "User1", Text: "**old**", ContentType: ContentTypeMarkdown})
+		require.NoError(t, err)
+
+		_, html, err := s.RenderNote(n.ID, "User1")
+		require.NoError(t, err)
+		require.Contains(t, html, "<strong>old</strong>")
+		_, ok := s.rendered.get(n)
+		require.True(t, ok)
+
+		n.Text = "**new**"
+		n, err = s.UpdateNote(n)
+		require.NoError(t, err)
+		_, ok = s.rendered.get(n)
+		require.False(t, ok)
+
+		_, html, err = s.RenderNote(n.ID, "User1")
+		require.NoError(t, err)
+		require.Contains(t, html, "<strong>new</strong>")
+	})
+
+	t.Run("should escape plain note", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		s := NewService(store)
+		n, err := s.CreateNote(Note{UserID: "User1", Text: "<b>1</b>"})
+		require.NoError(t, err)
+		require.Equal(t, ContentTypePlain, n.ContentType)
+
+		_, html, err := s.RenderNote(n.ID, "User1")
+		require.NoError(t, err)
+		require.Equal(t, "<pre>&lt;b&gt;1&lt;/b&gt;</pre>", html)
+	})
+
+	t.Run("should return ErrNoAccess", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		s := NewService(store)
+		n, err := s.CreateNote(Note{UserID: "User1", Text: "1", ContentType: ContentTypeMarkdown})
+		require.NoError(t, err)
+
+		_, _, err = s.RenderNote(n.ID, "User2")
+		require.ErrorIs(t, err, app.ErrNoAccess)
+	})
+}
+
+func TestServiceMarks(t *testing.T) {
+	service := NewService(NewInMemoryStore(zap.NewNop()))
+	shared := []string{"321-321"}
+	first, err := service.CreateNote(Note{UserID: "123-123", Subject: "a", Text: "1", PublicUsers: &shared})
+	require.NoError(t, err)
+	second, err := service.CreateNote(Note{UserID: "123-123", Subject: "b", Text: "2"})
+	require.NoError(t, err)
+	third, err := service.CreateNote(Note{UserID: "123-123", Subject: "c", Text: "3"})
+	require.NoError(t, err)
+	yes, no := true, false
+
+	subjects := func(notes []Note) []string {
+		res := make([]string, len(notes))
+		for i, n := range notes {
+			res[i] = n.Subject
+		}
+		return res
+	}
+
+	_, err = service.SetMarks([]string{third.ID}, "123-123", MarksUpdate{Pinned: &yes, Starred: &yes})
+	require.NoError(t, err)
+	_, err = service.SetMarks([]string{second.ID}, "123-123", MarksUpdate{Archived: &yes})
+	require.NoError(t, err)
+
+	notes, err := service.GetNotes("123-123", ListOptions{Sort: "subject"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "a"}, subjects(notes))
+	require.True(t, notes[0].Marks.Starred)
+
+	notes, err = service.GetNotes("123-123", ListOptions{Sort: "subject", Archived: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "a", "b"}, subjects(notes))
+
+	t.Run("marks should be per viewer", func(t *testing.T) {
+		notes, err := service.SetMarks([]string{first.ID}, "321-321", MarksUpdate{Pinned: &yes})
+		require.NoError(t, err)
+		require.True(t, notes[0].Marks.Pinned)
+
+		n, err := service.FindNoteByID(first.ID, "123-123")
+		require.NoError(t, err)
+		require.False(t, n.Marks.Pinned)
+
+		notes, err = service.GetNotes("321-321", ListOptions{Shared: true})
+		require.NoError(t, err)
+		require.Equal(t, []string{"a"}, subjects(notes))
+		require.True(t, notes[0].Marks.Pinned)
+	})
+
+	t.Run("should change nothing without access to every note", func(t *testing.T) {
+		_, err := service.SetMarks([]string{first.ID, second.ID}, "321-321", MarksUpdate{Pinned: &no})
+		require.ErrorIs(t, err, app.ErrNoAccess)
+		n, err := service.FindNoteByID(first.ID, "321-321")
+		require.NoError(t, err)
+		require.True(t, n.Marks.Pinned)
+	})
+}
@@ -1,7 +1,9 @@
 package note
 
 import (
+	"fmt"
 	"go.uber.org/zap"
+	"note-service/internal/pkg/schedule"
 	"sort"
 	"strings"
 	"sync"
@@ -9,50 +11,132 @@ import (
 
 	"github.com/google/uuid"
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
+// expireBatchSize limits how many notes ExpireNotes deletes under one lock
+const expireBatchSize = 100
+
 // notes map[userId]map[noteId]Note
 // noteIDs map[noteId] userId
+// expirations is a queue of note deadlines keyed by noteId
+// warnings is a queue of pre-expiry notifications keyed by noteId/lead
+// warningKeys map[noteId] keys of its queued warnings, lead times may change after they are queued
+// publications is a queue of publishAt of not yet published notes keyed by noteId
+// links map[noteId] link targets in order of appearance
+// linkIndex map[lowercase target] set of noteIds linking to it
+// marks map[noteId]map[userId]Marks
 type InMemoryStore struct {
 	sync.RWMutex
-	notes    map[string]map[string]Note
-	noteIDs  map[string]string
-	notesTTL map[string]int64
-	logger   *zap.Logger
+	notes           map[string]map[string]Note
+	noteIDs         map[string]string
+	expirations     *schedule.Queue
+	warnings        *schedule.Queue
+	warningKeys     map[string][]string
+	publications    *schedule.Queue
+	links           map[string][]string
+	linkIndex       map[string]map[string]struct{}
+	marks           map[string]map[string]Marks
+	leadTimes       []time.Duration
+	removeHooks     []func(Note)
+	scheduleChanged schedule.Signal
+	logger          *zap.Logger
 }
 
 func NewInMemoryStore(logger *zap.Logger) *InMemoryStore {
 	return &InMemoryStore{
-		notes:    make(map[string]map[string]Note, 0),
-		noteIDs:  make(map[string]string, 0),
-		notesTTL: make(map[string]int64, 0),
-		logger:   logger,
+		notes:           make(map[string]map[string]Note, 0),
+		noteIDs:         make(map[string]string, 0),
+		expirations:     schedule.NewQueue(),
+		warnings:        schedule.NewQueue(),
+		warningKeys:     make(map[string][]string),
+		publications:    schedule.NewQueue(),
+		links:           make(map[string][]string),
+		linkIndex:       make(map[string]map[string]struct{}),
+		marks:           make(map[string]map[string]Marks),
+		scheduleChanged: schedule.NewSignal(),
+		logger:          logger,
 	}
 }
 
+// SetExpirationLeadTimes sets how long before ttl notes with NotifyExpiration are warned about
+func (store *InMemoryStore) SetExpirationLeadTimes(leadTimes ...time.Duration) {
+	store.Lock()
+	defer store.Unlock()
+
+	store.leadTimes = leadTimes
+}
+
+// OnRemove registers hook which is called with every note deleted by DeleteNote or ExpireNotes.
+// Hooks are called after the store is unlocked, so they may use the store.
+func (store *InMemoryStore) OnRemove(hook func(Note)) {
+	store.Lock()
+	defer store.Unlock()
+
+	store.removeHooks = append(store.removeHooks, hook)
+}
+
 func (store *InMemoryStore) CreateNote(note Note) (Note, error) {
 	store.Lock()
 	defer store.Unlock()
 
+	return store.createNote(note), nil
+}
+
+// createNote isn't thread-safe
+func (store *InMemoryStore) createNote(note Note) Note {
 	note.ID = uuid.NewString()
 	note.CreatedAt = time.Now().UTC()
+	note.Marks = Marks{}
 	if _, ok := store.notes[note.UserID]; !ok {
 		store.notes[note.UserID] = make(map[string]Note, 0)
 	}
+	store.schedulePublication(&note)
 	store.notes[note.UserID][note.ID] = note
 	store.noteIDs[note.ID] = note.UserID
-	if note.TTL != nil {
-		store.notesTTL[note.ID] = *note.TTL
-	}
+	store.scheduleExpiration(note)
+	store.indexLinks(note)
 
-	return note, nil
+	return note
 }
 
 func (store *InMemoryStore) GetNotes(userID, param string) ([]Note, error) {
+	store.RLock()
+	defer store.RUnlock()
+
 	v := maps.Values(store.notes[userID])
+	sortNotes(v, param)
+	return v, nil
+}
+
+// GetSharedNotes returns notes of other users which list userID in PublicUsers
+func (store *InMemoryStore) GetSharedNotes(userID, param string) ([]Note, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	v := make([]Note, 0)
+	for owner, notes := range store.notes {
+		if owner == userID {
+			continue
+		}
+		for _, n := range notes {
+			if n.PublicUsers != nil && slices.Contains(*n.PublicUsers, userID) {
+				v = append(v, n)
+			}
+		}
+	}
+	sortNotes(v, param)
+	return v, nil
+}
+
+// sortNotes orders notes by param, unknown params keep the order
+func sortNotes(v []Note, param string) {
 	switch param {
 	case "ttl":
 		sort.SliceStable(v, func(i, j int) bool {
+			if v[i].TTL == nil || v[j].TTL == nil {
+				return v[j].TTL == nil && v[i].TTL != nil
+			}
 			return *v[i].TTL < *v[j].TTL
 		})
 	case "subject":
@@ -67,10 +151,28 @@ func (store *InMemoryStore) GetNotes(userID, param string) ([]Note, error) {
 		sort.SliceStable(v, func(i, j int) bool {
 			return v[i].UpdatedAt.Before(v[j].UpdatedAt)
 		})
+	case "completion":
+		sort.SliceStable(v, func(i, j int) bool {
+			return completion(v[i]) < completion(v[j])
+		})
+	case "due-at":
+		sort.SliceStable(v, func(i, j int) bool {
+			if v[i].DueAt == nil || v[j].DueAt == nil {
+				return v[j].DueAt == nil && v[i].DueAt != nil
+			}
+			return *v[i].DueAt < *v[j].DueAt
+		})
 	default:
 	}
+}
 
-	return v, nil
+// completion is a share of checked items, notes without items go last
+func completion(note Note) float64 {
+	done, total := note.Progress()
+	if total == 0 {
+		return 2
+	}
+	return float64(done) / float64(total)
 }
 
 func (store *InMemoryStore) FindNoteByID(id string) (Note, error) {
@@ -84,47 +186,488 @@ func (store *InMemoryStore) FindNoteByID(id string) (Note, error) {
 }
 
 func (store *InMemoryStore) DeleteNote(id string) error {
+	var removed []Note
+	defer func() { store.runRemoveHooks(removed) }()
 	store.Lock()
 	defer store.Unlock()
 
-	userID, ok := store.noteIDs[id]
-	if !ok {
+	if _, ok := store.noteIDs[id]; !ok {
 		return ErrNoteNotFound
 	}
-	delete(store.notes[userID], id)
-	delete(store.noteIDs, id)
-	delete(store.notesTTL, id)
+	removed = append(removed, store.removeNote(id))
 	return nil
 }
 
+func (store *InMemoryStore) Stats() Stats {
+	store.RLock()
+	defer store.RUnlock()
+
+	var stats Stats
+	for _, notes := range store.notes {
+		for _, n := range notes {
+			stats.Notes++
+			if n.IsPublic {
+				stats.Public++
+			} else if n.PublicUsers != nil && len(*n.PublicUsers) > 0 {
+				stats.Shared++
+			}
+		}
+	}
+	return stats
+}
+
 func (store *InMemoryStore) UpdateNote(note Note) (Note, error) {
 	store.Lock()
 	defer store.Unlock()
 
+	return store.updateNote(note), nil
+}
+
+// updateNote isn't thread-safe
+func (store *InMemoryStore) updateNote(note Note) Note {
 	note.UpdatedAt = time.Now().UTC()
+	note.Marks = Marks{}
+	store.schedulePublication(&note)
 	store.notes[note.UserID][note.ID] = note
-	if note.TTL != nil {
-		store.notesTTL[note.ID] = *note.TTL
-	} else {
-		delete(store.notesTTL, note.ID)
+	store.scheduleExpiration(note)
+	store.indexLinks(note)
+
+	return note
+}
+
+// ApplyBatch runs all operations under one lock or none of them. prepare gets every operation
+// with the current state of its target, as left by the previous operations, and returns the note to save.
+// Nothing is changed until every operation is prepared, the first failure comes back as *BatchError.
+func (store *InMemoryStore) ApplyBatch(ops []BatchOp, prepare func(op BatchOp, current Note) (Note, error)) ([]Note, error) {
+	var removed []Note
+	defer func() { store.runRemoveHooks(removed) }()
+	store.Lock()
+	defer store.Unlock()
+
+	// latest map[noteId] note after previous operations, nil if it was deleted
+	latest := make(map[string]*Note)
+	res := make([]Note, len(ops))
+	for i, op := range ops {
+		var current Note
+		if op.Type != OpCreate {
+			n, seen := latest[op.Note.ID]
+			userID, ok := store.noteIDs[op.Note.ID]
+			switch {
+			case seen && n == nil, !seen && !ok:
+				return nil, &BatchError{Index: i, Err: ErrNoteNotFound}
+			case seen:
+				current = *n
+			default:
+				current = store.notes[userID][op.Note.ID]
+			}
+		}
+		n, err := prepare(op, current)
+		if err != nil {
+			return nil, &BatchError{Index: i, Err: err}
+		}
+		res[i] = n
+		switch op.Type {
+		case OpCreate:
+		case OpDelete:
+			latest[op.Note.ID] = nil
+		default:
+			latest[op.Note.ID] = &res[i]
+		}
 	}
 
+	for i, op := range ops {
+		switch op.Type {
+		case OpCreate:
+			res[i] = store.createNote(res[i])
+		case OpDelete:
+			removed = append(removed, store.removeNote(op.Note.ID))
+		default:
+			res[i] = store.updateNote(res[i])
+		}
+	}
+	return res, nil
+}
+
+// UpdateChecklist replaces items of a checklist note with the result of update under the store lock,
+// so concurrent item operations don't overwrite each other. Positions are renumbered in order.
+func (store *InMemoryStore) UpdateChecklist(id string, update func(items []ChecklistItem) ([]ChecklistItem, error)) (Note, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	userID, ok := store.noteIDs[id]
+	if !ok {
+		return Note{}, ErrNoteNotFound
+	}
+	note := store.notes[userID][id]
+	if note.Kind != KindChecklist {
+		return Note{}, ErrNotChecklist
+	}
+	items, err := update(append([]ChecklistItem(nil), note.Items...))
+	if err != nil {
+		return Note{}, err
+	}
+	for i := range items {
+		items[i].Position = i
+	}
+	note.Items = items
+	note.UpdatedAt = time.Now().UTC()
+	store.notes[userID][id] = note
 	return note, nil
 }
 
+// GetLinks returns links of the note in order of appearance, they are resolved at the moment of the call,
+// so links to deleted or expired notes come back broken
+func (store *InMemoryStore) GetLinks(id string) ([]Link, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	userID, ok := store.noteIDs[id]
+	if !ok {
+		return nil, ErrNoteNotFound
+	}
+	res := make([]Link, 0, len(store.links[id]))
+	for _, target := range store.links[id] {
+		to, ok := store.resolveLink(userID, target)
+		res = append(res, Link{Target: target, Note: to, Broken: !ok})
+	}
+	return res, nil
+}
+
+// GetBacklinks returns notes with links resolving to the note, oldest first
+func (store *InMemoryStore) GetBacklinks(id string) ([]Note, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	userID, ok := store.noteIDs[id]
+	if !ok {
+		return nil, ErrNoteNotFound
+	}
+	keys := []string{strings.ToLower(id)}
+	if subject := store.notes[userID][id].Subject; subject != "" {
+		keys = append(keys, strings.ToLower(subject))
+	}
+	seen := make(map[string]struct{})
+	res := make([]Note, 0)
+	for _, key := range keys {
+		for fromID := range store.linkIndex[key] {
+			if _, ok := seen[fromID]; ok || fromID == id {
+				continue
+			}
+			from := store.notes[store.noteIDs[fromID]][fromID]
+			if to, ok := store.resolveLink(from.UserID, key); ok && to.ID == id {
+				seen[fromID] = struct{}{}
+				res = append(res, from)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+	return res, nil
+}
+
+func (store *InMemoryStore) GetMarks(noteID, userID string) Marks {
+	store.RLock()
+	defer store.RUnlock()
+
+	return store.marks[noteID][userID]
+}
+
+// UpdateMarks changes marks of the user on every note or, if any of them doesn't exist, on none
+func (store *InMemoryStore) UpdateMarks(userID string, noteIDs []string, update func(Marks) Marks) error {
+	store.Lock()
+	defer store.Unlock()
+
+	for _, id := range noteIDs {
+		if _, ok := store.noteIDs[id]; !ok {
+			return ErrNoteNotFound
+		}
+	}
+	for _, id := range noteIDs {
+		m := update(store.marks[id][userID])
+		if m == (Marks{}) {
+			delete(store.marks[id], userID)
+			continue
+		}
+		if store.marks[id] == nil {
+			store.marks[id] = make(map[string]Marks)
+		}
+		store.marks[id][userID] = m
+	}
+	return nil
+}
+
+// DeleteUserNotes deletes all notes of the user and takes the user out of publicUsers and marks of other notes
+func (store *InMemoryStore) DeleteUserNotes(userID string) {
+	var removed []Note
+	defer func() { store.runRemoveHooks(removed) }()
+	store.Lock()
+	defer store.Unlock()
+
+	for id := range store.notes[userID] {
+		removed = append(removed, store.removeNote(id))
+	}
+	delete(store.notes, userID)
+
+	for _, notes := range store.notes {
+		for id, n := range notes {
+			if n.PublicUsers == nil {
+				continue
+			}
+			i := slices.Index(*n.PublicUsers, userID)
+			if i < 0 {
+				continue
+			}
+			publicUsers := slices.Delete(slices.Clone(*n.PublicUsers), i, i+1)
+			n.PublicUsers = &publicUsers
+			notes[id] = n
+		}
+	}
+	for _, marks := range store.marks {
+		delete(marks, userID)
+	}
+}
+
+// ExpireNotes deletes every note whose ttl has passed.
+// Notes are deleted in small batches, so readers aren't blocked for the whole run.
 func (store *InMemoryStore) ExpireNotes() error {
+	for {
+		expired, err := store.ExpireBatch(time.Now().UTC(), expireBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(expired) < expireBatchSize {
+			return nil
+		}
+	}
+}
+
+// ExpireBatch deletes up to limit notes whose ttl is not after now
+func (store *InMemoryStore) ExpireBatch(now time.Time, limit int) ([]Expiration, error) {
+	var removed []Note
+	defer func() { store.runRemoveHooks(removed) }()
 	store.Lock()
 	defer store.Unlock()
 
-	for noteID, ttl := range store.notesTTL {
-		if time.Now().UTC().Unix() >= ttl {
-			userID := store.noteIDs[noteID]
-			delete(store.notes[userID], noteID)
-			delete(store.noteIDs, noteID)
-			delete(store.notesTTL, noteID)
-			store.logger.Info("note was deleted", zap.String("noteID", noteID))
+	var expired []Expiration
+	for len(expired) < limit {
+		noteID, deadline, ok := store.expirations.Peek()
+		if !ok || deadline.After(now) {
+			break
 		}
+		note := store.removeNote(noteID)
+		removed = append(removed, note)
+		expired = append(expired, Expiration{
+			NoteID:   noteID,
+			UserID:   note.UserID,
+			Subject:  note.Subject,
+			Deadline: deadline,
+			Notify:   note.NotifyExpiration,
+		})
+		store.logger.Info("note was deleted", zap.String("noteID", noteID))
 	}
 
-	return nil
+	return expired, nil
+}
+
+// WarnBatch returns up to limit pre-expiry warnings which are due at now
+func (store *InMemoryStore) WarnBatch(now time.Time, limit int) ([]Expiration, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	var warnings []Expiration
+	for _, key := range store.warnings.PopDue(now, limit) {
+		var noteID string
+		var lead time.Duration
+		if _, err := fmt.Sscanf(key, warningKeyFormat, &noteID, &lead); err != nil {
+			return warnings, fmt.Errorf("invalid warning key %q: %w", key, err)
+		}
+		store.forgetWarningKey(noteID, key)
+		userID, ok := store.noteIDs[noteID]
+		note := store.notes[userID][noteID]
+		if !ok || note.TTL == nil {
+			continue
+		}
+		warnings = append(warnings, Expiration{
+			NoteID:   noteID,
+			UserID:   userID,
+			Subject:  note.Subject,
+			Deadline: time.Unix(*note.TTL, 0).UTC(),
+			Lead:     lead,
+			Notify:   true,
+		})
+	}
+
+	return warnings, nil
+}
+
+// PublishBatch marks up to limit notes whose publishAt is not after now as published
+func (store *InMemoryStore) PublishBatch(now time.Time, limit int) ([]Note, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	var published []Note
+	for _, noteID := range store.publications.PopDue(now, limit) {
+		userID := store.noteIDs[noteID]
+		note := store.notes[userID][noteID]
+		note.Published = true
+		store.notes[userID][noteID] = note
+		published = append(published, note)
+		store.logger.Info("note was published", zap.String("noteID", noteID))
+	}
+
+	return published, nil
+}
+
+// NextDeadline returns the earliest moment when an expiration, a warning or a publication is due
+func (store *InMemoryStore) NextDeadline() (time.Time, bool) {
+	store.RLock()
+	defer store.RUnlock()
+
+	var next time.Time
+	found := false
+	for _, q := range []*schedule.Queue{store.expirations, store.warnings, store.publications} {
+		if _, at, ok := q.Peek(); ok && (!found || at.Before(next)) {
+			next, found = at, true
+		}
+	}
+	return next, found
+}
+
+// ScheduleChanged signals each time a ttl or publishAt is added or moved
+func (store *InMemoryStore) ScheduleChanged() <-chan struct{} {
+	return store.scheduleChanged
+}
+
+// removeNote deletes note with all its schedules and isn't thread-safe
+func (store *InMemoryStore) removeNote(id string) Note {
+	userID := store.noteIDs[id]
+	note := store.notes[userID][id]
+	delete(store.notes[userID], id)
+	delete(store.noteIDs, id)
+	store.unscheduleExpiration(id)
+	store.publications.Remove(id)
+	store.unindexLinks(id)
+	delete(store.marks, id)
+	return note
+}
+
+func (store *InMemoryStore) runRemoveHooks(removed []Note) {
+	if len(removed) == 0 {
+		return
+	}
+	store.RLock()
+	hooks := store.removeHooks
+	store.RUnlock()
+
+	for _, n := range removed {
+		for _, hook := range hooks {
+			hook(n)
+		}
+	}
+}
+
+// resolveLink finds a note by id or, among notes of userID, by subject ignoring case.
+// The oldest note wins when several have the same subject. It isn't thread-safe.
+func (store *InMemoryStore) resolveLink(userID, target string) (Note, bool) {
+	if owner, ok := store.noteIDs[target]; ok {
+		return store.notes[owner][target], true
+	}
+	var found Note
+	ok := false
+	for _, n := range store.notes[userID] {
+		if strings.EqualFold(n.Subject, target) && (!ok || n.CreatedAt.Before(found.CreatedAt)) {
+			found, ok = n, true
+		}
+	}
+	return found, ok
+}
+
+// indexLinks parses links of the note text and isn't thread-safe
+func (store *InMemoryStore) indexLinks(note Note) {
+	store.unindexLinks(note.ID)
+	targets := parseLinks(note.Text)
+	if len(targets) == 0 {
+		return
+	}
+	store.links[note.ID] = targets
+	for _, target := range targets {
+		key := strings.ToLower(target)
+		if store.linkIndex[key] == nil {
+			store.linkIndex[key] = make(map[string]struct{})
+		}
+		store.linkIndex[key][note.ID] = struct{}{}
+	}
+}
+
+// unindexLinks drops outgoing links of the note and isn't thread-safe
+func (store *InMemoryStore) unindexLinks(noteID string) {
+	for _, target := range store.links[noteID] {
+		key := strings.ToLower(target)
+		delete(store.linkIndex[key], noteID)
+		if len(store.linkIndex[key]) == 0 {
+			delete(store.linkIndex, key)
+		}
+	}
+	delete(store.links, noteID)
+}
+
+// warningKeyFormat is noteId and lead separated by space, uuid never contains one
+const warningKeyFormat = "%s %d"
+
+// scheduleExpiration puts note ttl and its warnings into the queues and isn't thread-safe
+func (store *InMemoryStore) scheduleExpiration(note Note) {
+	store.unscheduleExpiration(note.ID)
+	if note.TTL == nil {
+		return
+	}
+	deadline := time.Unix(*note.TTL, 0).UTC()
+	store.expirations.Set(note.ID, deadline)
+	if note.NotifyExpiration {
+		now := time.Now().UTC()
+		for _, lead := range store.leadTimes {
+			if at := deadline.Add(-lead); at.After(now) {
+				key := fmt.Sprintf(warningKeyFormat, note.ID, lead)
+				store.warnings.Set(key, at)
+				store.warningKeys[note.ID] = append(store.warningKeys[note.ID], key)
+			}
+		}
+	}
+	store.scheduleChanged.Notify()
+}
+
+// schedulePublication sets note.Published and queues a future publishAt, it isn't thread-safe
+func (store *InMemoryStore) schedulePublication(note *Note) {
+	note.Published = note.IsPublishedAt(time.Now().UTC())
+	if note.Published {
+		store.publications.Remove(note.ID)
+		return
+	}
+	store.publications.Set(note.ID, time.Unix(*note.PublishAt, 0).UTC())
+	store.scheduleChanged.Notify()
+}
+
+// unscheduleExpiration removes note ttl and its warnings from the queues and isn't thread-safe
+func (store *InMemoryStore) unscheduleExpiration(noteID string) {
+	store.expirations.Remove(noteID)
+	for _, key := range store.warningKeys[noteID] {
+		store.warnings.Remove(key)
+	}
+	delete(store.warningKeys, noteID)
+}
+
+// forgetWarningKey drops a warning popped from the queue and isn't thread-safe
+func (store *InMemoryStore) forgetWarningKey(noteID, key string) {
+	keys := store.warningKeys[noteID]
+	for i, k := range keys {
+		if k == key {
+			keys = append(keys[:i], keys[i+1:]...)
+			break
+		}
+	}
+	if len(keys) == 0 {
+		delete(store.warningKeys, noteID)
+	} else {
+		store.warningKeys[noteID] = keys
+	}
 }
@@ -180,3 +180,185 @@ func TestExpireNotes(t *testing.T) {
 		require.Equal(t, note2, actual[0])
 	})
 }
+
+func TestExpireBatch(t *testing.T) {
+	t.Run("should delete only limit notes", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		ttl := time.Now().UTC().Unix() - 1
+		for i := 0; i < 3; i++ {
+			_, err := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123", TTL: &ttl})
+			require.NoError(t, err)
+		}
+
+		expired, err := store.ExpireBatch(time.Now().UTC(), 2)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(expired))
+		actual, _ := store.GetNotes("123-123-123", "")
+		require.Equal(t, 1, len(actual))
+	})
+
+	t.Run("should follow ttl changes", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		ttl := time.Now().UTC().Unix() - 1
+		note, err := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123", TTL: &ttl})
+		require.NoError(t, err)
+
+		note.TTL = nil
+		_, err = store.UpdateNote(note)
+		require.NoError(t, err)
+		_, ok := store.NextDeadline()
+		require.False(t, ok)
+
+		expired, err := store.ExpireBatch(time.Now().UTC(), 10)
+		require.NoError(t, err)
+		require.Empty(t, expired)
+	})
+}
+
+func TestWarnBatch(t *testing.T) {
+	t.Run("should schedule warnings only for opted-in notes", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		store.SetExpirationLeadTimes(time.Hour, time.Minute)
+		ttl := time.Now().UTC().Unix() + 90
+		note, err := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123", TTL: &ttl, NotifyExpiration: true})
+		require.NoError(t, err)
+		_, err = store.CreateNote(Note{Text: "123-123", UserID: "123-123-123", TTL: &ttl})
+		require.NoError(t, err)
+
+		warnings, err := store.WarnBatch(time.Now().UTC().Add(time.Hour), 10)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(warnings))
+		require.Equal(t, note.ID, warnings[0].NoteID)
+		require.Equal(t, time.Minute, warnings[0].Lead)
+	})
+
+	t.Run("should drop warnings of deleted note", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		store.SetExpirationLeadTimes(time.Minute)
+		ttl := time.Now().UTC().Unix() + 90
+		note, err := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123", TTL: &ttl, NotifyExpiration: true})
+		require.NoError(t, err)
+
+		err = store.DeleteNote(note.ID)
+		require.NoError(t, err)
+		warnings, err := store.WarnBatch(time.Now().UTC().Add(time.Hour), 10)
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+	})
+
+	t.Run("should drop warnings queued with old lead times", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		store.SetExpirationLeadTimes(time.Minute)
+		ttl := time.Now().UTC().Unix() + 90
+		note, err := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123", TTL: &ttl, NotifyExpiration: true})
+		require.NoError(t, err)
+
+		store.SetExpirationLeadTimes(time.Hour)
+		require.NoError(t, store.DeleteNote(note.ID))
+		warnings, err := store.WarnBatch(time.Now().UTC().Add(time.Hour), 10)
+		require.NoError(t, err)
+		require.Empty(t, warnings)
+		require.Empty(t, store.warningKeys)
+	})
+}
+
+func TestPublishBatch(t *testing.T) {
+	t.Run("should publish note at publishAt", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		publishAt := time.Now().UTC().Unix() + 60
+		note, err := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123", IsPublic: true, PublishAt: &publishAt})
+		require.NoError(t, err)
+		require.False(t, note.Published)
+
+		published, err := store.PublishBatch(time.Now().UTC(), 10)
+		require.NoError(t, err)
+		require.Empty(t, published)
+
+		published, err = store.PublishBatch(time.Unix(publishAt, 0), 10)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(published))
+		actual, _ := store.FindNoteByID(note.ID)
+		require.True(t, actual.Published)
+	})
+
+	t.Run("should publish note without publishAt at once", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		note, err := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123"})
+		require.NoError(t, err)
+		require.True(t, note.Published)
+		_, ok := store.NextDeadline()
+		require.False(t, ok)
+	})
+}
+
+func TestOnRemove(t *testing.T) {
+	t.Run("should call hooks for deleted and expired notes", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		var removed []string
+		store.OnRemove(func(n Note) {
+			_, err := store.FindNoteByID(n.ID)
+			require.ErrorIs(t, err, ErrNoteNotFound)
+			removed = append(removed, n.ID)
+		})
+		ttl := time.Now().UTC().Unix() - 1
+		note1, err := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123"})
+		require.NoError(t, err)
+		note2, err := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123", TTL: &ttl})
+		require.NoError(t, err)
+
+		require.NoError(t, store.DeleteNote(note1.ID))
+		require.NoError(t, store.ExpireNotes())
+		require.Equal(t, []string{note1.ID, note2.ID}, removed)
+	})
+}
+
+func TestGetNotesByDueAt(t *testing.T) {
+	t.Run("should return notes without due date last", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		due1, due2 := int64(20), int64(10)
+		note1, _ := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123", DueAt: &due1})
+		note2, _ := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123"})
+		note3, _ := store.CreateNote(Note{Text: "123-123", UserID: "123-123-123", DueAt: &due2})
+
+		actual, err := store.GetNotes("123-123-123", "due-at")
+		require.NoError(t, err)
+		require.Equal(t, []Note{note3, note1, note2}, actual)
+	})
+}
+
+func TestDeleteUserNotes(t *testing.T) {
+	t.Run("should delete notes of user and unshare notes of others", func(t *testing.T) {
+		store := NewInMemoryStore(zap.NewNop())
+		var removed []string
+		store.OnRemove(func(n Note) { removed = append(removed, n.ID) })
+		own, err := store.CreateNote(Note{Text: "123-123", UserID: "gone"})
+		require.NoError(t, err)
+		shared, err := store.CreateNote(Note{Text: "123-123", UserID: "stays", PublicUsers: &[]string{"a", "gone", "b"}})
+		require.NoError(t, err)
+		require.NoError(t, store.UpdateMarks("gone", []string{shared.ID}, func(Marks) Marks { return Marks{Starred: true} }))
+
+		store.DeleteUserNotes("gone")
+
+		require.Equal(t, []string{own.ID}, removed)
+		_, err = store.FindNoteByID(own.ID)
+		require.ErrorIs(t, err, ErrNoteNotFound)
+		shared, err = store.FindNoteByID(shared.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, *shared.PublicUsers)
+		require.Equal(t, Marks{}, store.GetMarks(shared.ID, "gone"))
+	})
+}
+
+func TestStats(t *testing.T) {
+	store := NewInMemoryStore(zap.NewNop())
+	for _, n := range []Note{
+		{Text: "private", UserID: "a"},
+		{Text: "public", UserID: "a", IsPublic: true},
+		{Text: "shared", UserID: "b", PublicUsers: &[]string{"a"}},
+		{Text: "unshared", UserID: "b", PublicUsers: &[]string{}},
+	} {
+		_, err := store.CreateNote(n)
+		require.NoError(t, err)
+	}
+	require.Equal(t, Stats{Notes: 4, Public: 1, Shared: 1}, store.Stats())
+}
@@ -0,0 +1,43 @@
+package notify
+
+import "sync"
+
+// Bus delivers events to in-process subscribers synchronously
+type Bus struct {
+	sync.RWMutex
+	nextID   int
+	handlers map[int]func(Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[int]func(Event))}
+}
+
+// Subscribe registers handler and returns a function removing it
+func (bus *Bus) Subscribe(handler func(Event)) func() {
+	bus.Lock()
+	defer bus.Unlock()
+
+	id := bus.nextID
+	bus.nextID++
+	bus.handlers[id] = handler
+	return func() {
+		bus.Lock()
+		defer bus.Unlock()
+		delete(bus.handlers, id)
+	}
+}
+
+func (bus *Bus) Notify(event Event) error {
+	bus.RLock()
+	handlers := make([]func(Event), 0, len(bus.handlers))
+	for _, h := range bus.handlers {
+		handlers = append(handlers, h)
+	}
+	bus.RUnlock()
+
+	for _, h := range handlers {
+		h(event)
+	}
+	return nil
+}
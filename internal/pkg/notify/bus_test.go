@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	t.Run("should deliver event to subscribers", func(t *testing.T) {
+		bus := NewBus()
+		var got []Event
+		bus.Subscribe(func(e Event) { got = append(got, e) })
+
+		err := bus.Notify(Event{Type: EventNoteExpired, NoteID: "123"})
+		require.NoError(t, err)
+		require.Equal(t, []Event{{Type: EventNoteExpired, NoteID: "123"}}, got)
+	})
+
+	t.Run("should stop delivering after unsubscribe", func(t *testing.T) {
+		bus := NewBus()
+		calls := 0
+		unsubscribe := bus.Subscribe(func(e Event) { calls++ })
+		unsubscribe()
+
+		_ = bus.Notify(Event{Type: EventNoteExpired})
+		require.Equal(t, 0, calls)
+	})
+}
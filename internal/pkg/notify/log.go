@@ -0,0 +1,22 @@
+package notify
+
+import "go.uber.org/zap"
+
+// Log writes every event to logger, it is handy for local development
+type Log struct {
+	logger *zap.Logger
+}
+
+func NewLog(logger *zap.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (l *Log) Notify(event Event) error {
+	l.logger.Info("event",
+		zap.String("type", event.Type),
+		zap.String("userID", event.UserID),
+		zap.String("noteID", event.NoteID),
+		zap.String("reminderID", event.ReminderID),
+		zap.Time("at", event.At))
+	return nil
+}
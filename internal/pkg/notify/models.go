@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	EventNoteExpiring  = "note.expiring"
+	EventNoteExpired   = "note.expired"
+	EventNotePublished = "note.published"
+	EventReminderDue   = "reminder.due"
+)
+
+// Event is passed to notifiers, Lead is set for events sent ahead of At
+type Event struct {
+	Type       string        `json:"type"`
+	UserID     string        `json:"userId"`
+	NoteID     string        `json:"noteId"`
+	ReminderID string        `json:"reminderId,omitempty"`
+	Subject    string        `json:"subject"`
+	At         time.Time     `json:"at"`
+	Lead       time.Duration `json:"lead"`
+}
+
+type Notifier interface {
+	Notify(event Event) error
+}
+
+var ErrWebhookStatus = errors.New("webhook responded with unexpected status")
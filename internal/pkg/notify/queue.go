@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+var ErrQueueFull = errors.New("notification queue is full, event was dropped")
+
+// Queue hands events to next in its own goroutine, so a slow notifier doesn't hold up the caller.
+// When size events are waiting, new ones are dropped with ErrQueueFull.
+type Queue struct {
+	next   Notifier
+	events chan Event
+	logger *zap.Logger
+	done   chan struct{}
+	stop   sync.Once
+}
+
+func NewQueue(next Notifier, size int, logger *zap.Logger) *Queue {
+	return &Queue{next: next, events: make(chan Event, size), logger: logger, done: make(chan struct{})}
+}
+
+func (q *Queue) Notify(event Event) error {
+	select {
+	case q.events <- event:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run delivers events until Stop, events still waiting then are dropped
+func (q *Queue) Run() error {
+	for {
+		select {
+		case <-q.done:
+			return nil
+		case event := <-q.events:
+			if err := q.next.Notify(event); err != nil {
+				q.logger.Error("failed to notify", zap.String("event", event.Type), zap.String("noteID", event.NoteID), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (q *Queue) Stop() {
+	q.stop.Do(func() { close(q.done) })
+}
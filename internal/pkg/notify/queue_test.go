@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type blockingNotifier struct {
+	release chan struct{}
+	got     chan Event
+}
+
+func (n *blockingNotifier) Notify(event Event) error {
+	<-n.release
+	n.got <- event
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	next := &blockingNotifier{release: make(chan struct{}), got: make(chan Event, 10)}
+	q := NewQueue(next, 1, zap.NewNop())
+	go q.Run()
+	defer q.Stop()
+
+	require.NoError(t, q.Notify(Event{NoteID: "1"}))
+	require.Eventually(t, func() bool { return len(q.events) == 0 }, time.Second, time.Millisecond)
+	// the first event is being delivered, one more fits the queue
+	require.NoError(t, q.Notify(Event{NoteID: "2"}))
+	require.ErrorIs(t, q.Notify(Event{NoteID: "3"}), ErrQueueFull)
+
+	close(next.release)
+	require.Equal(t, "1", (<-next.got).NoteID)
+	require.Equal(t, "2", (<-next.got).NoteID)
+	q.Stop()
+}
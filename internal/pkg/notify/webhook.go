@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Webhook posts every event as json to url
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (w *Webhook) Notify(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: %d", ErrWebhookStatus, resp.StatusCode)
+	}
+	return nil
+}
+
+// Fanout sends event to every notifier and returns the first error
+type Fanout []Notifier
+
+func (f Fanout) Notify(event Event) error {
+	var firstErr error
+	for _, n := range f {
+		if err := n.Notify(event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
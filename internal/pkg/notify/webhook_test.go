@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type notifierMock struct {
+	NotifyFunc func(event Event) error
+}
+
+func (n *notifierMock) Notify(event Event) error {
+	return n.NotifyFunc(event)
+}
+
+func TestWebhook(t *testing.T) {
+	t.Run("should post event", func(t *testing.T) {
+		var got Event
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		event := Event{Type: EventNoteExpiring, NoteID: "123", Lead: time.Hour}
+		err := NewWebhook(server.URL, time.Second).Notify(event)
+		require.NoError(t, err)
+		require.Equal(t, event, got)
+	})
+
+	t.Run("should return ErrWebhookStatus", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		err := NewWebhook(server.URL, time.Second).Notify(Event{})
+		require.ErrorIs(t, err, ErrWebhookStatus)
+	})
+}
+
+func TestFanout(t *testing.T) {
+	t.Run("should notify everyone and return first error", func(t *testing.T) {
+		calls := 0
+		failing := &notifierMock{NotifyFunc: func(event Event) error {
+			calls++
+			return errors.New("something wrong")
+		}}
+		ok := &notifierMock{NotifyFunc: func(event Event) error {
+			calls++
+			return nil
+		}}
+
+		err := Fanout{failing, ok}.Notify(Event{})
+		require.Error(t, err)
+		require.Equal(t, 2, calls)
+	})
+}
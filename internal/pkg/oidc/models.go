@@ -0,0 +1,45 @@
+package oidc
+
+import (
+	"errors"
+	"time"
+)
+
+// Config is one OpenID Connect provider. Name is used in URLs, RedirectURL is where the provider
+// sends the user back with the code, the frontend passes the code and the state to the callback.
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Flow is a started login, it is kept on the server, so the PKCE verifier never reaches the browser.
+// UserID is set when a signed in user links the identity instead of logging in.
+type Flow struct {
+	State     string
+	Provider  string
+	Verifier  string
+	Nonce     string
+	UserID    string
+	ExpiresAt time.Time
+}
+
+// Claims are the checked claims of an ID token
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+var (
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	ErrInvalidState    = errors.New("invalid or expired login state")
+	ErrExchange        = errors.New("failed to exchange authorization code")
+	ErrIDToken         = errors.New("invalid id token")
+	ErrDiscovery       = errors.New("failed to discover identity provider")
+)
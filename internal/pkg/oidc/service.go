@@ -0,0 +1,112 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"sort"
+	"time"
+)
+
+// flowTTL is how long the user has to sign in at the provider
+const flowTTL = 10 * time.Minute
+
+type store interface {
+	SaveFlow(flow Flow)
+	TakeFlow(state string) (Flow, error)
+}
+
+// Service runs the authorization code flow with PKCE against configured providers
+type Service struct {
+	store     store
+	providers map[string]*Provider
+}
+
+func NewService(store store, providers ...*Provider) *Service {
+	s := &Service{store: store, providers: make(map[string]*Provider, len(providers))}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+	}
+	return s
+}
+
+// Providers returns names of the configured providers, sorted
+func (s *Service) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start begins a login, or linking when userID is set, and returns the URL of the provider to send the user to
+func (s *Service) Start(provider, userID string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+	state, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	authURL, err := p.AuthURL(state, nonce, challenge(verifier))
+	if err != nil {
+		return "", err
+	}
+	s.store.SaveFlow(Flow{
+		State:     state,
+		Provider:  provider,
+		Verifier:  verifier,
+		Nonce:     nonce,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(flowTTL),
+	})
+	return authURL, nil
+}
+
+// Finish checks the state, exchanges the code and verifies the ID token. A state is accepted only once.
+func (s *Service) Finish(provider, code, state string) (Flow, Claims, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return Flow{}, Claims{}, ErrUnknownProvider
+	}
+	flow, err := s.store.TakeFlow(state)
+	if err != nil {
+		return Flow{}, Claims{}, err
+	}
+	if flow.Provider != provider {
+		return Flow{}, Claims{}, ErrInvalidState
+	}
+	raw, err := p.Exchange(code, flow.Verifier)
+	if err != nil {
+		return Flow{}, Claims{}, err
+	}
+	claims, err := p.Verify(raw, flow.Nonce)
+	if err != nil {
+		return Flow{}, Claims{}, err
+	}
+	return flow, claims, nil
+}
+
+// randomString has 256 bits, as a PKCE verifier it is 43 characters long
+func randomString() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
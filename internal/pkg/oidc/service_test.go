@@ -0,0 +1,130 @@
+package oidc
+
+import (
+	"net/http"
+	"net/url"
+	"note-service/internal/pkg/oidc/oidctest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (*Service, *oidctest.Server) {
+	idp := oidctest.NewServer("note-service", "secret")
+	t.Cleanup(idp.Close)
+	idp.SetUser(oidctest.User{Subject: "42", Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice"})
+	provider := NewProvider(Config{
+		Name:         "corp",
+		Issuer:       idp.Issuer(),
+		ClientID:     "note-service",
+		ClientSecret: "secret",
+		RedirectURL:  "https://notes.example.com/oidc/corp/callback",
+	}, &http.Client{Timeout: 5 * time.Second})
+	return NewService(NewInMemoryStore(), provider), idp
+}
+
+func TestLoginFlow(t *testing.T) {
+	t.Run("should sign in with PKCE", func(t *testing.T) {
+		service, idp := newTestService(t)
+		require.Equal(t, []string{"corp"}, service.Providers())
+
+		authURL, err := service.Start("corp", "")
+		require.NoError(t, err)
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+		require.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+		require.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+
+		code, state, err := idp.Authorize(authURL)
+		require.NoError(t, err)
+		flow, claims, err := service.Finish("corp", code, state)
+		require.NoError(t, err)
+		require.Empty(t, flow.UserID)
+		require.Equal(t, Claims{Subject: "42", Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice"}, claims)
+
+		_, _, err = service.Finish("corp", code, state)
+		require.ErrorIs(t, err, ErrInvalidState, "state is used once")
+	})
+
+	t.Run("should keep user who links identity", func(t *testing.T) {
+		service, idp := newTestService(t)
+		authURL, err := service.Start("corp", "user-1")
+		require.NoError(t, err)
+		code, state, err := idp.Authorize(authURL)
+		require.NoError(t, err)
+		flow, _, err := service.Finish("corp", code, state)
+		require.NoError(t, err)
+		require.Equal(t, "user-1", flow.UserID)
+	})
+
+	t.Run("should reject unknown provider and foreign state", func(t *testing.T) {
+		service, _ := newTestService(t)
+		_, err := service.Start("other", "")
+		require.ErrorIs(t, err, ErrUnknownProvider)
+		_, _, err = service.Finish("corp", "code", "state")
+		require.ErrorIs(t, err, ErrInvalidState)
+	})
+
+	t.Run("should reject code without the verifier", func(t *testing.T) {
+		service, idp := newTestService(t)
+		authURL, err := service.Start("corp", "")
+		require.NoError(t, err)
+		code, state, err := idp.Authorize(authURL)
+		require.NoError(t, err)
+
+		// a stolen code is useless with a flow of another login
+		otherURL, err := service.Start("corp", "")
+		require.NoError(t, err)
+		_, otherState, err := idp.Authorize(otherURL)
+		require.NoError(t, err)
+		_, _, err = service.Finish("corp", code, otherState)
+		require.ErrorIs(t, err, ErrExchange)
+		_, _, err = service.Finish("corp", code, state)
+		require.ErrorIs(t, err, ErrExchange, "code was spent by the failed exchange")
+	})
+
+	t.Run("should follow key rotation", func(t *testing.T) {
+		service, idp := newTestService(t)
+		for i := 0; i < 2; i++ {
+			authURL, err := service.Start("corp", "")
+			require.NoError(t, err)
+			code, state, err := idp.Authorize(authURL)
+			require.NoError(t, err)
+			_, _, err = service.Finish("corp", code, state)
+			require.NoError(t, err)
+			require.NoError(t, idp.RotateKey())
+		}
+	})
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(claims jwt.MapClaims)
+	}{
+		{name: "should reject wrong issuer", tamper: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{name: "should reject wrong audience", tamper: func(c jwt.MapClaims) { c["aud"] = "other-client" }},
+		{name: "should reject other authorized party", tamper: func(c jwt.MapClaims) {
+			c["aud"] = []string{"note-service", "other-client"}
+			c["azp"] = "other-client"
+		}},
+		{name: "should reject wrong nonce", tamper: func(c jwt.MapClaims) { c["nonce"] = "replayed" }},
+		{name: "should reject expired token", tamper: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "should reject token without subject", tamper: func(c jwt.MapClaims) { c["sub"] = "" }},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			service, idp := newTestService(t)
+			idp.Tamper(tt.tamper)
+			authURL, err := service.Start("corp", "")
+			require.NoError(t, err)
+			code, state, err := idp.Authorize(authURL)
+			require.NoError(t, err)
+			_, _, err = service.Finish("corp", code, state)
+			require.ErrorIs(t, err, ErrIDToken)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package oidc
+
+import (
+	"sync"
+	"time"
+)
+
+// InMemoryStore keeps started flows by state until they are finished or expire
+type InMemoryStore struct {
+	sync.Mutex
+	flows map[string]Flow
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{flows: make(map[string]Flow)}
+}
+
+// SaveFlow also drops expired flows, so abandoned logins don't pile up
+func (store *InMemoryStore) SaveFlow(flow Flow) {
+	store.Lock()
+	defer store.Unlock()
+
+	now := time.Now()
+	for state, f := range store.flows {
+		if !f.ExpiresAt.After(now) {
+			delete(store.flows, state)
+		}
+	}
+	store.flows[flow.State] = flow
+}
+
+// TakeFlow returns the flow only once
+func (store *InMemoryStore) TakeFlow(state string) (Flow, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	flow, ok := store.flows[state]
+	if !ok {
+		return Flow{}, ErrInvalidState
+	}
+	delete(store.flows, state)
+	if !flow.ExpiresAt.After(time.Now()) {
+		return Flow{}, ErrInvalidState
+	}
+	return flow, nil
+}
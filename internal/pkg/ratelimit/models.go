@@ -0,0 +1,25 @@
+package ratelimit
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidRule = errors.New("rate limit rule must look like 'POST /note=30/1m'")
+
+// Limit lets Requests through per Period. The bucket holds up to Requests tokens and refills evenly,
+// so a quiet client may send Requests at once and then one every Period/Requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Result is the state of the bucket after a request, Reset is when the bucket is full again
+// and RetryAfter is when the next request is allowed if this one is not
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRoute is the rule for routes which have no rule of their own
+const DefaultRoute = "*"
+
+// Rules are limits by route, keys are a method and a route pattern like "POST /note/:id" or DefaultRoute
+type Rules map[string]Limit
+
+// ParseRules reads comma separated rules "<method> <route>=<requests>/<period>", where the period
+// is a duration like 1m or just a unit like s, m or h. "*=600/m" limits all other routes.
+func ParseRules(s string) (Rules, error) {
+	rules := make(Rules)
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, ErrInvalidRule
+		}
+		route = strings.Join(strings.Fields(route), " ")
+		if route != DefaultRoute {
+			method, path, ok := strings.Cut(route, " ")
+			if !ok || !strings.HasPrefix(path, "/") {
+				return nil, ErrInvalidRule
+			}
+			route = strings.ToUpper(method) + " " + path
+		}
+		limit, err := parseLimit(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		rules[route] = limit
+	}
+	return rules, nil
+}
+
+func parseLimit(s string) (Limit, error) {
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, ErrInvalidRule
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, ErrInvalidRule
+	}
+	if period == "s" || period == "m" || period == "h" {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, ErrInvalidRule
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// Find returns the rule of the route and its name, which keeps buckets of different rules apart
+func (r Rules) Find(method, route string) (string, Limit, bool) {
+	name := method + " " + route
+	if limit, ok := r[name]; ok {
+		return name, limit, true
+	}
+	limit, ok := r[DefaultRoute]
+	return DefaultRoute, limit, ok
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often full buckets are dropped
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// InMemoryStore keeps token buckets of one process, a shared backend is needed for several instances
+type InMemoryStore struct {
+	sync.Mutex
+	buckets   map[string]bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{buckets: make(map[string]bucket), lastSweep: time.Now(), now: time.Now}
+}
+
+// Take spends a token from the bucket of the key if there is one
+func (store *InMemoryStore) Take(key string, limit Limit) Result {
+	store.Lock()
+	defer store.Unlock()
+
+	now := store.now()
+	store.sweep(now)
+	capacity := float64(limit.Requests)
+	perToken := limit.Period / time.Duration(limit.Requests)
+
+	b, ok := store.buckets[key]
+	if !ok {
+		b = bucket{tokens: capacity, updated: now}
+	}
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.updated))/float64(perToken))
+	b.updated = now
+
+	result := Result{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	b.full = now.Add(time.Duration((capacity - b.tokens) * float64(perToken)))
+	store.buckets[key] = b
+
+	result.Remaining = int(b.tokens)
+	result.Reset = b.full.Sub(now)
+	return result
+}
+
+func (store *InMemoryStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < sweepInterval {
+		return
+	}
+	store.lastSweep = now
+	for k, b := range store.buckets {
+		if !now.Before(b.full) {
+			delete(store.buckets, k)
+		}
+	}
+}
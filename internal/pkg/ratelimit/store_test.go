@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	store := NewInMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	for i := 2; i >= 0; i-- {
+		r := store.Take("user", limit)
+		require.True(t, r.Allowed)
+		require.Equal(t, i, r.Remaining)
+	}
+	r := store.Take("user", limit)
+	require.False(t, r.Allowed)
+	require.Equal(t, time.Second, r.RetryAfter)
+	require.Equal(t, 3*time.Second, r.Reset)
+
+	require.True(t, store.Take("other", limit).Allowed, "buckets are kept per key")
+
+	now = now.Add(1500 * time.Millisecond)
+	r = store.Take("user", limit)
+	require.True(t, r.Allowed, "bucket refills evenly")
+	require.Equal(t, 0, r.Remaining)
+	require.False(t, store.Take("user", limit).Allowed)
+
+	now = now.Add(time.Hour)
+	r = store.Take("user", limit)
+	require.True(t, r.Allowed)
+	require.Equal(t, 2, r.Remaining, "bucket holds at most Requests tokens")
+	require.Len(t, store.buckets, 1, "full buckets are swept")
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("post /note=30/m, POST  /user=5/10m ,*=600/1m")
+	require.NoError(t, err)
+	require.Equal(t, Rules{
+		"POST /note": {Requests: 30, Period: time.Minute},
+		"POST /user": {Requests: 5, Period: 10 * time.Minute},
+		DefaultRoute: {Requests: 600, Period: time.Minute},
+	}, rules)
+
+	name, limit, ok := rules.Find("POST", "/note")
+	require.True(t, ok)
+	require.Equal(t, "POST /note", name)
+	require.Equal(t, 30, limit.Requests)
+	name, _, ok = rules.Find("GET", "/note/:id")
+	require.True(t, ok)
+	require.Equal(t, DefaultRoute, name)
+
+	for _, s := range []string{"POST /note", "/note=1/m", "POST /note=0/m", "POST /note=1/week", "POST note=1/m"} {
+		_, err := ParseRules(s)
+		require.ErrorIs(t, err, ErrInvalidRule, s)
+	}
+}
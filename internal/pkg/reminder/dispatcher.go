@@ -0,0 +1,113 @@
+package reminder
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"note-service/internal/app"
+	"note-service/internal/pkg/note"
+	"note-service/internal/pkg/notify"
+	"note-service/internal/pkg/schedule"
+	"sync"
+	"time"
+)
+
+type dispatcherStore interface {
+	FireBatch(now time.Time, limit int) ([]Reminder, error)
+	UpdateReminder(reminder Reminder) (Reminder, error)
+	NextDeadline() (time.Time, bool)
+	ScheduleChanged() <-chan struct{}
+}
+
+// Dispatcher sleeps until the earliest pending reminder and delivers due ones through notifier.
+// A reminder is delivered only while its user can still see the note.
+type Dispatcher struct {
+	store     dispatcherStore
+	notes     noteFinder
+	batchSize int
+	notifier  notify.Notifier
+	logger    *zap.Logger
+	done      chan struct{}
+	stop      sync.Once
+}
+
+func NewDispatcher(store dispatcherStore, notes noteFinder, batchSize int, notifier notify.Notifier, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:     store,
+		notes:     notes,
+		batchSize: batchSize,
+		notifier:  notifier,
+		logger:    logger,
+		done:      make(chan struct{}),
+	}
+}
+
+func (d *Dispatcher) Run() error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return nil
+		case <-d.store.ScheduleChanged():
+		case <-timer.C:
+			d.dispatch()
+		}
+
+		if next, ok := d.store.NextDeadline(); ok {
+			schedule.ResetTimer(timer, time.Until(next))
+		} else {
+			schedule.StopTimer(timer)
+		}
+	}
+}
+
+// Stop ends Run, it may be called more than once
+func (d *Dispatcher) Stop() {
+	d.stop.Do(func() { close(d.done) })
+}
+
+func (d *Dispatcher) dispatch() {
+	for {
+		fired, err := d.store.FireBatch(time.Now().UTC(), d.batchSize)
+		if err != nil {
+			d.logger.Error("failed to fire reminders", zap.Error(err))
+			return
+		}
+		for _, r := range fired {
+			if !d.canSee(r) {
+				continue
+			}
+			event := notify.Event{
+				Type:       notify.EventReminderDue,
+				UserID:     r.UserID,
+				NoteID:     r.NoteID,
+				ReminderID: r.ID,
+				At:         r.RemindAt,
+			}
+			if err := d.notifier.Notify(event); err != nil {
+				d.logger.Error("failed to deliver reminder", zap.String("reminderID", r.ID), zap.Error(err))
+			}
+		}
+		if len(fired) < d.batchSize {
+			return
+		}
+	}
+}
+
+// canSee dismisses the reminder when the note was unshared from its user or is gone
+func (d *Dispatcher) canSee(r Reminder) bool {
+	_, err := d.notes.FindNoteByID(r.NoteID, r.UserID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, app.ErrNoAccess), errors.Is(err, note.ErrNoteNotFound):
+		r.Status = StatusDismissed
+		if _, err := d.store.UpdateReminder(r); err != nil && !errors.Is(err, ErrReminderNotFound) {
+			d.logger.Error("failed to dismiss reminder", zap.String("reminderID", r.ID), zap.Error(err))
+		}
+	default:
+		d.logger.Error("failed to check reminder note", zap.String("reminderID", r.ID), zap.Error(err))
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package reminder
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"note-service/internal/app"
+	"note-service/internal/pkg/note"
+	"note-service/internal/pkg/notify"
+	"testing"
+	"time"
+)
+
+func TestDispatcherRun(t *testing.T) {
+	t.Run("should deliver reminder at its time", func(t *testing.T) {
+		store := NewInMemoryStore()
+		bus := notify.NewBus()
+		events := make(chan notify.Event, 10)
+		bus.Subscribe(func(e notify.Event) { events <- e })
+		notes := &noteFinderMock{FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+			return note.Note{ID: id}, nil
+		}}
+		d := NewDispatcher(store, notes, 10, bus, zap.NewNop())
+		go d.Run()
+		defer d.Stop()
+
+		r, err := store.CreateReminder(Reminder{NoteID: "1", UserID: "123-123", RemindAt: time.Now().UTC().Add(100 * time.Millisecond)})
+		require.NoError(t, err)
+
+		select {
+		case e := <-events:
+			require.Equal(t, notify.EventReminderDue, e.Type)
+			require.Equal(t, r.ID, e.ReminderID)
+			require.Equal(t, "123-123", e.UserID)
+		case <-time.After(2 * time.Second):
+			t.Fatal("reminder wasn't delivered")
+		}
+	})
+	t.Run("should dismiss reminder on note the user can't see", func(t *testing.T) {
+		store := NewInMemoryStore()
+		bus := notify.NewBus()
+		events := make(chan notify.Event, 10)
+		bus.Subscribe(func(e notify.Event) { events <- e })
+		notes := &noteFinderMock{FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+			if userID == "456-456" {
+				return note.Note{}, app.ErrNoAccess
+			}
+			return note.Note{ID: id}, nil
+		}}
+		d := NewDispatcher(store, notes, 10, bus, zap.NewNop())
+		go d.Run()
+		defer d.Stop()
+
+		soon := time.Now().UTC().Add(100 * time.Millisecond)
+		unshared, err := store.CreateReminder(Reminder{NoteID: "1", UserID: "456-456", RemindAt: soon})
+		require.NoError(t, err)
+		own, err := store.CreateReminder(Reminder{NoteID: "1", UserID: "123-123", RemindAt: soon.Add(50 * time.Millisecond)})
+		require.NoError(t, err)
+
+		select {
+		case e := <-events:
+			require.Equal(t, own.ID, e.ReminderID)
+		case <-time.After(2 * time.Second):
+			t.Fatal("reminder wasn't delivered")
+		}
+		r, err := store.FindReminderByID(unshared.ID)
+		require.NoError(t, err)
+		require.Equal(t, StatusDismissed, r.Status)
+	})
+	t.Run("should stop more than once", func(t *testing.T) {
+		d := NewDispatcher(NewInMemoryStore(), &noteFinderMock{}, 10, notify.NewBus(), zap.NewNop())
+		stopped := make(chan error)
+		go func() { stopped <- d.Run() }()
+		d.Stop()
+		d.Stop()
+		require.NoError(t, <-stopped)
+	})
+}
@@ -0,0 +1,27 @@
+package reminder
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusFired     = "fired"
+	StatusDismissed = "dismissed"
+)
+
+type Reminder struct {
+	ID        string
+	NoteID    string
+	UserID    string
+	RemindAt  time.Time
+	Status    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+var (
+	ErrReminderNotFound = errors.New("reminder not found")
+	ErrRemindAtPassed   = errors.New("remind time has already passed")
+)
@@ -0,0 +1,76 @@
+package reminder
+
+import (
+	"note-service/internal/app"
+	"note-service/internal/pkg/note"
+	"time"
+)
+
+type store interface {
+	CreateReminder(reminder Reminder) (Reminder, error)
+	FindReminderByID(id string) (Reminder, error)
+	UpdateReminder(reminder Reminder) (Reminder, error)
+	GetUpcoming(userID string, until time.Time) ([]Reminder, error)
+}
+
+type noteFinder interface {
+	FindNoteByID(id, userID string) (note.Note, error)
+}
+
+type Service struct {
+	store store
+	notes noteFinder
+}
+
+func NewService(store store, notes noteFinder) *Service {
+	return &Service{store: store, notes: notes}
+}
+
+// CreateReminder adds reminder to any note the user can see
+func (s *Service) CreateReminder(noteID, userID string, remindAt time.Time) (Reminder, error) {
+	if _, err := s.notes.FindNoteByID(noteID, userID); err != nil {
+		return Reminder{}, err
+	}
+	if !remindAt.After(time.Now().UTC()) {
+		return Reminder{}, ErrRemindAtPassed
+	}
+	return s.store.CreateReminder(Reminder{NoteID: noteID, UserID: userID, RemindAt: remindAt})
+}
+
+func (s *Service) GetUpcoming(userID string, within time.Duration) ([]Reminder, error) {
+	return s.store.GetUpcoming(userID, time.Now().UTC().Add(within))
+}
+
+// Snooze moves a pending or fired reminder to until
+func (s *Service) Snooze(id, userID string, until time.Time) (Reminder, error) {
+	r, err := s.findOwnReminder(id, userID)
+	if err != nil {
+		return Reminder{}, err
+	}
+	if !until.After(time.Now().UTC()) {
+		return Reminder{}, ErrRemindAtPassed
+	}
+	r.RemindAt = until
+	r.Status = StatusPending
+	return s.store.UpdateReminder(r)
+}
+
+func (s *Service) Dismiss(id, userID string) (Reminder, error) {
+	r, err := s.findOwnReminder(id, userID)
+	if err != nil {
+		return Reminder{}, err
+	}
+	r.Status = StatusDismissed
+	return s.store.UpdateReminder(r)
+}
+
+func (s *Service) findOwnReminder(id, userID string) (Reminder, error) {
+	r, err := s.store.FindReminderByID(id)
+	if err != nil {
+		return Reminder{}, err
+	}
+	if r.UserID != userID {
+		return Reminder{}, app.ErrNoAccess
+	}
+	return r, nil
+}
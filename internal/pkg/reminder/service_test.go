@@ -0,0 +1,147 @@
+package reminder
+
+import (
+	"github.com/stretchr/testify/require"
+	"note-service/internal/app"
+	"note-service/internal/pkg/note"
+	"testing"
+	"time"
+)
+
+type reminderStoreMock struct {
+	CreateReminderFunc   func(reminder Reminder) (Reminder, error)
+	FindReminderByIDFunc func(id string) (Reminder, error)
+	UpdateReminderFunc   func(reminder Reminder) (Reminder, error)
+	GetUpcomingFunc      func(userID string, until time.Time) ([]Reminder, error)
+}
+
+func (s *reminderStoreMock) CreateReminder(reminder Reminder) (Reminder, error) {
+	return s.CreateReminderFunc(reminder)
+}
+
+func (s *reminderStoreMock) FindReminderByID(id string) (Reminder, error) {
+	return s.FindReminderByIDFunc(id)
+}
+
+func (s *reminderStoreMock) UpdateReminder(reminder Reminder) (Reminder, error) {
+	return s.UpdateReminderFunc(reminder)
+}
+
+func (s *reminderStoreMock) GetUpcoming(userID string, until time.Time) ([]Reminder, error) {
+	return s.GetUpcomingFunc(userID, until)
+}
+
+type noteFinderMock struct {
+	FindNoteByIDFunc func(id, userID string) (note.Note, error)
+}
+
+func (n *noteFinderMock) FindNoteByID(id, userID string) (note.Note, error) {
+	return n.FindNoteByIDFunc(id, userID)
+}
+
+func TestServiceCreateReminder(t *testing.T) {
+	future := time.Now().UTC().Add(time.Hour)
+	tests := []struct {
+		name          string
+		store         reminderStoreMock
+		notes         noteFinderMock
+		remindAt      time.Time
+		expectedError error
+	}{
+		{
+			name: "should return ErrNoAccess",
+			notes: noteFinderMock{FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+				return note.Note{}, app.ErrNoAccess
+			}},
+			remindAt:      future,
+			expectedError: app.ErrNoAccess,
+		},
+		{
+			name: "should return ErrRemindAtPassed",
+			notes: noteFinderMock{FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+				return note.Note{ID: id}, nil
+			}},
+			remindAt:      time.Now().UTC().Add(-time.Hour),
+			expectedError: ErrRemindAtPassed,
+		},
+		{
+			name: "should create reminder",
+			notes: noteFinderMock{FindNoteByIDFunc: func(id, userID string) (note.Note, error) {
+				return note.Note{ID: id}, nil
+			}},
+			store: reminderStoreMock{CreateReminderFunc: func(reminder Reminder) (Reminder, error) {
+				return reminder, nil
+			}},
+			remindAt: future,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(&tt.store, &tt.notes)
+			r, err := s.CreateReminder("note-1", "123-123", tt.remindAt)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, Reminder{NoteID: "note-1", UserID: "123-123", RemindAt: future}, r)
+		})
+	}
+}
+
+func TestServiceSnooze(t *testing.T) {
+	until := time.Now().UTC().Add(time.Hour)
+	tests := []struct {
+		name             string
+		store            reminderStoreMock
+		until            time.Time
+		expectedReminder Reminder
+		expectedError    error
+	}{
+		{
+			name: "should return ErrReminderNotFound",
+			store: reminderStoreMock{FindReminderByIDFunc: func(id string) (Reminder, error) {
+				return Reminder{}, ErrReminderNotFound
+			}},
+			until:         until,
+			expectedError: ErrReminderNotFound,
+		},
+		{
+			name: "should return ErrNoAccess",
+			store: reminderStoreMock{FindReminderByIDFunc: func(id string) (Reminder, error) {
+				return Reminder{ID: id, UserID: "321-321"}, nil
+			}},
+			until:         until,
+			expectedError: app.ErrNoAccess,
+		},
+		{
+			name: "should snooze fired reminder",
+			store: reminderStoreMock{
+				FindReminderByIDFunc: func(id string) (Reminder, error) {
+					return Reminder{ID: id, UserID: "123-123", Status: StatusFired}, nil
+				},
+				UpdateReminderFunc: func(reminder Reminder) (Reminder, error) {
+					return reminder, nil
+				},
+			},
+			until:            until,
+			expectedReminder: Reminder{ID: "1", UserID: "123-123", Status: StatusPending, RemindAt: until},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(&tt.store, &noteFinderMock{})
+			r, err := s.Snooze("1", "123-123", tt.until)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedReminder, r)
+		})
+	}
+}
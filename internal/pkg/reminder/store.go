@@ -0,0 +1,144 @@
+package reminder
+
+import (
+	"note-service/internal/pkg/schedule"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// reminders map[reminderId]Reminder
+// pending is a queue of pending reminders keyed by reminderId
+type InMemoryStore struct {
+	sync.RWMutex
+	reminders       map[string]Reminder
+	pending         *schedule.Queue
+	scheduleChanged schedule.Signal
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		reminders:       make(map[string]Reminder),
+		pending:         schedule.NewQueue(),
+		scheduleChanged: schedule.NewSignal(),
+	}
+}
+
+func (store *InMemoryStore) CreateReminder(reminder Reminder) (Reminder, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	reminder.ID = uuid.NewString()
+	reminder.Status = StatusPending
+	reminder.CreatedAt = time.Now().UTC()
+	store.reminders[reminder.ID] = reminder
+	store.schedule(reminder)
+	return reminder, nil
+}
+
+func (store *InMemoryStore) FindReminderByID(id string) (Reminder, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	if r, ok := store.reminders[id]; ok {
+		return r, nil
+	}
+	return Reminder{}, ErrReminderNotFound
+}
+
+func (store *InMemoryStore) UpdateReminder(reminder Reminder) (Reminder, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	if _, ok := store.reminders[reminder.ID]; !ok {
+		return Reminder{}, ErrReminderNotFound
+	}
+	reminder.UpdatedAt = time.Now().UTC()
+	store.reminders[reminder.ID] = reminder
+	store.schedule(reminder)
+	return reminder, nil
+}
+
+// GetUpcoming returns pending reminders of user due before until, the earliest first
+func (store *InMemoryStore) GetUpcoming(userID string, until time.Time) ([]Reminder, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	res := make([]Reminder, 0)
+	for _, r := range store.reminders {
+		if r.UserID == userID && r.Status == StatusPending && !r.RemindAt.After(until) {
+			res = append(res, r)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].RemindAt.Before(res[j].RemindAt)
+	})
+	return res, nil
+}
+
+// DeleteNoteReminders removes every reminder of the note
+func (store *InMemoryStore) DeleteNoteReminders(noteID string) {
+	store.Lock()
+	defer store.Unlock()
+
+	for id, r := range store.reminders {
+		if r.NoteID == noteID {
+			delete(store.reminders, id)
+			store.pending.Remove(id)
+		}
+	}
+}
+
+// DeleteUserReminders removes every reminder of the user, on own and shared notes
+func (store *InMemoryStore) DeleteUserReminders(userID string) {
+	store.Lock()
+	defer store.Unlock()
+
+	for id, r := range store.reminders {
+		if r.UserID == userID {
+			delete(store.reminders, id)
+			store.pending.Remove(id)
+		}
+	}
+}
+
+// FireBatch marks up to limit pending reminders due at now as fired and returns them
+func (store *InMemoryStore) FireBatch(now time.Time, limit int) ([]Reminder, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	var fired []Reminder
+	for _, id := range store.pending.PopDue(now, limit) {
+		r := store.reminders[id]
+		r.Status = StatusFired
+		r.UpdatedAt = now
+		store.reminders[id] = r
+		fired = append(fired, r)
+	}
+	return fired, nil
+}
+
+func (store *InMemoryStore) NextDeadline() (time.Time, bool) {
+	store.RLock()
+	defer store.RUnlock()
+
+	_, at, ok := store.pending.Peek()
+	return at, ok
+}
+
+// ScheduleChanged signals each time a pending reminder is added or moved
+func (store *InMemoryStore) ScheduleChanged() <-chan struct{} {
+	return store.scheduleChanged
+}
+
+// schedule keeps only pending reminders in the queue and isn't thread-safe
+func (store *InMemoryStore) schedule(reminder Reminder) {
+	if reminder.Status != StatusPending {
+		store.pending.Remove(reminder.ID)
+		return
+	}
+	store.pending.Set(reminder.ID, reminder.RemindAt)
+	store.scheduleChanged.Notify()
+}
@@ -0,0 +1,93 @@
+package reminder
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGetUpcoming(t *testing.T) {
+	t.Run("should return pending reminders of user sorted by time", func(t *testing.T) {
+		store := NewInMemoryStore()
+		now := time.Now().UTC()
+		r1, err := store.CreateReminder(Reminder{NoteID: "1", UserID: "123-123", RemindAt: now.Add(2 * time.Hour)})
+		require.NoError(t, err)
+		r2, err := store.CreateReminder(Reminder{NoteID: "1", UserID: "123-123", RemindAt: now.Add(time.Hour)})
+		require.NoError(t, err)
+		_, err = store.CreateReminder(Reminder{NoteID: "1", UserID: "123-123", RemindAt: now.Add(48 * time.Hour)})
+		require.NoError(t, err)
+		_, err = store.CreateReminder(Reminder{NoteID: "1", UserID: "321-321", RemindAt: now.Add(time.Hour)})
+		require.NoError(t, err)
+
+		actual, err := store.GetUpcoming("123-123", now.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, []Reminder{r2, r1}, actual)
+	})
+}
+
+func TestFireBatch(t *testing.T) {
+	t.Run("should fire due reminders once", func(t *testing.T) {
+		store := NewInMemoryStore()
+		now := time.Now().UTC()
+		r, err := store.CreateReminder(Reminder{NoteID: "1", UserID: "123-123", RemindAt: now})
+		require.NoError(t, err)
+
+		fired, err := store.FireBatch(now, 10)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(fired))
+		require.Equal(t, StatusFired, fired[0].Status)
+
+		fired, err = store.FireBatch(now, 10)
+		require.NoError(t, err)
+		require.Empty(t, fired)
+		actual, _ := store.FindReminderByID(r.ID)
+		require.Equal(t, StatusFired, actual.Status)
+	})
+
+	t.Run("should not fire dismissed reminder", func(t *testing.T) {
+		store := NewInMemoryStore()
+		now := time.Now().UTC()
+		r, err := store.CreateReminder(Reminder{NoteID: "1", UserID: "123-123", RemindAt: now})
+		require.NoError(t, err)
+		r.Status = StatusDismissed
+		_, err = store.UpdateReminder(r)
+		require.NoError(t, err)
+
+		fired, err := store.FireBatch(now, 10)
+		require.NoError(t, err)
+		require.Empty(t, fired)
+	})
+}
+
+func TestDeleteNoteReminders(t *testing.T) {
+	t.Run("should delete reminders of note", func(t *testing.T) {
+		store := NewInMemoryStore()
+		r, err := store.CreateReminder(Reminder{NoteID: "1", UserID: "123-123", RemindAt: time.Now().UTC()})
+		require.NoError(t, err)
+
+		store.DeleteNoteReminders("1")
+		_, err = store.FindReminderByID(r.ID)
+		require.ErrorIs(t, err, ErrReminderNotFound)
+		_, ok := store.NextDeadline()
+		require.False(t, ok)
+	})
+}
+
+func TestDeleteUserReminders(t *testing.T) {
+	t.Run("should delete only reminders of user", func(t *testing.T) {
+		store := NewInMemoryStore()
+		r, err := store.CreateReminder(Reminder{NoteID: "1", UserID: "123-123", RemindAt: time.Now().UTC()})
+		require.NoError(t, err)
+		other, err := store.CreateReminder(Reminder{NoteID: "1", UserID: "456-456", RemindAt: time.Now().UTC()})
+		require.NoError(t, err)
+
+		store.DeleteUserReminders("123-123")
+		_, err = store.FindReminderByID(r.ID)
+		require.ErrorIs(t, err, ErrReminderNotFound)
+		_, err = store.FindReminderByID(other.ID)
+		require.NoError(t, err)
+		fired, err := store.FireBatch(time.Now().UTC(), 10)
+		require.NoError(t, err)
+		require.Len(t, fired, 1)
+	})
+}
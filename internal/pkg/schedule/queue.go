@@ -0,0 +1,109 @@
+package schedule
+
+import (
+	"container/heap"
+	"time"
+)
+
+// Queue is a min-heap of keyed deadlines, the earliest deadline is always on top.
+// Queue isn't thread-safe, callers should guard it with their own lock.
+type Queue struct {
+	items deadlineHeap
+	index map[string]*item
+}
+
+type item struct {
+	key string
+	at  time.Time
+	pos int
+}
+
+func NewQueue() *Queue {
+	return &Queue{index: make(map[string]*item)}
+}
+
+// Set adds key to the queue or moves it to the new deadline if it is already queued
+func (q *Queue) Set(key string, at time.Time) {
+	if it, ok := q.index[key]; ok {
+		it.at = at
+		heap.Fix(&q.items, it.pos)
+		return
+	}
+	it := &item{key: key, at: at}
+	q.index[key] = it
+	heap.Push(&q.items, it)
+}
+
+func (q *Queue) Remove(key string) bool {
+	it, ok := q.index[key]
+	if !ok {
+		return false
+	}
+	heap.Remove(&q.items, it.pos)
+	delete(q.index, key)
+	return true
+}
+
+func (q *Queue) Deadline(key string) (time.Time, bool) {
+	if it, ok := q.index[key]; ok {
+		return it.at, true
+	}
+	return time.Time{}, false
+}
+
+func (q *Queue) Peek() (string, time.Time, bool) {
+	if len(q.items) == 0 {
+		return "", time.Time{}, false
+	}
+	return q.items[0].key, q.items[0].at, true
+}
+
+func (q *Queue) Pop() (string, time.Time, bool) {
+	if len(q.items) == 0 {
+		return "", time.Time{}, false
+	}
+	it := heap.Pop(&q.items).(*item)
+	delete(q.index, it.key)
+	return it.key, it.at, true
+}
+
+// PopDue removes and returns up to limit keys whose deadline is not after now
+func (q *Queue) PopDue(now time.Time, limit int) []string {
+	var res []string
+	for len(q.items) > 0 && len(res) < limit && !q.items[0].at.After(now) {
+		key, _, _ := q.Pop()
+		res = append(res, key)
+	}
+	return res
+}
+
+func (q *Queue) Len() int {
+	return len(q.items)
+}
+
+type deadlineHeap []*item
+
+func (h deadlineHeap) Len() int { return len(h) }
+
+func (h deadlineHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+
+func (h deadlineHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *deadlineHeap) Push(x any) {
+	it := x.(*item)
+	it.pos = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *deadlineHeap) Pop() any {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return it
+}
@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("should pop keys in deadline order", func(t *testing.T) {
+		q := NewQueue()
+		q.Set("c", now.Add(3*time.Second))
+		q.Set("a", now.Add(time.Second))
+		q.Set("b", now.Add(2*time.Second))
+
+		for _, expected := range []string{"a", "b", "c"} {
+			key, _, ok := q.Pop()
+			require.True(t, ok)
+			require.Equal(t, expected, key)
+		}
+		_, _, ok := q.Pop()
+		require.False(t, ok)
+	})
+
+	t.Run("should move key to new deadline", func(t *testing.T) {
+		q := NewQueue()
+		q.Set("a", now.Add(time.Second))
+		q.Set("b", now.Add(2*time.Second))
+		q.Set("a", now.Add(3*time.Second))
+
+		key, at, ok := q.Peek()
+		require.True(t, ok)
+		require.Equal(t, "b", key)
+		require.Equal(t, now.Add(2*time.Second), at)
+		require.Equal(t, 2, q.Len())
+	})
+
+	t.Run("should remove key", func(t *testing.T) {
+		q := NewQueue()
+		q.Set("a", now)
+		q.Set("b", now.Add(time.Second))
+
+		require.True(t, q.Remove("a"))
+		require.False(t, q.Remove("a"))
+		_, ok := q.Deadline("a")
+		require.False(t, ok)
+		key, _, _ := q.Peek()
+		require.Equal(t, "b", key)
+	})
+
+	t.Run("should pop only due keys up to limit", func(t *testing.T) {
+		q := NewQueue()
+		q.Set("a", now.Add(-3*time.Second))
+		q.Set("b", now.Add(-2*time.Second))
+		q.Set("c", now.Add(-time.Second))
+		q.Set("d", now.Add(time.Second))
+
+		require.Equal(t, []string{"a", "b"}, q.PopDue(now, 2))
+		require.Equal(t, []string{"c"}, q.PopDue(now, 2))
+		require.Empty(t, q.PopDue(now, 2))
+		require.Equal(t, 1, q.Len())
+	})
+}
+
+func TestSignal(t *testing.T) {
+	t.Run("should merge notifications", func(t *testing.T) {
+		s := NewSignal()
+		s.Notify()
+		s.Notify()
+
+		<-s
+		select {
+		case <-s:
+			t.Fatal("signal should be empty")
+		default:
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package schedule
+
+import "time"
+
+// Signal wakes up a worker waiting for the next deadline without blocking the sender.
+// Several notifications before the worker wakes up are merged into one.
+type Signal chan struct{}
+
+func NewSignal() Signal {
+	return make(Signal, 1)
+}
+
+func (s Signal) Notify() {
+	select {
+	case s <- struct{}{}:
+	default:
+	}
+}
+
+// ResetTimer stops t, drains a pending tick and starts it again for d
+func ResetTimer(t *time.Timer, d time.Duration) {
+	StopTimer(t)
+	t.Reset(d)
+}
+
+func StopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package scope
+
+import "golang.org/x/exp/slices"
+
+const (
+	NotesRead  = "notes:read"
+	NotesWrite = "notes:write"
+	// Full is needed by routes that don't declare a scope, only credentials without scopes have it
+	Full = "*"
+)
+
+// Names are the scopes tokens and API keys can be limited to
+var Names = []string{NotesRead, NotesWrite}
+
+// implied are scopes included in a granted one
+var implied = map[string][]string{
+	NotesWrite: {NotesRead},
+}
+
+func Valid(name string) bool {
+	return slices.Contains(Names, name)
+}
+
+// Allows tells whether credentials with granted scopes can use a route needing required,
+// credentials without scopes have full access
+func Allows(granted []string, required string) bool {
+	if len(granted) == 0 {
+		return true
+	}
+	for _, g := range granted {
+		if g == required || slices.Contains(implied[g], required) {
+			return true
+		}
+	}
+	return false
+}
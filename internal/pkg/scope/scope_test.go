@@ -0,0 +1,28 @@
+package scope
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		expected bool
+	}{
+		{name: "no scopes is full access", required: Full, expected: true},
+		{name: "read allows read", granted: []string{NotesRead}, required: NotesRead, expected: true},
+		{name: "read doesn't allow write", granted: []string{NotesRead}, required: NotesWrite},
+		{name: "write includes read", granted: []string{NotesWrite}, required: NotesRead, expected: true},
+		{name: "scoped credentials aren't full", granted: []string{NotesRead, NotesWrite}, required: Full},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, Allows(tt.granted, tt.required))
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package template
+
+import (
+	"errors"
+	"time"
+)
+
+// Template is used to create notes, Cron makes the service create them on schedule
+type Template struct {
+	ID        string
+	UserID    string
+	Name      string
+	Subject   string
+	Text      string
+	Cron      string
+	Paused    bool
+	NextRunAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrNoSchedule       = errors.New("template has no schedule")
+)
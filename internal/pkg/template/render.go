@@ -0,0 +1,20 @@
+package template
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Render replaces {{date}}, {{time}}, {{week}}, {{year}} and {{username}} placeholders
+func Render(text string, now time.Time, username string) string {
+	year, week := now.ISOWeek()
+	r := strings.NewReplacer(
+		"{{date}}", now.Format("2006-01-02"),
+		"{{time}}", now.Format("15:04"),
+		"{{week}}", strconv.Itoa(week),
+		"{{year}}", strconv.Itoa(year),
+		"{{username}}", username,
+	)
+	return r.Replace(text)
+}
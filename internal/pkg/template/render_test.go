@@ -0,0 +1,13 @@
+package template
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	now := time.Date(2022, time.September, 20, 10, 15, 0, 0, time.UTC)
+	actual := Render("Stand-up {{date}} {{time}}, week {{week}} of {{year}} by {{username}} {{unknown}}", now, "user1")
+	require.Equal(t, "Stand-up 2022-09-20 10:15, week 38 of 2022 by user1 {{unknown}}", actual)
+}
@@ -0,0 +1,116 @@
+package template
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"note-service/internal/pkg/note"
+	"note-service/internal/pkg/schedule"
+	"sync"
+	"time"
+)
+
+type schedulerStore interface {
+	DueBatch(now time.Time, limit int) ([]Template, error)
+	AdvanceSchedule(id string, due time.Time, next *time.Time) error
+	NextDeadline() (time.Time, bool)
+	ScheduleChanged() <-chan struct{}
+}
+
+// Scheduler sleeps until the earliest template run and creates notes for due templates
+type Scheduler struct {
+	store     schedulerStore
+	service   *Service
+	batchSize int
+	logger    *zap.Logger
+	done      chan struct{}
+	stop      sync.Once
+
+	mu          sync.Mutex
+	createHooks []func(n note.Note, templateID string)
+}
+
+func NewScheduler(store schedulerStore, service *Service, batchSize int, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		store:     store,
+		service:   service,
+		batchSize: batchSize,
+		logger:    logger,
+		done:      make(chan struct{}),
+	}
+}
+
+// OnCreate registers hook which is called with every note created by the scheduler
+func (s *Scheduler) OnCreate(hook func(n note.Note, templateID string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.createHooks = append(s.createHooks, hook)
+}
+
+func (s *Scheduler) Run() error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return nil
+		case <-s.store.ScheduleChanged():
+		case <-timer.C:
+			s.runDue()
+		}
+
+		if next, ok := s.store.NextDeadline(); ok {
+			schedule.ResetTimer(timer, time.Until(next))
+		} else {
+			schedule.StopTimer(timer)
+		}
+	}
+}
+
+// Stop ends Run, it may be called more than once
+func (s *Scheduler) Stop() {
+	s.stop.Do(func() { close(s.done) })
+}
+
+func (s *Scheduler) runDue() {
+	for {
+		now := time.Now().UTC()
+		due, err := s.store.DueBatch(now, s.batchSize)
+		if err != nil {
+			s.logger.Error("failed to get due templates", zap.Error(err))
+			return
+		}
+		for _, t := range due {
+			s.run(t, now)
+		}
+		if len(due) < s.batchSize {
+			return
+		}
+	}
+}
+
+// run creates a note and stores the following run, a failed run isn't retried.
+// Changes made to the template while the note is created are kept.
+func (s *Scheduler) run(t Template, now time.Time) {
+	if n, err := s.service.createNote(t, now); err != nil {
+		s.logger.Error("failed to create note from template", zap.String("templateID", t.ID), zap.Error(err))
+	} else {
+		s.logger.Info("note was created from template", zap.String("templateID", t.ID), zap.String("noteID", n.ID))
+		s.mu.Lock()
+		hooks := s.createHooks
+		s.mu.Unlock()
+		for _, hook := range hooks {
+			hook(n, t.ID)
+		}
+	}
+
+	due := *t.NextRunAt
+	if err := setNextRun(&t, now); err != nil {
+		s.logger.Error("failed to schedule template", zap.String("templateID", t.ID), zap.Error(err))
+		return
+	}
+	if err := s.store.AdvanceSchedule(t.ID, due, t.NextRunAt); err != nil && !errors.Is(err, ErrTemplateNotFound) {
+		s.logger.Error("failed to schedule template", zap.String("templateID", t.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,88 @@
+package template
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"note-service/internal/pkg/note"
+	"note-service/internal/pkg/user"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRun(t *testing.T) {
+	t.Run("should create note and schedule the following run", func(t *testing.T) {
+		store := NewInMemoryStore()
+		created := make(chan note.Note, 10)
+		notes := &noteCreatorMock{CreateNoteFunc: func(n note.Note) (note.Note, error) {
+			created <- n
+			return n, nil
+		}}
+		users := &userFinderMock{FindUserByIDFunc: func(id string) (user.User, error) {
+			return user.User{ID: id, Username: "user1"}, nil
+		}}
+		service := NewService(store, notes, users)
+		scheduler := NewScheduler(store, service, 10, zap.NewNop())
+		hooked := make(chan string, 10)
+		scheduler.OnCreate(func(n note.Note, templateID string) { hooked <- templateID })
+		go scheduler.Run()
+		defer scheduler.Stop()
+
+		soon := time.Now().UTC().Add(50 * time.Millisecond)
+		template, err := store.CreateTemplate(Template{UserID: "123-123", Name: "daily", Text: "{{username}}", Cron: "@daily", NextRunAt: &soon})
+		require.NoError(t, err)
+
+		select {
+		case n := <-created:
+			require.Equal(t, "user1", n.Text)
+		case <-time.After(2 * time.Second):
+			t.Fatal("note wasn't created")
+		}
+		require.Equal(t, template.ID, <-hooked)
+		require.Eventually(t, func() bool {
+			actual, _ := store.FindTemplateByID(template.ID)
+			return actual.NextRunAt != nil && actual.NextRunAt.After(soon)
+		}, time.Second, 10*time.Millisecond)
+	})
+	t.Run("should keep changes made while the note is created", func(t *testing.T) {
+		store := NewInMemoryStore()
+		soon := time.Now().UTC().Add(50 * time.Millisecond)
+		template, err := store.CreateTemplate(Template{UserID: "123-123", Name: "daily", Text: "text", Cron: "@daily", NextRunAt: &soon})
+		require.NoError(t, err)
+		created := make(chan note.Note, 10)
+		notes := &noteCreatorMock{CreateNoteFunc: func(n note.Note) (note.Note, error) {
+			paused := template
+			paused.Paused = true
+			paused.Text = "edited"
+			_, err := store.UpdateTemplate(paused)
+			require.NoError(t, err)
+			created <- n
+			return n, nil
+		}}
+		users := &userFinderMock{FindUserByIDFunc: func(id string) (user.User, error) {
+			return user.User{ID: id, Username: "user1"}, nil
+		}}
+		scheduler := NewScheduler(store, NewService(store, notes, users), 10, zap.NewNop())
+		go scheduler.Run()
+		defer scheduler.Stop()
+
+		select {
+		case <-created:
+		case <-time.After(2 * time.Second):
+			t.Fatal("note wasn't created")
+		}
+		time.Sleep(50 * time.Millisecond)
+		actual, err := store.FindTemplateByID(template.ID)
+		require.NoError(t, err)
+		require.True(t, actual.Paused)
+		require.Equal(t, "edited", actual.Text)
+		require.True(t, actual.NextRunAt.Equal(soon))
+	})
+	t.Run("should stop more than once", func(t *testing.T) {
+		scheduler := NewScheduler(NewInMemoryStore(), nil, 10, zap.NewNop())
+		stopped := make(chan error)
+		go func() { stopped <- scheduler.Run() }()
+		scheduler.Stop()
+		scheduler.Stop()
+		require.NoError(t, <-stopped)
+	})
+}
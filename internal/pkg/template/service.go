@@ -0,0 +1,158 @@
+package template
+
+import (
+	"fmt"
+	"note-service/internal/app"
+	"note-service/internal/pkg/cron"
+	"note-service/internal/pkg/note"
+	"note-service/internal/pkg/user"
+	"time"
+)
+
+type store interface {
+	CreateTemplate(t Template) (Template, error)
+	FindTemplateByID(id string) (Template, error)
+	GetTemplates(userID string) ([]Template, error)
+	UpdateTemplate(t Template) (Template, error)
+	DeleteTemplate(id string) error
+}
+
+type noteCreator interface {
+	CreateNote(note note.Note) (note.Note, error)
+}
+
+type userFinder interface {
+	FindUserByID(id string) (user.User, error)
+}
+
+type Service struct {
+	store store
+	notes noteCreator
+	users userFinder
+}
+
+func NewService(store store, notes noteCreator, users userFinder) *Service {
+	return &Service{store: store, notes: notes, users: users}
+}
+
+func (s *Service) CreateTemplate(t Template) (Template, error) {
+	if err := setNextRun(&t, time.Now().UTC()); err != nil {
+		return Template{}, err
+	}
+	return s.store.CreateTemplate(t)
+}
+
+func (s *Service) GetTemplates(userID string) ([]Template, error) {
+	return s.store.GetTemplates(userID)
+}
+
+func (s *Service) DeleteTemplate(id, userID string) error {
+	if _, err := s.findOwnTemplate(id, userID); err != nil {
+		return err
+	}
+	return s.store.DeleteTemplate(id)
+}
+
+// CreateNote renders template for its owner and creates a note from it
+func (s *Service) CreateNote(id, userID string) (note.Note, error) {
+	t, err := s.findOwnTemplate(id, userID)
+	if err != nil {
+		return note.Note{}, err
+	}
+	return s.createNote(t, time.Now().UTC())
+}
+
+// GetSchedules returns templates of user which have a cron expression
+func (s *Service) GetSchedules(userID string) ([]Template, error) {
+	templates, err := s.store.GetTemplates(userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Template, 0, len(templates))
+	for _, t := range templates {
+		if t.Cron != "" {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (s *Service) PauseSchedule(id, userID string) (Template, error) {
+	return s.updateSchedule(id, userID, func(t *Template) error {
+		t.Paused = true
+		return nil
+	})
+}
+
+func (s *Service) ResumeSchedule(id, userID string) (Template, error) {
+	return s.updateSchedule(id, userID, func(t *Template) error {
+		t.Paused = false
+		return setNextRun(t, time.Now().UTC())
+	})
+}
+
+// DeleteSchedule stops automatic runs but keeps the template
+func (s *Service) DeleteSchedule(id, userID string) (Template, error) {
+	return s.updateSchedule(id, userID, func(t *Template) error {
+		t.Cron = ""
+		t.Paused = false
+		t.NextRunAt = nil
+		return nil
+	})
+}
+
+func (s *Service) updateSchedule(id, userID string, update func(t *Template) error) (Template, error) {
+	t, err := s.findOwnTemplate(id, userID)
+	if err != nil {
+		return Template{}, err
+	}
+	if t.Cron == "" {
+		return Template{}, ErrNoSchedule
+	}
+	if err = update(&t); err != nil {
+		return Template{}, err
+	}
+	return s.store.UpdateTemplate(t)
+}
+
+func (s *Service) createNote(t Template, now time.Time) (note.Note, error) {
+	u, err := s.users.FindUserByID(t.UserID)
+	if err != nil {
+		return note.Note{}, fmt.Errorf("failed to find template owner: %w", err)
+	}
+	return s.notes.CreateNote(note.Note{
+		UserID:  t.UserID,
+		Subject: Render(t.Subject, now, u.Username),
+		Text:    Render(t.Text, now, u.Username),
+	})
+}
+
+func (s *Service) findOwnTemplate(id, userID string) (Template, error) {
+	t, err := s.store.FindTemplateByID(id)
+	if err != nil {
+		return Template{}, err
+	}
+	if t.UserID != userID {
+		return Template{}, app.ErrNoAccess
+	}
+	return t, nil
+}
+
+// setNextRun validates cron expression and sets the first run after now
+func setNextRun(t *Template, now time.Time) error {
+	if t.Cron == "" {
+		t.NextRunAt = nil
+		return nil
+	}
+	sched, err := cron.Parse(t.Cron)
+	if err != nil {
+		return err
+	}
+	next := sched.Next(now)
+	if next.IsZero() {
+		t.NextRunAt = nil
+		return nil
+	}
+	t.NextRunAt = &next
+	return nil
+}
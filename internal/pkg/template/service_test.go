@@ -0,0 +1,125 @@
+package template
+
+import (
+	"github.com/stretchr/testify/require"
+	"note-service/internal/app"
+	"note-service/internal/pkg/cron"
+	"note-service/internal/pkg/note"
+	"note-service/internal/pkg/user"
+	"testing"
+	"time"
+)
+
+type noteCreatorMock struct {
+	CreateNoteFunc func(note note.Note) (note.Note, error)
+}
+
+func (n *noteCreatorMock) CreateNote(note note.Note) (note.Note, error) {
+	return n.CreateNoteFunc(note)
+}
+
+type userFinderMock struct {
+	FindUserByIDFunc func(id string) (user.User, error)
+}
+
+func (u *userFinderMock) FindUserByID(id string) (user.User, error) {
+	return u.FindUserByIDFunc(id)
+}
+
+func TestServiceCreateTemplate(t *testing.T) {
+	t.Run("should set next run of schedule", func(t *testing.T) {
+		s := NewService(NewInMemoryStore(), &noteCreatorMock{}, &userFinderMock{})
+		actual, err := s.CreateTemplate(Template{UserID: "123-123", Name: "retro", Text: "123", Cron: "@weekly"})
+		require.NoError(t, err)
+		require.NotNil(t, actual.NextRunAt)
+		require.Equal(t, time.Sunday, actual.NextRunAt.Weekday())
+	})
+
+	t.Run("should return ErrInvalidExpression", func(t *testing.T) {
+		s := NewService(NewInMemoryStore(), &noteCreatorMock{}, &userFinderMock{})
+		_, err := s.CreateTemplate(Template{UserID: "123-123", Name: "retro", Text: "123", Cron: "weekly"})
+		require.ErrorIs(t, err, cron.ErrInvalidExpression)
+	})
+}
+
+func TestServiceCreateNote(t *testing.T) {
+	tests := []struct {
+		name          string
+		template      Template
+		userID        string
+		expectedNote  note.Note
+		expectedError error
+	}{
+		{
+			name:          "should return ErrNoAccess",
+			template:      Template{UserID: "321-321", Name: "retro", Text: "123"},
+			userID:        "123-123",
+			expectedError: app.ErrNoAccess,
+		},
+		{
+			name:         "should create rendered note",
+			template:     Template{UserID: "123-123", Name: "retro", Subject: "Retro {{username}}", Text: "Week {{week}}"},
+			userID:       "123-123",
+			expectedNote: note.Note{UserID: "123-123", Subject: "Retro user1"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewInMemoryStore()
+			template, err := store.CreateTemplate(tt.template)
+			require.NoError(t, err)
+			notes := &noteCreatorMock{CreateNoteFunc: func(n note.Note) (note.Note, error) {
+				return n, nil
+			}}
+			users := &userFinderMock{FindUserByIDFunc: func(id string) (user.User, error) {
+				return user.User{ID: id, Username: "user1"}, nil
+			}}
+			s := NewService(store, notes, users)
+
+			n, err := s.CreateNote(template.ID, tt.userID)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedNote.Subject, n.Subject)
+			require.Equal(t, tt.expectedNote.UserID, n.UserID)
+			require.Contains(t, n.Text, "Week ")
+		})
+	}
+}
+
+func TestServiceSchedules(t *testing.T) {
+	t.Run("should pause, resume and delete schedule", func(t *testing.T) {
+		store := NewInMemoryStore()
+		s := NewService(store, &noteCreatorMock{}, &userFinderMock{})
+		template, err := s.CreateTemplate(Template{UserID: "123-123", Name: "stand-up", Text: "123", Cron: "0 10 * * 1-5"})
+		require.NoError(t, err)
+		_, err = s.CreateTemplate(Template{UserID: "123-123", Name: "plain", Text: "123"})
+		require.NoError(t, err)
+
+		schedules, err := s.GetSchedules("123-123")
+		require.NoError(t, err)
+		require.Equal(t, 1, len(schedules))
+
+		paused, err := s.PauseSchedule(template.ID, "123-123")
+		require.NoError(t, err)
+		require.True(t, paused.Paused)
+		_, ok := store.NextDeadline()
+		require.False(t, ok)
+
+		resumed, err := s.ResumeSchedule(template.ID, "123-123")
+		require.NoError(t, err)
+		require.False(t, resumed.Paused)
+		_, ok = store.NextDeadline()
+		require.True(t, ok)
+
+		deleted, err := s.DeleteSchedule(template.ID, "123-123")
+		require.NoError(t, err)
+		require.Empty(t, deleted.Cron)
+		_, err = s.DeleteSchedule(template.ID, "123-123")
+		require.ErrorIs(t, err, ErrNoSchedule)
+	})
+}
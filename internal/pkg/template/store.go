@@ -0,0 +1,158 @@
+package template
+
+import (
+	"note-service/internal/pkg/schedule"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// templates map[templateId]Template
+// runs is a queue of next runs of active schedules keyed by templateId
+type InMemoryStore struct {
+	sync.RWMutex
+	templates       map[string]Template
+	runs            *schedule.Queue
+	scheduleChanged schedule.Signal
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		templates:       make(map[string]Template),
+		runs:            schedule.NewQueue(),
+		scheduleChanged: schedule.NewSignal(),
+	}
+}
+
+func (store *InMemoryStore) CreateTemplate(t Template) (Template, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	t.ID = uuid.NewString()
+	t.CreatedAt = time.Now().UTC()
+	store.templates[t.ID] = t
+	store.schedule(t)
+	return t, nil
+}
+
+func (store *InMemoryStore) FindTemplateByID(id string) (Template, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	if t, ok := store.templates[id]; ok {
+		return t, nil
+	}
+	return Template{}, ErrTemplateNotFound
+}
+
+// GetTemplates returns templates of user sorted by name
+func (store *InMemoryStore) GetTemplates(userID string) ([]Template, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	res := make([]Template, 0)
+	for _, t := range store.templates {
+		if t.UserID == userID {
+			res = append(res, t)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+func (store *InMemoryStore) UpdateTemplate(t Template) (Template, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	if _, ok := store.templates[t.ID]; !ok {
+		return Template{}, ErrTemplateNotFound
+	}
+	t.UpdatedAt = time.Now().UTC()
+	store.templates[t.ID] = t
+	store.schedule(t)
+	return t, nil
+}
+
+func (store *InMemoryStore) DeleteTemplate(id string) error {
+	store.Lock()
+	defer store.Unlock()
+
+	if _, ok := store.templates[id]; !ok {
+		return ErrTemplateNotFound
+	}
+	delete(store.templates, id)
+	store.runs.Remove(id)
+	return nil
+}
+
+// DeleteUserTemplates deletes all templates of the user together with their schedules
+func (store *InMemoryStore) DeleteUserTemplates(userID string) {
+	store.Lock()
+	defer store.Unlock()
+
+	for id, t := range store.templates {
+		if t.UserID == userID {
+			delete(store.templates, id)
+			store.runs.Remove(id)
+		}
+	}
+}
+
+// DueBatch removes up to limit templates whose next run is not after now from the queue and returns them.
+// The caller is expected to store their following run with AdvanceSchedule.
+func (store *InMemoryStore) DueBatch(now time.Time, limit int) ([]Template, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	var due []Template
+	for _, id := range store.runs.PopDue(now, limit) {
+		due = append(due, store.templates[id])
+	}
+	return due, nil
+}
+
+// AdvanceSchedule moves the template from the run it was due at to the next one, nil ends the schedule.
+// A template paused or rescheduled since DueBatch is left as it is, other fields are never touched.
+func (store *InMemoryStore) AdvanceSchedule(id string, due time.Time, next *time.Time) error {
+	store.Lock()
+	defer store.Unlock()
+
+	t, ok := store.templates[id]
+	if !ok {
+		return ErrTemplateNotFound
+	}
+	if t.Paused || t.NextRunAt == nil || !t.NextRunAt.Equal(due) {
+		return nil
+	}
+	t.NextRunAt = next
+	store.templates[id] = t
+	store.schedule(t)
+	return nil
+}
+
+func (store *InMemoryStore) NextDeadline() (time.Time, bool) {
+	store.RLock()
+	defer store.RUnlock()
+
+	_, at, ok := store.runs.Peek()
+	return at, ok
+}
+
+// ScheduleChanged signals each time a run is added or moved
+func (store *InMemoryStore) ScheduleChanged() <-chan struct{} {
+	return store.scheduleChanged
+}
+
+// schedule keeps only active schedules in the queue and isn't thread-safe
+func (store *InMemoryStore) schedule(t Template) {
+	if t.Paused || t.NextRunAt == nil {
+		store.runs.Remove(t.ID)
+		return
+	}
+	store.runs.Set(t.ID, *t.NextRunAt)
+	store.scheduleChanged.Notify()
+}
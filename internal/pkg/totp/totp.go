@@ -0,0 +1,87 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period is the time step of RFC 6238 in seconds
+	Period = 30
+	Digits = 6
+
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret returns a random secret in base32 as authenticator apps expect it
+func NewSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// URI is the otpauth:// provisioning URI which authenticator apps read from a QR code
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(Digits)},
+		"period":    {fmt.Sprint(Period)},
+	}
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Counter is the time step number of t
+func Counter(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// Code is the HOTP value (RFC 4226) of the counter with given number of digits
+func Code(secret []byte, counter int64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// Validate accepts the code of the time step of now or of the neighbouring ones to allow for clock drift.
+// It returns the counter of the matched step, codes of steps up to after are rejected to prevent replay.
+func Validate(secret, code string, now time.Time, after int64) (int64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != Digits {
+		return 0, false
+	}
+	current := Counter(now)
+	for counter := current - 1; counter <= current+1; counter++ {
+		if counter <= after {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(Code(key, counter, Digits)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
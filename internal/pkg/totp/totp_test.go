@@ -0,0 +1,58 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 key of RFC 6238 appendix B
+var rfcSecret = []byte("12345678901234567890")
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "94287082"},
+		{unix: 1111111109, expected: "07081804"},
+		{unix: 1111111111, expected: "14050471"},
+		{unix: 1234567890, expected: "89005924"},
+		{unix: 2000000000, expected: "69279037"},
+		{unix: 20000000000, expected: "65353130"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, Code(rfcSecret, Counter(time.Unix(tt.unix, 0)), 8), tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := encoding.EncodeToString(rfcSecret)
+	now := time.Unix(1111111111, 0)
+	code := Code(rfcSecret, Counter(now), Digits)
+
+	counter, ok := Validate(secret, code, now, 0)
+	require.True(t, ok)
+	require.Equal(t, Counter(now), counter)
+
+	_, ok = Validate(secret, code, now.Add(Period*time.Second), 0)
+	require.True(t, ok, "previous step is accepted for clock drift")
+	_, ok = Validate(secret, code, now.Add(2*Period*time.Second), 0)
+	require.False(t, ok)
+	_, ok = Validate(secret, code, now, counter)
+	require.False(t, ok, "used code is rejected")
+	_, ok = Validate(secret, "000000", now, 0)
+	require.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("note-service", "alice", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/note-service:alice", u.Path)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	require.Equal(t, "note-service", u.Query().Get("issuer"))
+}
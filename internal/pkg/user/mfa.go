@@ -0,0 +1,217 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"note-service/internal/pkg/totp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	purposeMFA = "mfa"
+
+	challengeTTL      = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+type mfaStore interface {
+	FindUserByID(id string) (User, error)
+	UpdateUser(id string, update func(u *User) error) (User, error)
+	UseToken(nonce string, expiresAt time.Time) error
+	SetRecoveryCodes(userID string, hashes []string)
+	UseRecoveryCode(userID, hash string) error
+}
+
+// MFA is TOTP two-factor authentication. After the password step of login a user with it enabled
+// gets a short-lived challenge which is exchanged for a token together with a TOTP or recovery code.
+type MFA struct {
+	store    mfaStore
+	throttle *Throttle
+	key      []byte
+	issuer   string
+}
+
+func NewMFA(store mfaStore, key []byte, issuer string) *MFA {
+	return &MFA{store: store, throttle: NewThrottle(DefaultThrottleConfig), key: key, issuer: issuer}
+}
+
+// SetThrottle shares the throttle with Service, so wrong codes count as failed logins of the account
+func (m *MFA) SetThrottle(throttle *Throttle) {
+	m.throttle = throttle
+}
+
+// Enroll makes a new secret, two-factor authentication is enabled only after Confirm
+func (m *MFA) Enroll(userID string) (secret, uri string, err error) {
+	u, err := m.store.FindUserByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if u.TOTPEnabled {
+		return "", "", ErrMFAEnabled
+	}
+	if secret, err = totp.NewSecret(); err != nil {
+		return "", "", err
+	}
+	if _, err = m.store.UpdateUser(userID, func(u *User) error {
+		if u.TOTPEnabled {
+			return ErrMFAEnabled
+		}
+		u.TOTPSecret = secret
+		return nil
+	}); err != nil {
+		return "", "", err
+	}
+	return secret, totp.URI(m.issuer, u.Username, secret), nil
+}
+
+// Confirm enables two-factor authentication when the code matches the enrolled secret
+// and returns recovery codes, they are stored hashed and can't be shown again
+func (m *MFA) Confirm(userID, code string) ([]string, error) {
+	u, err := m.store.FindUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case u.TOTPEnabled:
+		return nil, ErrMFAEnabled
+	case u.TOTPSecret == "":
+		return nil, ErrMFANotEnrolled
+	}
+	counter, ok := totp.Validate(u.TOTPSecret, code, time.Now(), u.TOTPCounter)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = m.store.UpdateUser(userID, func(current *User) error {
+		switch {
+		case current.TOTPEnabled:
+			return ErrMFAEnabled
+		case current.TOTPSecret != u.TOTPSecret:
+			// enrolled again while the code was checked
+			return ErrInvalidCode
+		}
+		current.TOTPEnabled = true
+		current.TOTPCounter = counter
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	m.store.SetRecoveryCodes(u.ID, hashes)
+	return codes, nil
+}
+
+// Disable needs both the password and a TOTP or recovery code
+func (m *MFA) Disable(userID, password, code string) error {
+	u, err := m.store.FindUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !u.TOTPEnabled {
+		return ErrMFADisabled
+	}
+	if err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)); err != nil {
+		return ErrWrongPassword
+	}
+	if u, err = m.checkCode(u, code); err != nil {
+		return err
+	}
+	if _, err = m.store.UpdateUser(userID, func(u *User) error {
+		u.TOTPEnabled = false
+		u.TOTPSecret = ""
+		u.TOTPCounter = 0
+		return nil
+	}); err != nil {
+		return err
+	}
+	m.store.SetRecoveryCodes(u.ID, nil)
+	return nil
+}
+
+// Challenge is given instead of a token by the password step of login
+func (m *MFA) Challenge(u User) string {
+	return signToken(m.key, u.ID, purposeMFA, u.Password, time.Now().Add(challengeTTL))
+}
+
+// CompleteLogin checks the code for the challenge, a challenge can be used once.
+// Wrong codes are throttled like wrong passwords, a new challenge doesn't reset the count.
+func (m *MFA) CompleteLogin(challenge, code, ip string) (User, error) {
+	p, err := readToken(challenge, purposeMFA, time.Now())
+	if err != nil {
+		return User{}, err
+	}
+	u, err := m.store.FindUserByID(p.UserID)
+	if err != nil || !u.TOTPEnabled || !verifyToken(m.key, challenge, u.Password) {
+		return User{}, ErrInvalidToken
+	}
+	if err = m.throttle.Check(u.Username, ip); err != nil {
+		return User{}, err
+	}
+	// the challenge is spent before the code check, so each password step gives one attempt
+	if err = m.store.UseToken(p.Nonce, time.Unix(p.ExpiresAt, 0)); err != nil {
+		return User{}, err
+	}
+	if u.Disabled {
+		return User{}, ErrUserDisabled
+	}
+	logged, err := m.checkCode(u, code)
+	switch {
+	case errors.Is(err, ErrInvalidCode):
+		m.throttle.Fail(u.Username, ip)
+	case err == nil:
+		m.throttle.Succeed(u.Username)
+	}
+	return logged, err
+}
+
+// checkCode accepts a TOTP code not used before or a recovery code, which is spent
+func (m *MFA) checkCode(u User, code string) (User, error) {
+	code = strings.TrimSpace(code)
+	if counter, ok := totp.Validate(u.TOTPSecret, code, time.Now(), u.TOTPCounter); ok {
+		return m.store.UpdateUser(u.ID, func(current *User) error {
+			// a code is accepted once even by concurrent requests
+			if current.TOTPSecret != u.TOTPSecret || counter <= current.TOTPCounter {
+				return ErrInvalidCode
+			}
+			current.TOTPCounter = counter
+			return nil
+		})
+	}
+	if err := m.store.UseRecoveryCode(u.ID, hashRecoveryCode(code)); err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns codes like abcde-fghij and their hashes. Codes are random,
+// so a fast hash is enough to keep them unreadable in the store.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		c := strings.ToLower(recoveryEncoding.EncodeToString(raw))[:10]
+		codes[i] = c[:5] + "-" + c[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
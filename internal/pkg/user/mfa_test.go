@@ -0,0 +1,138 @@
+package user
+
+import (
+	"encoding/base32"
+	"note-service/internal/pkg/totp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func totpCode(t *testing.T, secret string, step int64) string {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	require.NoError(t, err)
+	return totp.Code(key, totp.Counter(time.Now())+step, totp.Digits)
+}
+
+func newTestMFA(t *testing.T) (*MFA, *InMemoryStore, User) {
+	store := NewInMemoryStore()
+	u, err := store.CreateUser("username1", hashPassword("password123"))
+	require.NoError(t, err)
+	return NewMFA(store, []byte("key"), "note-service"), store, u
+}
+
+func TestEnrollMFA(t *testing.T) {
+	mfa, store, u := newTestMFA(t)
+
+	_, err := mfa.Confirm(u.ID, "123456")
+	require.ErrorIs(t, err, ErrMFANotEnrolled)
+
+	secret, uri, err := mfa.Enroll(u.ID)
+	require.NoError(t, err)
+	require.Contains(t, uri, "secret="+secret)
+
+	_, err = mfa.Confirm(u.ID, "000000")
+	require.ErrorIs(t, err, ErrInvalidCode)
+	u, _ = store.FindUserByID(u.ID)
+	require.False(t, u.TOTPEnabled)
+
+	codes, err := mfa.Confirm(u.ID, totpCode(t, secret, 0))
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+	u, _ = store.FindUserByID(u.ID)
+	require.True(t, u.TOTPEnabled)
+
+	_, _, err = mfa.Enroll(u.ID)
+	require.ErrorIs(t, err, ErrMFAEnabled)
+}
+
+func TestCompleteLogin(t *testing.T) {
+	mfa, _, u := newTestMFA(t)
+	secret, _, err := mfa.Enroll(u.ID)
+	require.NoError(t, err)
+	used := totpCode(t, secret, 0)
+	codes, err := mfa.Confirm(u.ID, used)
+	require.NoError(t, err)
+	u, _ = mfa.store.FindUserByID(u.ID)
+
+	t.Run("should reject used totp code", func(t *testing.T) {
+		_, err := mfa.CompleteLogin(mfa.Challenge(u), used, "10.0.0.1")
+		require.ErrorIs(t, err, ErrInvalidCode)
+	})
+
+	t.Run("should accept challenge once", func(t *testing.T) {
+		challenge := mfa.Challenge(u)
+		logged, err := mfa.CompleteLogin(challenge, totpCode(t, secret, 1), "10.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, u.ID, logged.ID)
+
+		_, err = mfa.CompleteLogin(challenge, codes[1], "10.0.0.1")
+		require.ErrorIs(t, err, ErrTokenUsed)
+		_, err = mfa.CompleteLogin(mfa.Challenge(u), codes[1], "10.0.0.1")
+		require.NoError(t, err)
+	})
+
+	t.Run("should accept recovery code once", func(t *testing.T) {
+		code := " " + codes[0][:5] + codes[0][6:] + " "
+		_, err := mfa.CompleteLogin(mfa.Challenge(u), code, "10.0.0.1")
+		require.NoError(t, err)
+
+		_, err = mfa.CompleteLogin(mfa.Challenge(u), codes[0], "10.0.0.1")
+		require.ErrorIs(t, err, ErrInvalidCode)
+	})
+
+	t.Run("should reject invalid challenge", func(t *testing.T) {
+		_, err := mfa.CompleteLogin("challenge", codes[2], "10.0.0.1")
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestCompleteLoginThrottle(t *testing.T) {
+	mfa, store, u := newTestMFA(t)
+	secret, _, err := mfa.Enroll(u.ID)
+	require.NoError(t, err)
+	_, err = mfa.Confirm(u.ID, totpCode(t, secret, 0))
+	require.NoError(t, err)
+	throttle := NewThrottle(ThrottleConfig{AccountAttempts: 1, IPAttempts: 10, BaseDelay: time.Minute,
+		MaxDelay: time.Minute, LockAttempts: 2, LockDuration: time.Hour, Window: time.Hour})
+	service := NewService(store)
+	service.SetThrottle(throttle)
+	mfa.SetThrottle(throttle)
+
+	for i := 0; i < 2; i++ {
+		// the password step gives a fresh challenge but doesn't forget wrong codes
+		u, err = service.Login("username1", "password123", "10.0.0.1")
+		require.NoError(t, err)
+		_, err = mfa.CompleteLogin(mfa.Challenge(u), "000000", "10.0.0.1")
+		require.ErrorIs(t, err, ErrInvalidCode)
+	}
+	_, err = service.Login("username1", "password123", "10.0.0.2")
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+	_, err = mfa.CompleteLogin(mfa.Challenge(u), totpCode(t, secret, 1), "10.0.0.2")
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+
+	require.NoError(t, service.UnlockUser(u.ID))
+	_, err = mfa.CompleteLogin(mfa.Challenge(u), totpCode(t, secret, 1), "10.0.0.2")
+	require.NoError(t, err)
+}
+
+func TestDisableMFA(t *testing.T) {
+	mfa, store, u := newTestMFA(t)
+	require.ErrorIs(t, mfa.Disable(u.ID, "password123", "123456"), ErrMFADisabled)
+
+	secret, _, err := mfa.Enroll(u.ID)
+	require.NoError(t, err)
+	codes, err := mfa.Confirm(u.ID, totpCode(t, secret, 0))
+	require.NoError(t, err)
+
+	require.ErrorIs(t, mfa.Disable(u.ID, "wrong password", codes[0]), ErrWrongPassword)
+	require.ErrorIs(t, mfa.Disable(u.ID, "password123", "000000"), ErrInvalidCode)
+	require.NoError(t, mfa.Disable(u.ID, "password123", codes[0]))
+
+	u, _ = store.FindUserByID(u.ID)
+	require.False(t, u.TOTPEnabled)
+	require.Empty(t, u.TOTPSecret)
+	require.ErrorIs(t, store.UseRecoveryCode(u.ID, hashRecoveryCode(codes[1])), ErrInvalidCode)
+}
@@ -1,14 +1,88 @@
 package user
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
 
+// User has RoleUser or RoleAdmin, a disabled user can't log in
 type User struct {
-	ID       string
-	Username string
-	Password string
+	ID            string
+	Username      string
+	Password      string
+	Role          string
+	Disabled      bool
+	DisplayName   string
+	Email         string
+	EmailVerified bool
+	Locale        string
+	TimeZone      string
+	TOTPSecret    string
+	TOTPEnabled   bool
+	TOTPCounter   int64
+	CreatedAt     time.Time
+}
+
+// ExternalIdentity is an account at an OpenID Connect provider linked to a user
+type ExternalIdentity struct {
+	Provider string
+	Subject  string
+	UserID   string
+	Email    string
+	LinkedAt time.Time
+}
+
+type UserStats struct {
+	Users    int
+	Admins   int
+	Disabled int
+}
+
+// ProfileUpdate changes only the fields which are set
+type ProfileUpdate struct {
+	DisplayName *string
+	Email       *string
+	Locale      *string
+	TimeZone    *string
+}
+
+func (u ProfileUpdate) Apply(user User) User {
+	if u.DisplayName != nil {
+		user.DisplayName = *u.DisplayName
+	}
+	if u.Email != nil && *u.Email != user.Email {
+		user.Email = *u.Email
+		user.EmailVerified = false
+	}
+	if u.Locale != nil {
+		user.Locale = *u.Locale
+	}
+	if u.TimeZone != nil {
+		user.TimeZone = *u.TimeZone
+	}
+	return user
 }
 
 var (
-	ErrUserNotFound = errors.New("user was not found")
-	ErrUsedUsername = errors.New("username already in use")
+	ErrUserNotFound   = errors.New("user was not found")
+	ErrUsedUsername   = errors.New("username already in use")
+	ErrUsedEmail      = errors.New("email already in use")
+	ErrWrongPassword  = errors.New("wrong password")
+	ErrInvalidToken   = errors.New("invalid token")
+	ErrTokenExpired   = errors.New("token has expired")
+	ErrTokenUsed      = errors.New("token was already used")
+	ErrNoEmail        = errors.New("user has no email")
+	ErrMFAEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrMFADisabled    = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolled = errors.New("start two-factor enrollment first")
+	ErrInvalidCode    = errors.New("invalid code")
+	ErrUserDisabled   = errors.New("user is disabled")
+	ErrRole           = errors.New("unknown role")
+	ErrIdentityLinked = errors.New("external identity is linked to another user")
+	ErrNoIdentity     = errors.New("external identity is not linked")
 )
@@ -0,0 +1,172 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/mail"
+	"time"
+)
+
+const (
+	resetTokenTTL  = time.Hour
+	verifyTokenTTL = 48 * time.Hour
+)
+
+type recoveryStore interface {
+	FindUserByName(name string) (User, error)
+	FindUserByEmail(email string) (User, error)
+	FindUserByID(id string) (User, error)
+	UpdateUser(id string, update func(u *User) error) (User, error)
+	UseToken(nonce string, expiresAt time.Time) error
+}
+
+// Links are pages of the client which take the token from the "token" query parameter
+type Links struct {
+	ResetPassword string
+	VerifyEmail   string
+}
+
+// Recovery sends password reset and email verification tokens. Tokens are signed with key,
+// valid for a limited time and accepted once.
+type Recovery struct {
+	store  recoveryStore
+	mailer mail.Mailer
+	key    []byte
+	links  Links
+}
+
+func NewRecovery(store recoveryStore, mailer mail.Mailer, key []byte, links Links) *Recovery {
+	return &Recovery{store: store, mailer: mailer, key: key, links: links}
+}
+
+// RequestPasswordReset mails a reset token to the user found by username or email.
+// Unknown users and users without email get no error, so the response doesn't tell whether the account exists.
+// The mailer is expected to queue the message (see mail.Queue) and the send error is dropped
+// for the same reason, the queue logs failures itself.
+func (r *Recovery) RequestPasswordReset(login string) error {
+	u, err := r.store.FindUserByName(login)
+	if err != nil {
+		if u, err = r.store.FindUserByEmail(login); err != nil {
+			return nil
+		}
+	}
+	if u.Email == "" {
+		return nil
+	}
+	_ = r.sendReset(u)
+	return nil
+}
+
+func (r *Recovery) sendReset(u User) error {
+	token := signToken(r.key, u.ID, purposeReset, u.Password, time.Now().Add(resetTokenTTL))
+	return r.mailer.Send(mail.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello, %s!\n\nTo set a new password open %s\nThe link is valid for an hour. "+
+			"If you didn't ask to reset the password, ignore this message.\n", u.Username, link(r.links.ResetPassword, token)),
+	})
+}
+
+// ResetPassword sets the password and signs out every session. The email which got the token counts as verified.
+func (r *Recovery) ResetPassword(token, password string) (User, error) {
+	u, p, err := r.checkToken(token, purposeReset, func(u User) string { return u.Password })
+	if err != nil {
+		return User{}, err
+	}
+	if err := r.store.UseToken(p.Nonce, time.Unix(p.ExpiresAt, 0)); err != nil {
+		return User{}, err
+	}
+	hash := hashPassword(password)
+	u, err = r.store.UpdateUser(u.ID, func(current *User) error {
+		// the token is bound to the password it was issued for
+		if current.Password != u.Password {
+			return ErrInvalidToken
+		}
+		current.Password = hash
+		current.EmailVerified = true
+		return nil
+	})
+	if err != nil {
+		return User{}, err
+	}
+	jwt.RevokeTokens(u.ID)
+	return u, nil
+}
+
+// ForceReset is done by an admin: the old password stops working, every session is signed out
+// and the user gets a reset link by email
+func (r *Recovery) ForceReset(userID string) error {
+	u, err := r.store.FindUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if u.Email == "" {
+		return ErrNoEmail
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	hash := hashPassword(hex.EncodeToString(raw))
+	if u, err = r.store.UpdateUser(u.ID, func(u *User) error {
+		u.Password = hash
+		return nil
+	}); err != nil {
+		return err
+	}
+	jwt.RevokeTokens(u.ID)
+	return r.sendReset(u)
+}
+
+func (r *Recovery) SendVerification(userID string) error {
+	u, err := r.store.FindUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if u.Email == "" {
+		return ErrNoEmail
+	}
+	token := signToken(r.key, u.ID, purposeVerify, u.Email, time.Now().Add(verifyTokenTTL))
+	return r.mailer.Send(mail.Message{
+		To:      u.Email,
+		Subject: "Confirm your email",
+		Body:    fmt.Sprintf("Hello, %s!\n\nTo confirm your email open %s\n", u.Username, link(r.links.VerifyEmail, token)),
+	})
+}
+
+func (r *Recovery) VerifyEmail(token string) (User, error) {
+	u, p, err := r.checkToken(token, purposeVerify, func(u User) string { return u.Email })
+	if err != nil {
+		return User{}, err
+	}
+	if err := r.store.UseToken(p.Nonce, time.Unix(p.ExpiresAt, 0)); err != nil {
+		return User{}, err
+	}
+	return r.store.UpdateUser(u.ID, func(current *User) error {
+		// the token is bound to the email it was sent to
+		if current.Email != u.Email {
+			return ErrInvalidToken
+		}
+		current.EmailVerified = true
+		return nil
+	})
+}
+
+func (r *Recovery) checkToken(token, purpose string, state func(User) string) (User, tokenPayload, error) {
+	p, err := readToken(token, purpose, time.Now())
+	if err != nil {
+		return User{}, tokenPayload{}, err
+	}
+	u, err := r.store.FindUserByID(p.UserID)
+	if err != nil || !verifyToken(r.key, token, state(u)) {
+		return User{}, tokenPayload{}, ErrInvalidToken
+	}
+	return u, p, nil
+}
+
+func link(page, token string) string {
+	return page + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,156 @@
+package user
+
+import (
+	"net/url"
+	"note-service/internal/pkg/jwt"
+	"note-service/internal/pkg/mail"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type mailerMock struct {
+	sent []mail.Message
+}
+
+func (m *mailerMock) Send(msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var tokenInLink = regexp.MustCompile(`token=(\S+)`)
+
+func (m *mailerMock) lastToken(t *testing.T) string {
+	require.NotEmpty(t, m.sent)
+	match := tokenInLink.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	require.Len(t, match, 2)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+func newTestRecovery(t *testing.T) (*Recovery, *InMemoryStore, *mailerMock, User) {
+	store := NewInMemoryStore()
+	u, err := store.CreateUser("username1", hashPassword("old password"))
+	require.NoError(t, err)
+	u, err = store.UpdateUser(u.ID, func(u *User) error {
+		u.Email = "user@example.com"
+		return nil
+	})
+	require.NoError(t, err)
+	mailer := &mailerMock{}
+	links := Links{ResetPassword: "https://notes.example.com/reset", VerifyEmail: "https://notes.example.com/verify"}
+	return NewRecovery(store, mailer, []byte("key"), links), store, mailer, u
+}
+
+func TestResetPassword(t *testing.T) {
+	t.Run("should not tell about unknown users", func(t *testing.T) {
+		recovery, _, mailer, _ := newTestRecovery(t)
+		require.NoError(t, recovery.RequestPasswordReset("nobody"))
+		require.Empty(t, mailer.sent)
+	})
+
+	t.Run("should reset password once", func(t *testing.T) {
+		recovery, store, mailer, u := newTestRecovery(t)
+		session, _ := jwt.CreateToken(u.ID)
+		require.NoError(t, recovery.RequestPasswordReset("USER@example.com"))
+		require.Equal(t, "user@example.com", mailer.sent[0].To)
+		token := mailer.lastToken(t)
+
+		reset, err := recovery.ResetPassword(token, "new password")
+		require.NoError(t, err)
+		require.Equal(t, u.ID, reset.ID)
+		u, err = store.FindUserByID(u.ID)
+		require.NoError(t, err)
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("new password")))
+		require.True(t, u.EmailVerified)
+		_, err = jwt.ParseToken(session)
+		require.ErrorIs(t, err, jwt.ErrJwtParse)
+
+		_, err = recovery.ResetPassword(token, "third password")
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("should reject token of another purpose or key", func(t *testing.T) {
+		recovery, _, mailer, u := newTestRecovery(t)
+		require.NoError(t, recovery.SendVerification(u.ID))
+		_, err := recovery.ResetPassword(mailer.lastToken(t), "new password")
+		require.ErrorIs(t, err, ErrInvalidToken)
+
+		other := NewRecovery(recovery.store, mailer, []byte("other key"), recovery.links)
+		require.NoError(t, other.RequestPasswordReset(u.Username))
+		_, err = recovery.ResetPassword(mailer.lastToken(t), "new password")
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestForceReset(t *testing.T) {
+	recovery, store, mailer, u := newTestRecovery(t)
+	session, _ := jwt.CreateToken(u.ID)
+
+	require.NoError(t, recovery.ForceReset(u.ID))
+	stored, err := store.FindUserByID(u.ID)
+	require.NoError(t, err)
+	require.Error(t, bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte("old password")))
+	_, err = jwt.ParseToken(session)
+	require.ErrorIs(t, err, jwt.ErrJwtParse)
+	_, err = recovery.ResetPassword(mailer.lastToken(t), "new password")
+	require.NoError(t, err)
+
+	_, err = store.UpdateUser(stored.ID, func(u *User) error {
+		u.Email = ""
+		return nil
+	})
+	require.NoError(t, err)
+	require.ErrorIs(t, recovery.ForceReset(u.ID), ErrNoEmail)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	t.Run("should verify email once", func(t *testing.T) {
+		recovery, _, mailer, u := newTestRecovery(t)
+		require.NoError(t, recovery.SendVerification(u.ID))
+		token := mailer.lastToken(t)
+
+		verified, err := recovery.VerifyEmail(token)
+		require.NoError(t, err)
+		require.True(t, verified.EmailVerified)
+		_, err = recovery.VerifyEmail(token)
+		require.ErrorIs(t, err, ErrTokenUsed)
+	})
+
+	t.Run("should reject token for changed email", func(t *testing.T) {
+		recovery, store, mailer, u := newTestRecovery(t)
+		require.NoError(t, recovery.SendVerification(u.ID))
+		email := "new@example.com"
+		_, err := store.UpdateUser(u.ID, func(u *User) error {
+			*u = ProfileUpdate{Email: &email}.Apply(*u)
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, err = recovery.VerifyEmail(mailer.lastToken(t))
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("should return ErrNoEmail", func(t *testing.T) {
+		recovery, store, _, _ := newTestRecovery(t)
+		u, err := store.CreateUser("username2", "hash")
+		require.NoError(t, err)
+		require.ErrorIs(t, recovery.SendVerification(u.ID), ErrNoEmail)
+	})
+}
+
+func TestReadTokenExpired(t *testing.T) {
+	token := signToken([]byte("key"), "1", purposeReset, "", mustParse(t, "2022-10-01T00:00:00Z"))
+	_, err := readToken(token, purposeReset, mustParse(t, "2022-10-01T00:00:01Z"))
+	require.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func mustParse(t *testing.T, value string) time.Time {
+	at, err := time.Parse(time.RFC3339, value)
+	require.NoError(t, err)
+	return at
+}
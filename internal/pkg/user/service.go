@@ -2,6 +2,10 @@ package user
 
 import (
 	"fmt"
+	"note-service/internal/pkg/jwt"
+	"sort"
+	"strings"
+	"sync"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -9,14 +13,30 @@ import (
 type store interface {
 	CreateUser(name, password string) (User, error)
 	FindUserByName(name string) (User, error)
+	FindUserByID(id string) (User, error)
+	UpdateUser(id string, update func(u *User) error) (User, error)
+	DeleteUser(id string) error
+	GetUsers() ([]*User, error)
 }
 
 type Service struct {
-	store store
+	store    store
+	throttle *Throttle
+	admins   []string
 }
 
 func NewService(store store) *Service {
-	return &Service{store: store}
+	return &Service{store: store, throttle: NewThrottle(DefaultThrottleConfig)}
+}
+
+// SetAdmins gives RoleAdmin to users signing up with these names, that is how the first admin appears
+func (s *Service) SetAdmins(names []string) {
+	s.admins = names
+}
+
+// SetThrottle replaces the default limits of failed logins
+func (s *Service) SetThrottle(throttle *Throttle) {
+	s.throttle = throttle
 }
 
 func (s *Service) SignUp(name, password string) (User, error) {
@@ -30,22 +50,203 @@ func (s *Service) SignUp(name, password string) (User, error) {
 	if err != nil {
 		return User{}, fmt.Errorf("failed to signup: %w", err)
 	}
+	for _, admin := range s.admins {
+		if strings.EqualFold(admin, name) {
+			return s.store.UpdateUser(user.ID, func(u *User) error {
+				u.Role = RoleAdmin
+				return nil
+			})
+		}
+	}
 	return user, nil
 }
 
-func (s *Service) Login(name, password string) (User, error) {
+// Login is throttled per account and per IP. Unknown users cost the same bcrypt comparison,
+// so the response time doesn't tell whether the account exists. Failures are forgotten only
+// on a login that lets the user in, for a user with 2FA when MFA.CompleteLogin accepts the code.
+func (s *Service) Login(name, password, ip string) (User, error) {
+	if err := s.throttle.Check(name, ip); err != nil {
+		return User{}, err
+	}
 	u, err := s.store.FindUserByName(name)
+	hash := u.Password
+	if err != nil {
+		hash = dummyHash()
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil || err != nil {
+		s.throttle.Fail(name, ip)
+		return User{}, ErrUserNotFound
+	}
+	if u.Disabled {
+		return User{}, ErrUserDisabled
+	}
+	if !u.TOTPEnabled {
+		s.throttle.Succeed(name)
+	}
+	return u, nil
+}
+
+// UnlockUser lifts the lockout after failed logins
+func (s *Service) UnlockUser(id string) error {
+	u, err := s.store.FindUserByID(id)
+	if err != nil {
+		return err
+	}
+	s.throttle.Unlock(u.Username)
+	return nil
+}
+
+func (s *Service) GetUser(id string) (User, error) {
+	return s.store.FindUserByID(id)
+}
+
+func (s *Service) UpdateProfile(id string, update ProfileUpdate) (User, error) {
+	return s.store.UpdateUser(id, func(u *User) error {
+		*u = update.Apply(*u)
+		return nil
+	})
+}
+
+// ChangePassword checks the old password and signs out every session of the user
+func (s *Service) ChangePassword(id, oldPassword, newPassword string) (User, error) {
+	checked, err := s.checkPassword(id, oldPassword)
+	if err != nil {
+		return User{}, err
+	}
+	hash := s.createHash(newPassword)
+	u, err := s.store.UpdateUser(id, func(u *User) error {
+		// the password checked above may have been reset meanwhile
+		if u.Password != checked.Password {
+			return ErrWrongPassword
+		}
+		u.Password = hash
+		return nil
+	})
+	if err != nil {
+		return User{}, err
+	}
+	jwt.RevokeTokens(id)
+	return u, nil
+}
+
+// DeleteUser checks the password, the store cleans up notes and other data of the user in its delete hooks
+func (s *Service) DeleteUser(id, password string) error {
+	if _, err := s.checkPassword(id, password); err != nil {
+		return err
+	}
+	if err := s.store.DeleteUser(id); err != nil {
+		return err
+	}
+	jwt.RevokeTokens(id)
+	return nil
+}
+
+// SearchUsers finds users whose name, display name or email contains the query, ordered by name.
+// It returns a page of them and the number of all found.
+func (s *Service) SearchUsers(query string, limit, offset int) ([]User, int, error) {
+	users, err := s.store.GetUsers()
+	if err != nil {
+		return nil, 0, err
+	}
+	query = strings.ToLower(query)
+	found := make([]User, 0)
+	for _, u := range users {
+		if strings.Contains(strings.ToLower(u.Username), query) || strings.Contains(strings.ToLower(u.DisplayName), query) ||
+			strings.Contains(strings.ToLower(u.Email), query) {
+			found = append(found, *u)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return strings.ToLower(found[i].Username) < strings.ToLower(found[j].Username)
+	})
+	total := len(found)
+	if offset > total {
+		offset = total
+	}
+	if limit > 0 && offset+limit < total {
+		return found[offset : offset+limit], total, nil
+	}
+	return found[offset:], total, nil
+}
+
+// SetDisabled disables or enables the user, disabling signs out every session
+func (s *Service) SetDisabled(id string, disabled bool) (User, error) {
+	u, err := s.store.UpdateUser(id, func(u *User) error {
+		u.Disabled = disabled
+		return nil
+	})
+	if err != nil {
+		return User{}, err
+	}
+	if disabled {
+		jwt.RevokeTokens(id)
+	}
+	return u, nil
+}
+
+func (s *Service) SetRole(id, role string) (User, error) {
+	if role != RoleUser && role != RoleAdmin {
+		return User{}, ErrRole
+	}
+	return s.store.UpdateUser(id, func(u *User) error {
+		u.Role = role
+		return nil
+	})
+}
+
+// IsAdmin tells whether the user is an enabled admin
+func (s *Service) IsAdmin(id string) bool {
+	u, err := s.store.FindUserByID(id)
+	return err == nil && u.Role == RoleAdmin && !u.Disabled
+}
+
+// Stats counts users by role and state
+func (s *Service) Stats() (UserStats, error) {
+	users, err := s.store.GetUsers()
+	if err != nil {
+		return UserStats{}, err
+	}
+	stats := UserStats{Users: len(users)}
+	for _, u := range users {
+		if u.Role == RoleAdmin {
+			stats.Admins++
+		}
+		if u.Disabled {
+			stats.Disabled++
+		}
+	}
+	return stats, nil
+}
+
+func (s *Service) checkPassword(id, password string) (User, error) {
+	u, err := s.store.FindUserByID(id)
 	if err != nil {
 		return User{}, err
 	}
 	if err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)); err != nil {
-		return User{}, ErrUserNotFound
+		return User{}, ErrWrongPassword
 	}
 	return u, nil
 }
 
 func (s *Service) createHash(str string) string {
-	bytePassword := []byte(str)
-	hashPassword, _ := bcrypt.GenerateFromPassword(bytePassword, bcrypt.DefaultCost)
-	return string(hashPassword)
+	return hashPassword(str)
+}
+
+func hashPassword(password string) string {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash)
+}
+
+var dummy struct {
+	sync.Once
+	hash string
+}
+
+// dummyHash is compared with passwords of unknown users
+func dummyHash() string {
+	dummy.Do(func() {
+		dummy.hash = hashPassword("not a password of any user")
+	})
+	return dummy.hash
 }
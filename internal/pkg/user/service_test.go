@@ -4,12 +4,19 @@ import (
 	"errors"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"note-service/internal/pkg/jwt"
 	"testing"
+	"time"
 )
 
 type userStoreMock struct {
 	CreateUserFunc     func(name, password string) (User, error)
 	FindUserByNameFunc func(name string) (User, error)
+	FindUserByIDFunc   func(id string) (User, error)
+	UpdateUserFunc     func(user User) (User, error)
+	DeleteUserFunc     func(id string) error
+	GetUsersFunc       func() ([]*User, error)
 }
 
 func (s *userStoreMock) CreateUser(name, password string) (User, error) {
@@ -20,6 +27,30 @@ func (s *userStoreMock) FindUserByName(name string) (User, error) {
 	return s.FindUserByNameFunc(name)
 }
 
+func (s *userStoreMock) FindUserByID(id string) (User, error) {
+	return s.FindUserByIDFunc(id)
+}
+
+// UpdateUser applies update to the user of FindUserByIDFunc and saves it with UpdateUserFunc
+func (s *userStoreMock) UpdateUser(id string, update func(u *User) error) (User, error) {
+	u, err := s.FindUserByIDFunc(id)
+	if err != nil {
+		return User{}, err
+	}
+	if err := update(&u); err != nil {
+		return User{}, err
+	}
+	return s.UpdateUserFunc(u)
+}
+
+func (s *userStoreMock) DeleteUser(id string) error {
+	return s.DeleteUserFunc(id)
+}
+
+func (s *userStoreMock) GetUsers() ([]*User, error) {
+	return s.GetUsersFunc()
+}
+
 func TestSignUp(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -111,7 +142,7 @@ func TestLogin(t *testing.T) {
 			name: "should return errUserNotFound, wrong password",
 			userStore: userStoreMock{
 				FindUserByNameFunc: func(id string) (User, error) {
-					return User{"123-123", "username1", "password1"}, nil
+					return User{ID: "123-123", Username: "username1", Password: "password1"}, nil
 				},
 			},
 			username:      uuid.NewString(),
@@ -135,7 +166,7 @@ func TestLogin(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			s := NewService(&tt.userStore)
-			u, err := s.Login(tt.username, tt.password)
+			u, err := s.Login(tt.username, tt.password, "127.0.0.1")
 			emptyUser := User{}
 			if tt.expectedUser != emptyUser {
 				require.Equal(t, u, tt.expectedUser)
@@ -147,3 +178,187 @@ func TestLogin(t *testing.T) {
 		})
 	}
 }
+
+// storedUser has password "123123123"
+var storedUser = User{ID: "123-123-123", Username: "username1", Password: "$2a$10$7Fy455pjoxYl4f3.TGiPNut/pHy/K0C93oSwqkX.pDEDxGNvplrUG"}
+
+func TestUpdateProfile(t *testing.T) {
+	s := NewService(&userStoreMock{
+		FindUserByIDFunc: func(id string) (User, error) {
+			return User{ID: id, Username: "username1", DisplayName: "Old", Locale: "en"}, nil
+		},
+		UpdateUserFunc: func(user User) (User, error) {
+			return user, nil
+		},
+	})
+	name, zone := "New", "Europe/Moscow"
+	u, err := s.UpdateProfile("123", ProfileUpdate{DisplayName: &name, TimeZone: &zone})
+	require.NoError(t, err)
+	require.Equal(t, User{ID: "123", Username: "username1", DisplayName: "New", Locale: "en", TimeZone: zone}, u)
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name          string
+		oldPassword   string
+		expectedError error
+	}{
+		{
+			name:          "should return ErrWrongPassword",
+			oldPassword:   "wrong",
+			expectedError: ErrWrongPassword,
+		},
+		{
+			name:        "should change password",
+			oldPassword: "123123123",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var saved User
+			s := NewService(&userStoreMock{
+				FindUserByIDFunc: func(id string) (User, error) {
+					return storedUser, nil
+				},
+				UpdateUserFunc: func(user User) (User, error) {
+					saved = user
+					return user, nil
+				},
+			})
+			token, _ := jwt.CreateToken(storedUser.ID)
+
+			_, err := s.ChangePassword(storedUser.ID, tt.oldPassword, "new password")
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				_, err = jwt.ParseToken(token)
+				require.NoError(t, err, "failed change keeps sessions")
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, bcrypt.CompareHashAndPassword([]byte(saved.Password), []byte("new password")))
+			_, err = jwt.ParseToken(token)
+			require.ErrorIs(t, err, jwt.ErrJwtParse)
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	deleted := ""
+	s := NewService(&userStoreMock{
+		FindUserByIDFunc: func(id string) (User, error) {
+			return storedUser, nil
+		},
+		DeleteUserFunc: func(id string) error {
+			deleted = id
+			return nil
+		},
+	})
+
+	require.ErrorIs(t, s.DeleteUser(storedUser.ID, "wrong"), ErrWrongPassword)
+	require.Empty(t, deleted)
+	require.NoError(t, s.DeleteUser(storedUser.ID, "123123123"))
+	require.Equal(t, storedUser.ID, deleted)
+}
+
+func TestLoginLockout(t *testing.T) {
+	store := NewInMemoryStore()
+	u, err := store.CreateUser("username1", hashPassword("password123"))
+	require.NoError(t, err)
+	s := NewService(store)
+	s.SetThrottle(NewThrottle(ThrottleConfig{AccountAttempts: 1, IPAttempts: 10, BaseDelay: time.Minute,
+		MaxDelay: time.Minute, LockAttempts: 2, LockDuration: time.Hour, Window: time.Hour}))
+
+	_, err = s.Login("username1", "wrong", "10.0.0.1")
+	require.ErrorIs(t, err, ErrUserNotFound)
+	_, err = s.Login("username1", "wrong", "10.0.0.1")
+	require.ErrorIs(t, err, ErrUserNotFound)
+	_, err = s.Login("username1", "password123", "10.0.0.2")
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+
+	require.NoError(t, s.UnlockUser(u.ID))
+	_, err = s.Login("username1", "password123", "10.0.0.2")
+	require.NoError(t, err)
+
+	_, err = s.Login("nobody", "password123", "10.0.0.2")
+	require.ErrorIs(t, err, ErrUserNotFound)
+
+	t.Run("disabled user should not clear failures", func(t *testing.T) {
+		_, err := s.Login("username1", "wrong", "10.0.0.3")
+		require.ErrorIs(t, err, ErrUserNotFound)
+		_, err = s.SetDisabled(u.ID, true)
+		require.NoError(t, err)
+		_, err = s.Login("username1", "password123", "10.0.0.3")
+		require.ErrorIs(t, err, ErrUserDisabled)
+		_, err = s.SetDisabled(u.ID, false)
+		require.NoError(t, err)
+
+		_, err = s.Login("username1", "wrong", "10.0.0.3")
+		require.ErrorIs(t, err, ErrUserNotFound)
+		_, err = s.Login("username1", "password123", "10.0.0.3")
+		require.ErrorIs(t, err, ErrTooManyAttempts)
+	})
+}
+
+func TestSearchUsers(t *testing.T) {
+	store := NewInMemoryStore()
+	for _, name := range []string{"charlie", "alice", "bob", "alicia"} {
+		_, err := store.CreateUser(name, "hash")
+		require.NoError(t, err)
+	}
+	s := NewService(store)
+
+	users, total, err := s.SearchUsers("ALI", 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	require.Equal(t, "alice", users[0].Username)
+	require.Equal(t, "alicia", users[1].Username)
+
+	users, total, err = s.SearchUsers("", 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, 4, total)
+	require.Equal(t, "alicia", users[0].Username)
+	require.Equal(t, "bob", users[1].Username)
+
+	users, _, err = s.SearchUsers("", 2, 10)
+	require.NoError(t, err)
+	require.Empty(t, users)
+}
+
+func TestAdminActions(t *testing.T) {
+	store := NewInMemoryStore()
+	s := NewService(store)
+	s.SetAdmins([]string{"Root"})
+
+	admin, err := s.SignUp("root", "password123")
+	require.NoError(t, err)
+	require.Equal(t, RoleAdmin, admin.Role)
+	require.True(t, s.IsAdmin(admin.ID))
+	u, err := s.SignUp("username1", "password123")
+	require.NoError(t, err)
+	require.Equal(t, RoleUser, u.Role)
+	require.False(t, s.IsAdmin(u.ID))
+
+	session, _ := jwt.CreateToken(u.ID)
+	_, err = s.SetDisabled(u.ID, true)
+	require.NoError(t, err)
+	_, err = jwt.ParseToken(session)
+	require.ErrorIs(t, err, jwt.ErrJwtParse)
+	_, err = s.Login("username1", "password123", "127.0.0.1")
+	require.ErrorIs(t, err, ErrUserDisabled)
+
+	stats, err := s.Stats()
+	require.NoError(t, err)
+	require.Equal(t, UserStats{Users: 2, Admins: 1, Disabled: 1}, stats)
+
+	_, err = s.SetDisabled(u.ID, false)
+	require.NoError(t, err)
+	_, err = s.Login("username1", "password123", "127.0.0.1")
+	require.NoError(t, err)
+
+	_, err = s.SetRole(u.ID, "owner")
+	require.ErrorIs(t, err, ErrRole)
+	u, err = s.SetRole(u.ID, RoleAdmin)
+	require.NoError(t, err)
+	require.True(t, s.IsAdmin(u.ID))
+}
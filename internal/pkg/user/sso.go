@@ -0,0 +1,158 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxUsernameTries is how many numbered variants of a taken username are tried before a random one
+const maxUsernameTries = 20
+
+type ssoStore interface {
+	CreateUser(name, password string) (User, error)
+	FindUserByID(id string) (User, error)
+	UpdateUser(id string, update func(u *User) error) (User, error)
+	LinkIdentity(identity ExternalIdentity) (ExternalIdentity, error)
+	FindIdentity(provider, subject string) (ExternalIdentity, error)
+	GetUserIdentities(userID string) ([]ExternalIdentity, error)
+}
+
+// ExternalProfile is what the provider tells about the user, it fills the user created on first login
+type ExternalProfile struct {
+	Username      string
+	DisplayName   string
+	Email         string
+	EmailVerified bool
+}
+
+// SSO signs in users by identities of OpenID Connect providers. A new identity gets a new user,
+// it is never matched to an existing user by email, an existing user links identities explicitly.
+type SSO struct {
+	store ssoStore
+	// mu makes the first login of an identity create one user only
+	mu sync.Mutex
+}
+
+func NewSSO(store ssoStore) *SSO {
+	return &SSO{store: store}
+}
+
+// Login returns the user linked to the identity and creates one on the first login.
+// The created user has a random password, they can set their own by the password reset.
+func (s *SSO) Login(identity ExternalIdentity, profile ExternalProfile) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	linked, err := s.store.FindIdentity(identity.Provider, identity.Subject)
+	if err == nil {
+		u, err := s.store.FindUserByID(linked.UserID)
+		if err != nil {
+			return User{}, err
+		}
+		if u.Disabled {
+			return User{}, ErrUserDisabled
+		}
+		return u, nil
+	}
+	if !errors.Is(err, ErrNoIdentity) {
+		return User{}, err
+	}
+
+	u, err := s.createUser(profile)
+	if err != nil {
+		return User{}, err
+	}
+	identity.UserID = u.ID
+	if _, err := s.store.LinkIdentity(identity); err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+// Link links the identity to a signed in user
+func (s *SSO) Link(userID string, identity ExternalIdentity) (ExternalIdentity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	identity.UserID = userID
+	return s.store.LinkIdentity(identity)
+}
+
+func (s *SSO) GetIdentities(userID string) ([]ExternalIdentity, error) {
+	return s.store.GetUserIdentities(userID)
+}
+
+// createUser takes the username from the profile or the email, a taken name gets a number.
+// A verified email is kept unless another user has it, so password reset by email stays unambiguous.
+func (s *SSO) createUser(profile ExternalProfile) (User, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return User{}, err
+	}
+	password := hashPassword(hex.EncodeToString(raw))
+
+	base := externalUsername(profile)
+	var u User
+	var err error
+	for i := 1; ; i++ {
+		name := base
+		switch {
+		case i > maxUsernameTries:
+			name = base + "-" + hex.EncodeToString(raw[:4])
+		case i > 1:
+			name = base + "-" + strconv.Itoa(i)
+		}
+		u, err = s.store.CreateUser(name, password)
+		if !errors.Is(err, ErrUsedUsername) || i > maxUsernameTries {
+			break
+		}
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	setProfile := func(withEmail bool) func(u *User) error {
+		return func(u *User) error {
+			u.DisplayName = profile.DisplayName
+			if withEmail {
+				u.Email = profile.Email
+				u.EmailVerified = true
+			}
+			return nil
+		}
+	}
+	withEmail := profile.Email != "" && profile.EmailVerified
+	updated, err := s.store.UpdateUser(u.ID, setProfile(withEmail))
+	if errors.Is(err, ErrUsedEmail) {
+		// the email stays with the user who has it
+		return s.store.UpdateUser(u.ID, setProfile(false))
+	}
+	return updated, err
+}
+
+// externalUsername keeps letters, digits, dots, dashes and underscores of the preferred name,
+// short names are padded to the length sign-up requires
+func externalUsername(profile ExternalProfile) string {
+	name := profile.Username
+	if name == "" {
+		name, _, _ = strings.Cut(profile.Email, "@")
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return -1
+	}, name)
+	if name == "" {
+		name = "user"
+	}
+	for len(name) < 4 {
+		name += "_"
+	}
+	return name
+}
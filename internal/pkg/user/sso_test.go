@@ -0,0 +1,83 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSOLogin(t *testing.T) {
+	store := NewInMemoryStore()
+	sso := NewSSO(store)
+	existing, err := store.CreateUser("alice", hashPassword("password123"))
+	require.NoError(t, err)
+	existing, err = store.UpdateUser(existing.ID, func(u *User) error {
+		u.Email = "alice@example.com"
+		return nil
+	})
+	require.NoError(t, err)
+
+	identity := ExternalIdentity{Provider: "corp", Subject: "1"}
+	profile := ExternalProfile{Username: "alice", DisplayName: "Alice", Email: "alice@example.com", EmailVerified: true}
+
+	t.Run("should create user on first login", func(t *testing.T) {
+		u, err := sso.Login(identity, profile)
+		require.NoError(t, err)
+		require.NotEqual(t, existing.ID, u.ID, "users are never matched by email")
+		require.Equal(t, "alice-2", u.Username)
+		require.Equal(t, "Alice", u.DisplayName)
+		require.Empty(t, u.Email, "email of another user is not copied")
+		require.Equal(t, RoleUser, u.Role)
+
+		again, err := sso.Login(identity, ExternalProfile{Username: "renamed"})
+		require.NoError(t, err)
+		require.Equal(t, u.ID, again.ID)
+
+		identities, err := sso.GetIdentities(u.ID)
+		require.NoError(t, err)
+		require.Len(t, identities, 1)
+	})
+
+	t.Run("should keep verified email", func(t *testing.T) {
+		u, err := sso.Login(ExternalIdentity{Provider: "corp", Subject: "2"}, ExternalProfile{Email: "b@example.com", EmailVerified: true})
+		require.NoError(t, err)
+		require.Equal(t, "b___", u.Username)
+		require.Equal(t, "b@example.com", u.Email)
+		require.True(t, u.EmailVerified)
+
+		u, err = sso.Login(ExternalIdentity{Provider: "corp", Subject: "3"}, ExternalProfile{Username: "c d!", Email: "c@example.com"})
+		require.NoError(t, err)
+		require.Equal(t, "cd__", u.Username)
+		require.Empty(t, u.Email)
+	})
+
+	t.Run("should link identity once", func(t *testing.T) {
+		other := ExternalIdentity{Provider: "other", Subject: "1"}
+		linked, err := sso.Link(existing.ID, other)
+		require.NoError(t, err)
+		require.Equal(t, existing.ID, linked.UserID)
+
+		u, err := sso.Login(other, profile)
+		require.NoError(t, err)
+		require.Equal(t, existing.ID, u.ID)
+
+		_, err = sso.Link(existing.ID, identity)
+		require.ErrorIs(t, err, ErrIdentityLinked)
+	})
+
+	t.Run("should reject disabled user", func(t *testing.T) {
+		_, err := store.UpdateUser(existing.ID, func(u *User) error {
+			u.Disabled = true
+			return nil
+		})
+		require.NoError(t, err)
+		_, err = sso.Login(ExternalIdentity{Provider: "other", Subject: "1"}, profile)
+		require.ErrorIs(t, err, ErrUserDisabled)
+	})
+
+	t.Run("should forget identities of deleted user", func(t *testing.T) {
+		require.NoError(t, store.DeleteUser(existing.ID))
+		_, err := store.FindIdentity("other", "1")
+		require.ErrorIs(t, err, ErrNoIdentity)
+	})
+}
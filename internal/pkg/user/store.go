@@ -1,19 +1,35 @@
 package user
 
 import (
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type InMemoryStore struct {
 	sync.RWMutex
-	users map[string]User
+	users         map[string]User
+	deleteHooks   []func(User)
+	usedTokens    map[string]time.Time
+	recoveryCodes map[string]map[string]struct{}
+	identities    map[identityKey]ExternalIdentity
+}
+
+type identityKey struct {
+	provider string
+	subject  string
 }
 
 func NewInMemoryStore() *InMemoryStore {
-	return &InMemoryStore{users: make(map[string]User)}
+	return &InMemoryStore{
+		users:         make(map[string]User),
+		usedTokens:    make(map[string]time.Time),
+		recoveryCodes: make(map[string]map[string]struct{}),
+		identities:    make(map[identityKey]ExternalIdentity),
+	}
 }
 
 func (store *InMemoryStore) CreateUser(name, password string) (User, error) {
@@ -24,9 +40,11 @@ func (store *InMemoryStore) CreateUser(name, password string) (User, error) {
 		return User{}, ErrUsedUsername
 	}
 	user := User{
-		ID:       uuid.NewString(),
-		Username: name,
-		Password: password,
+		ID:        uuid.NewString(),
+		Username:  name,
+		Password:  password,
+		Role:      RoleUser,
+		CreatedAt: time.Now().UTC(),
 	}
 	store.users[user.ID] = user
 	return user, nil
@@ -42,6 +60,60 @@ func (store *InMemoryStore) FindUserByID(id string) (User, error) {
 	return User{}, ErrUserNotFound
 }
 
+// OnDelete registers a hook called after a user is deleted, it cleans up data of the user elsewhere
+func (store *InMemoryStore) OnDelete(hook func(User)) {
+	store.Lock()
+	defer store.Unlock()
+
+	store.deleteHooks = append(store.deleteHooks, hook)
+}
+
+// UpdateUser changes the user with update under the store lock, so concurrent updates of different fields
+// don't overwrite each other. Nothing is saved when update returns an error, update must not use the store.
+// An email belongs to one user only, so password reset by email finds exactly one account.
+func (store *InMemoryStore) UpdateUser(id string, update func(u *User) error) (User, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	u, ok := store.users[id]
+	if !ok {
+		return User{}, ErrUserNotFound
+	}
+	oldEmail := u.Email
+	if err := update(&u); err != nil {
+		return User{}, err
+	}
+	if u.Email != "" && !strings.EqualFold(u.Email, oldEmail) {
+		if other, err := store.findUserByEmail(u.Email); err == nil && other.ID != id {
+			return User{}, ErrUsedEmail
+		}
+	}
+	store.users[id] = u
+	return u, nil
+}
+
+func (store *InMemoryStore) DeleteUser(id string) error {
+	store.Lock()
+	u, ok := store.users[id]
+	delete(store.users, id)
+	delete(store.recoveryCodes, id)
+	for k, identity := range store.identities {
+		if identity.UserID == id {
+			delete(store.identities, k)
+		}
+	}
+	hooks := store.deleteHooks
+	store.Unlock()
+
+	if !ok {
+		return ErrUserNotFound
+	}
+	for _, hook := range hooks {
+		hook(u)
+	}
+	return nil
+}
+
 func createPointer(u User) *User {
 	return &u
 }
@@ -67,6 +139,116 @@ func (store *InMemoryStore) FindUserByName(name string) (User, error) {
 	return store.findUserByName(name)
 }
 
+func (store *InMemoryStore) FindUserByEmail(email string) (User, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	return store.findUserByEmail(email)
+}
+
+// findUserByEmail compares emails ignoring case and isn't thread-safe
+func (store *InMemoryStore) findUserByEmail(email string) (User, error) {
+	for _, u := range store.users {
+		if u.Email != "" && strings.EqualFold(email, u.Email) {
+			return u, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+// UseToken remembers the token until it expires, a token is accepted only the first time
+func (store *InMemoryStore) UseToken(nonce string, expiresAt time.Time) error {
+	store.Lock()
+	defer store.Unlock()
+
+	now := time.Now()
+	for n, until := range store.usedTokens {
+		if now.After(until) {
+			delete(store.usedTokens, n)
+		}
+	}
+	if _, ok := store.usedTokens[nonce]; ok {
+		return ErrTokenUsed
+	}
+	store.usedTokens[nonce] = expiresAt
+	return nil
+}
+
+// SetRecoveryCodes replaces recovery codes of the user, nil removes them
+func (store *InMemoryStore) SetRecoveryCodes(userID string, hashes []string) {
+	store.Lock()
+	defer store.Unlock()
+
+	if len(hashes) == 0 {
+		delete(store.recoveryCodes, userID)
+		return
+	}
+	codes := make(map[string]struct{}, len(hashes))
+	for _, h := range hashes {
+		codes[h] = struct{}{}
+	}
+	store.recoveryCodes[userID] = codes
+}
+
+// UseRecoveryCode removes the code, so it works only once
+func (store *InMemoryStore) UseRecoveryCode(userID, hash string) error {
+	store.Lock()
+	defer store.Unlock()
+
+	if _, ok := store.recoveryCodes[userID][hash]; !ok {
+		return ErrInvalidCode
+	}
+	delete(store.recoveryCodes[userID], hash)
+	return nil
+}
+
+// LinkIdentity links the identity to its UserID, an identity belongs to one user only
+func (store *InMemoryStore) LinkIdentity(identity ExternalIdentity) (ExternalIdentity, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	k := identityKey{identity.Provider, identity.Subject}
+	if linked, ok := store.identities[k]; ok {
+		if linked.UserID != identity.UserID {
+			return ExternalIdentity{}, ErrIdentityLinked
+		}
+		return linked, nil
+	}
+	if _, ok := store.users[identity.UserID]; !ok {
+		return ExternalIdentity{}, ErrUserNotFound
+	}
+	identity.LinkedAt = time.Now().UTC()
+	store.identities[k] = identity
+	return identity, nil
+}
+
+func (store *InMemoryStore) FindIdentity(provider, subject string) (ExternalIdentity, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	if identity, ok := store.identities[identityKey{provider, subject}]; ok {
+		return identity, nil
+	}
+	return ExternalIdentity{}, ErrNoIdentity
+}
+
+// GetUserIdentities returns identities of the user, oldest first
+func (store *InMemoryStore) GetUserIdentities(userID string) ([]ExternalIdentity, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	res := make([]ExternalIdentity, 0)
+	for _, identity := range store.identities {
+		if identity.UserID == userID {
+			res = append(res, identity)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LinkedAt.Before(res[j].LinkedAt)
+	})
+	return res, nil
+}
+
 // findUserByName find user and isn't thread-safe
 func (store *InMemoryStore) findUserByName(name string) (User, error) {
 	for _, u := range store.users {
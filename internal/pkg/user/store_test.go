@@ -141,3 +141,73 @@ func TestCreateUser(t *testing.T) {
 		require.NotEmpty(t, actual.ID)
 	})
 }
+
+func TestUpdateUser(t *testing.T) {
+	store := NewInMemoryStore()
+	u, err := store.CreateUser("username1", "hash")
+	require.NoError(t, err)
+
+	t.Run("should change only fields set by update", func(t *testing.T) {
+		stale := u
+		_, err := store.UpdateUser(u.ID, func(u *User) error {
+			u.Password = "reset"
+			return nil
+		})
+		require.NoError(t, err)
+		updated, err := store.UpdateUser(stale.ID, func(u *User) error {
+			u.DisplayName = "User"
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "reset", updated.Password)
+		require.Equal(t, "User", updated.DisplayName)
+	})
+
+	t.Run("should not save when update fails", func(t *testing.T) {
+		_, err := store.UpdateUser(u.ID, func(u *User) error {
+			u.Role = RoleAdmin
+			return ErrRole
+		})
+		require.ErrorIs(t, err, ErrRole)
+		stored, _ := store.FindUserByID(u.ID)
+		require.Equal(t, RoleUser, stored.Role)
+
+		_, err = store.UpdateUser("unknown", func(u *User) error { return nil })
+		require.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("should reject email of another user", func(t *testing.T) {
+		other, err := store.CreateUser("username2", "hash")
+		require.NoError(t, err)
+		setEmail := func(email string) func(u *User) error {
+			return func(u *User) error {
+				u.Email = email
+				return nil
+			}
+		}
+		_, err = store.UpdateUser(u.ID, setEmail("user@example.com"))
+		require.NoError(t, err)
+		_, err = store.UpdateUser(u.ID, setEmail("User@example.com"))
+		require.NoError(t, err, "own email may change case")
+
+		_, err = store.UpdateUser(other.ID, setEmail("USER@example.com"))
+		require.ErrorIs(t, err, ErrUsedEmail)
+		stored, _ := store.FindUserByID(other.ID)
+		require.Empty(t, stored.Email)
+	})
+}
+
+func TestDeleteUserHooks(t *testing.T) {
+	store := NewInMemoryStore()
+	var deleted []User
+	store.OnDelete(func(u User) { deleted = append(deleted, u) })
+	u, err := store.CreateUser(uuid.NewString(), uuid.NewString())
+	require.NoError(t, err)
+
+	require.NoError(t, store.DeleteUser(u.ID))
+	require.Equal(t, []User{u}, deleted)
+	_, err = store.FindUserByID(u.ID)
+	require.ErrorIs(t, err, ErrUserNotFound)
+	require.ErrorIs(t, store.DeleteUser(u.ID), ErrUserNotFound)
+	require.Len(t, deleted, 1)
+}
@@ -0,0 +1,164 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrTooManyAttempts = errors.New("too many failed login attempts, try again later")
+
+// ThrottleError is returned while logins are blocked, it matches ErrTooManyAttempts
+type ThrottleError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *ThrottleError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+type ThrottleConfig struct {
+	// AccountAttempts and IPAttempts are failures allowed before backoff starts
+	AccountAttempts int
+	IPAttempts      int
+	// BaseDelay is the first backoff, it doubles with every next failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockAttempts failures lock the account for LockDuration
+	LockAttempts int
+	LockDuration time.Duration
+	// Window is how long failures are remembered after the last one
+	Window time.Duration
+}
+
+var DefaultThrottleConfig = ThrottleConfig{
+	AccountAttempts: 3,
+	IPAttempts:      20,
+	BaseDelay:       time.Second,
+	MaxDelay:        5 * time.Minute,
+	LockAttempts:    10,
+	LockDuration:    30 * time.Minute,
+	Window:          time.Hour,
+}
+
+type attempts struct {
+	failures     int
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// Throttle counts failed logins per account and per IP. Accounts are keyed by the login name,
+// so unknown names are throttled the same way and don't reveal whether the account exists.
+type Throttle struct {
+	mu        sync.Mutex
+	config    ThrottleConfig
+	accounts  map[string]*attempts
+	ips       map[string]*attempts
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewThrottle(config ThrottleConfig) *Throttle {
+	return &Throttle{
+		config:   config,
+		accounts: make(map[string]*attempts),
+		ips:      make(map[string]*attempts),
+		now:      time.Now,
+	}
+}
+
+// Check returns *ThrottleError when the account or the IP is blocked
+func (t *Throttle) Check(name, ip string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	var wait time.Duration
+	for _, a := range []*attempts{t.accounts[accountKey(name)], t.ips[ip]} {
+		if a != nil && a.blockedUntil.After(now) && a.blockedUntil.Sub(now) > wait {
+			wait = a.blockedUntil.Sub(now)
+		}
+	}
+	if wait > 0 {
+		return &ThrottleError{RetryAfter: wait}
+	}
+	return nil
+}
+
+func (t *Throttle) Fail(name, ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if now.Sub(t.lastSweep) > t.config.Window {
+		t.sweep(now)
+	}
+	a := t.fail(t.accounts, accountKey(name), t.config.AccountAttempts, now)
+	if a.failures >= t.config.LockAttempts {
+		a.blockedUntil = now.Add(t.config.LockDuration)
+	}
+	t.fail(t.ips, ip, t.config.IPAttempts, now)
+}
+
+// Succeed forgets failures of the account, failures of the IP stay until the window passes
+func (t *Throttle) Succeed(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.accounts, accountKey(name))
+}
+
+// Unlock lifts the lockout of the account
+func (t *Throttle) Unlock(name string) {
+	t.Succeed(name)
+}
+
+func (t *Throttle) fail(m map[string]*attempts, key string, free int, now time.Time) *attempts {
+	a, ok := m[key]
+	if !ok || t.expired(a, now) {
+		a = &attempts{}
+		m[key] = a
+	}
+	a.failures++
+	a.last = now
+	if a.failures > free {
+		a.blockedUntil = now.Add(backoff(t.config.BaseDelay, t.config.MaxDelay, a.failures-free-1))
+	}
+	return a
+}
+
+func (t *Throttle) expired(a *attempts, now time.Time) bool {
+	return now.Sub(a.last) > t.config.Window && !a.blockedUntil.After(now)
+}
+
+// sweep drops forgotten failures, so guessing random names doesn't grow the maps forever
+func (t *Throttle) sweep(now time.Time) {
+	for _, m := range []map[string]*attempts{t.accounts, t.ips} {
+		for key, a := range m {
+			if t.expired(a, now) {
+				delete(m, key)
+			}
+		}
+	}
+	t.lastSweep = now
+}
+
+func backoff(base, max time.Duration, n int) time.Duration {
+	d := base
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+func accountKey(name string) string {
+	return strings.ToLower(name)
+}
@@ -0,0 +1,72 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestThrottle() (*Throttle, *time.Time) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	throttle := NewThrottle(ThrottleConfig{
+		AccountAttempts: 2,
+		IPAttempts:      4,
+		BaseDelay:       time.Second,
+		MaxDelay:        3 * time.Second,
+		LockAttempts:    6,
+		LockDuration:    time.Hour,
+		Window:          2 * time.Hour,
+	})
+	throttle.now = func() time.Time { return now }
+	return throttle, &now
+}
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	var throttleErr *ThrottleError
+	require.ErrorAs(t, err, &throttleErr)
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+	return throttleErr.RetryAfter
+}
+
+func TestThrottleBackoff(t *testing.T) {
+	throttle, now := newTestThrottle()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, throttle.Check("User1", "10.0.0.1"))
+		throttle.Fail("User1", "10.0.0.1")
+	}
+	require.NoError(t, throttle.Check("user1", "10.0.0.1"))
+
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		throttle.Fail("user1", "10.0.0.2")
+		require.Equal(t, expected, retryAfter(t, throttle.Check("user1", "10.0.0.3")))
+		*now = now.Add(expected)
+		require.NoError(t, throttle.Check("user1", "10.0.0.3"))
+	}
+
+	throttle.Fail("user1", "10.0.0.2")
+	require.Equal(t, time.Hour, retryAfter(t, throttle.Check("user1", "10.0.0.3")))
+
+	throttle.Unlock("USER1")
+	require.NoError(t, throttle.Check("user1", "10.0.0.3"))
+}
+
+func TestThrottleIP(t *testing.T) {
+	throttle, now := newTestThrottle()
+
+	for i := 0; i < 5; i++ {
+		throttle.Fail("user"+string(rune('a'+i)), "10.0.0.1")
+	}
+	require.Equal(t, time.Second, retryAfter(t, throttle.Check("other", "10.0.0.1")))
+	require.NoError(t, throttle.Check("other", "10.0.0.2"))
+
+	throttle.Succeed("usere")
+	require.Error(t, throttle.Check("other", "10.0.0.1"))
+
+	*now = now.Add(3 * time.Hour)
+	throttle.Fail("other", "10.0.0.1")
+	require.NoError(t, throttle.Check("other", "10.0.0.1"))
+	require.Len(t, throttle.ips, 1)
+	require.Len(t, throttle.accounts, 1)
+}
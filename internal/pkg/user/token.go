@@ -0,0 +1,66 @@
+package user
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	purposeReset  = "reset"
+	purposeVerify = "verify"
+)
+
+type tokenPayload struct {
+	UserID    string `json:"u"`
+	Purpose   string `json:"p"`
+	ExpiresAt int64  `json:"e"`
+	Nonce     string `json:"n"`
+}
+
+// signToken makes payload.signature, the signature also covers state which isn't in the token:
+// the password hash for reset and the email for verification, so a token dies when they change
+func signToken(key []byte, userID, purpose, state string, expiresAt time.Time) string {
+	payload, _ := json.Marshal(tokenPayload{UserID: userID, Purpose: purpose, ExpiresAt: expiresAt.Unix(), Nonce: uuid.NewString()})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(tokenSignature(key, encoded, state))
+}
+
+// readToken decodes the payload without checking the signature, check it with verifyToken
+func readToken(token, purpose string, now time.Time) (tokenPayload, error) {
+	encoded, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return tokenPayload{}, ErrInvalidToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return tokenPayload{}, ErrInvalidToken
+	}
+	var p tokenPayload
+	if err := json.Unmarshal(data, &p); err != nil || p.Purpose != purpose {
+		return tokenPayload{}, ErrInvalidToken
+	}
+	if now.Unix() > p.ExpiresAt {
+		return tokenPayload{}, ErrTokenExpired
+	}
+	return p, nil
+}
+
+func verifyToken(key []byte, token, state string) bool {
+	encoded, signature, _ := strings.Cut(token, ".")
+	got, err := base64.RawURLEncoding.DecodeString(signature)
+	return err == nil && hmac.Equal(got, tokenSignature(key, encoded, state))
+}
+
+func tokenSignature(key []byte, encoded, state string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encoded))
+	mac.Write([]byte{0})
+	mac.Write([]byte(state))
+	return mac.Sum(nil)
+}